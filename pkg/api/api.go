@@ -387,7 +387,7 @@ func (hs *HTTPServer) registerRoutes() {
 		})
 
 		apiRoute.Get("/alert-notifiers", reqEditorRole, routing.Wrap(
-			GetAlertNotifiers(hs.Alertmanager != nil && hs.Cfg.IsNgAlertEnabled())),
+			GetAlertNotifiers(hs.MultiOrgAlertmanager != nil && hs.Cfg.IsNgAlertEnabled())),
 		)
 
 		apiRoute.Group("/alert-notifications", func(alertNotifications routing.RouteRegister) {
@@ -432,6 +432,14 @@ func (hs *HTTPServer) registerRoutes() {
 
 			// Some channels may have info
 			liveRoute.Get("/info/*", routing.Wrap(hs.Live.HandleInfoHTTP))
+
+			// Rules restricting the access to the channels
+			liveRoute.Group("/channel-rules", func(rulesRoute routing.RouteRegister) {
+				rulesRoute.Get("/", routing.Wrap(hs.Live.HandleChannelRulesListHTTP))
+				rulesRoute.Post("/", bind(models.CreateLiveChannelRuleCommand{}), routing.Wrap(hs.Live.HandleChannelRuleCreateHTTP))
+				rulesRoute.Put("/:id", bind(models.UpdateLiveChannelRuleCommand{}), routing.Wrap(hs.Live.HandleChannelRuleUpdateHTTP))
+				rulesRoute.Delete("/:id", routing.Wrap(hs.Live.HandleChannelRuleDeleteHTTP))
+			}, reqOrgAdmin)
 		})
 
 		// short urls
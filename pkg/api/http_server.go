@@ -102,7 +102,7 @@ type HTTPServer struct {
 	PluginDashboardService *plugindashboards.Service               `inject:""`
 	AlertEngine            *alerting.AlertEngine                   `inject:""`
 	LoadSchemaService      *schemaloader.SchemaLoaderService       `inject:""`
-	Alertmanager           *notifier.Alertmanager                  `inject:""`
+	MultiOrgAlertmanager   *notifier.MultiOrgAlertmanager          `inject:""`
 	LibraryPanelService    librarypanels.Service                   `inject:""`
 	LibraryElementService  libraryelements.Service                 `inject:""`
 	Listener               net.Listener
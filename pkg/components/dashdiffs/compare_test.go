@@ -0,0 +1,34 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestCalculateJSONDiff(t *testing.T) {
+	base, err := simplejson.NewJson([]byte(`{"title": "a rule", "for": "5m"}`))
+	require.NoError(t, err)
+	changed, err := simplejson.NewJson([]byte(`{"title": "a rule", "for": "10m"}`))
+	require.NoError(t, err)
+
+	t.Run("computes a delta", func(t *testing.T) {
+		result, err := CalculateJSONDiff(base, changed, DiffDelta)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"for": ["5m", "10m"]}`, string(result.Delta))
+	})
+
+	t.Run("renders a basic diff", func(t *testing.T) {
+		result, err := CalculateJSONDiff(base, changed, DiffBasic)
+		require.NoError(t, err)
+		assert.Contains(t, string(result.Delta), "10m")
+	})
+
+	t.Run("identical documents have no diff", func(t *testing.T) {
+		_, err := CalculateJSONDiff(base, base, DiffJSON)
+		require.ErrorIs(t, err, ErrNilDiff)
+	})
+}
@@ -0,0 +1,306 @@
+package expr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+// queryCacheSweepInterval is the minimum interval between two removals of the expired entries of a QueryCache.
+const queryCacheSweepInterval = time.Minute
+
+// queryCacheQueryTimeout is the timeout of the data source queries sent by a QueryCache. The queries are not
+// canceled with the context of the caller sending them, as their response is shared with the merged callers.
+const queryCacheQueryTimeout = 30 * time.Second
+
+// QueryCache caches the responses of the data source queries of expression pipelines for a short time,
+// so that pipelines sharing the same queries, such as alert rules evaluated at the same interval,
+// query the data source once. Concurrent executions of the same query are also merged.
+// It is safe for concurrent use.
+type QueryCache struct {
+	mtx       sync.Mutex
+	entries   map[string]queryCacheEntry
+	lastSweep time.Time
+	inflight  singleflight.Group
+	now       func() time.Time
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+type queryCacheEntry struct {
+	resp      *backend.QueryDataResponse
+	expiresAt time.Time
+}
+
+// NewQueryCache returns an empty cache registering its metrics with r.
+func NewQueryCache(r prometheus.Registerer) *QueryCache {
+	return &QueryCache{
+		entries: make(map[string]queryCacheEntry),
+		now:     time.Now,
+		hits: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "expressions",
+			Name:      "query_cache_hits_total",
+			Help:      "The total number of data source queries of expressions answered from the cache.",
+		}),
+		misses: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "expressions",
+			Name:      "query_cache_misses_total",
+			Help:      "The total number of data source queries of expressions sent to the data source.",
+		}),
+	}
+}
+
+// getOrQuery returns a copy of the cached response for the key, or calls query and caches its response for ttl.
+// Responses with an error are not cached. The query gets a context with the values of ctx, which is not
+// canceled with ctx but after queryCacheQueryTimeout, a canceled caller stops waiting for the response.
+func (c *QueryCache) getOrQuery(ctx context.Context, key string, ttl time.Duration, query func(context.Context) (*backend.QueryDataResponse, error)) (*backend.QueryDataResponse, error) {
+	if resp, ok := c.get(key); ok {
+		c.hits.Inc()
+		return c.copyOrQuery(ctx, resp, query)
+	}
+
+	queried := false
+	ch := c.inflight.DoChan(key, func() (interface{}, error) {
+		queried = true
+		queryCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, queryCacheQueryTimeout)
+		defer cancel()
+		resp, err := query(queryCtx)
+		if err != nil {
+			return nil, err
+		}
+		if !hasErrors(resp) {
+			if cached, err := copyQueryDataResponse(resp); err != nil {
+				logger.Warn("failed to copy the response of the query, it is not cached", "err", err)
+			} else {
+				c.set(key, cached, ttl)
+			}
+		}
+		return resp, nil
+	})
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-ch:
+	}
+	if queried {
+		c.misses.Inc()
+	} else {
+		c.hits.Inc()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	// the response of a merged query is shared by all the callers
+	return c.copyOrQuery(ctx, res.Val.(*backend.QueryDataResponse), query)
+}
+
+// copyOrQuery returns a copy of the response, or calls query if it can not be copied.
+func (c *QueryCache) copyOrQuery(ctx context.Context, resp *backend.QueryDataResponse, query func(context.Context) (*backend.QueryDataResponse, error)) (*backend.QueryDataResponse, error) {
+	copied, err := copyQueryDataResponse(resp)
+	if err != nil {
+		logger.Warn("failed to copy the response of the query, the query is sent again", "err", err)
+		return query(ctx)
+	}
+	return copied, nil
+}
+
+// detachedContext is a context with the values of its parent, which is not canceled with its parent.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+func (c *QueryCache) get(key string) (*backend.QueryDataResponse, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		return nil, false
+	}
+	return e.resp, true
+}
+
+func (c *QueryCache) set(key string, resp *backend.QueryDataResponse, ttl time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastSweep) >= queryCacheSweepInterval {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[key] = queryCacheEntry{resp: resp, expiresAt: now.Add(ttl)}
+}
+
+// queryCacheKey is what identifies the same data source query in different pipelines.
+// The refId of the query is left out of the model, and the time range is aligned to the interval
+// of the query so that the queries of evaluations a little apart share the same key, while a cached
+// response misses at most one interval of data. The TTL is part of the key so that a response is
+// never reused for longer than the TTL of the queries sharing it.
+type queryCacheKey struct {
+	TTL           int64                  `json:"ttl"`
+	OrgID         int64                  `json:"orgId"`
+	DatasourceID  int64                  `json:"datasourceId"`
+	DatasourceUID string                 `json:"datasourceUid"`
+	QueryType     string                 `json:"queryType"`
+	IntervalMS    int64                  `json:"intervalMs"`
+	MaxDP         int64                  `json:"maxDataPoints"`
+	From          int64                  `json:"from"`
+	To            int64                  `json:"to"`
+	Query         map[string]interface{} `json:"query"`
+}
+
+// cacheKey returns the key of the query of the node in a QueryCache with the given TTL.
+func (dn *DSNode) cacheKey(ttl time.Duration) (string, error) {
+	var query map[string]interface{}
+	if err := json.Unmarshal(dn.query, &query); err != nil {
+		return "", err
+	}
+	delete(query, "refId")
+
+	interval := time.Duration(dn.intervalMS) * time.Millisecond
+	b, err := json.Marshal(queryCacheKey{
+		TTL:           int64(ttl),
+		OrgID:         dn.orgID,
+		DatasourceID:  dn.datasourceID,
+		DatasourceUID: dn.datasourceUID,
+		QueryType:     dn.queryType,
+		IntervalMS:    dn.intervalMS,
+		MaxDP:         dn.maxDP,
+		From:          dn.timeRange.From.Truncate(interval).UnixNano(),
+		To:            dn.timeRange.To.Truncate(interval).UnixNano(),
+		Query:         query,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// queryDataCached queries the data source of the node, or returns the response of the same query
+// from the query cache of the service if it has one.
+func (s *Service) queryDataCached(ctx context.Context, dn *DSNode, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if s.QueryCache == nil || s.QueryCacheTTL <= 0 {
+		return s.queryData(ctx, req)
+	}
+	key, err := dn.cacheKey(s.QueryCacheTTL)
+	if err != nil {
+		logger.Warn("failed to compute the cache key of the query, the cache is skipped", "query", dn.refID, "err", err)
+		return s.queryData(ctx, req)
+	}
+	return s.QueryCache.getOrQuery(ctx, key, s.QueryCacheTTL, func(ctx context.Context) (*backend.QueryDataResponse, error) {
+		return s.queryData(ctx, req)
+	})
+}
+
+func hasErrors(resp *backend.QueryDataResponse) bool {
+	for _, r := range resp.Responses {
+		if r.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// copyQueryDataResponse returns a deep copy of the frames of the response,
+// the frames of cached responses must not be changed by the pipelines.
+func copyQueryDataResponse(resp *backend.QueryDataResponse) (*backend.QueryDataResponse, error) {
+	c := backend.NewQueryDataResponse()
+	for refID, r := range resp.Responses {
+		frames := make(data.Frames, 0, len(r.Frames))
+		for _, f := range r.Frames {
+			frame, err := copyFrame(f)
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, frame)
+		}
+		c.Responses[refID] = backend.DataResponse{Frames: frames, Error: r.Error}
+	}
+	return c, nil
+}
+
+func copyFrame(f *data.Frame) (*data.Frame, error) {
+	fields := make([]*data.Field, 0, len(f.Fields))
+	for _, field := range f.Fields {
+		nf := data.NewFieldFromFieldType(field.Type(), field.Len())
+		nf.Name = field.Name
+		if field.Labels != nil {
+			nf.Labels = field.Labels.Copy()
+		}
+		if field.Config != nil {
+			config := *field.Config
+			nf.Config = &config
+		}
+		for i := 0; i < field.Len(); i++ {
+			nf.Set(i, field.CopyAt(i))
+		}
+		fields = append(fields, nf)
+	}
+	nf := data.NewFrame(f.Name, fields...)
+	nf.RefID = f.RefID
+	if f.Meta != nil {
+		meta, err := copyFrameMeta(f.Meta)
+		if err != nil {
+			return nil, err
+		}
+		nf.Meta = meta
+	}
+	return nf, nil
+}
+
+// copyFrameMeta returns a deep copy of the metadata of a frame, made through its JSON model.
+// The custom metadata is copied into a value of the same type, that the readers of the frame expect.
+func copyFrameMeta(m *data.FrameMeta) (*data.FrameMeta, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the frame metadata: %w", err)
+	}
+	c := &data.FrameMeta{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the frame metadata: %w", err)
+	}
+	if m.Custom == nil {
+		return c, nil
+	}
+
+	b, err = json.Marshal(m.Custom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the custom frame metadata: %w", err)
+	}
+	custom := reflect.New(reflect.TypeOf(m.Custom))
+	if err := json.Unmarshal(b, custom.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the custom frame metadata: %w", err)
+	}
+	c.Custom = custom.Elem().Interface()
+	return c, nil
+}
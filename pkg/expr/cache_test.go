@@ -0,0 +1,267 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	newCache := func() *QueryCache {
+		c := NewQueryCache(prometheus.NewRegistry())
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	var queries int32
+	query := func(context.Context) (*backend.QueryDataResponse, error) {
+		atomic.AddInt32(&queries, 1)
+		return newTestQueryDataResponse("A", 2), nil
+	}
+
+	t.Run("the response is cached until it expires", func(t *testing.T) {
+		atomic.StoreInt32(&queries, 0)
+		c := newCache()
+
+		for i := 0; i < 3; i++ {
+			resp, err := c.getOrQuery(context.Background(), "key", time.Minute, query)
+			require.NoError(t, err)
+			require.Equal(t, newTestQueryDataResponse("A", 2), resp)
+		}
+		require.Equal(t, int32(1), atomic.LoadInt32(&queries))
+		require.Equal(t, float64(2), testutil.ToFloat64(c.hits))
+		require.Equal(t, float64(1), testutil.ToFloat64(c.misses))
+
+		_, err := c.getOrQuery(context.Background(), "other key", time.Minute, query)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), atomic.LoadInt32(&queries))
+
+		now = now.Add(time.Minute)
+		_, err = c.getOrQuery(context.Background(), "key", time.Minute, query)
+		require.NoError(t, err)
+		require.Equal(t, int32(3), atomic.LoadInt32(&queries))
+		require.Equal(t, float64(3), testutil.ToFloat64(c.misses))
+	})
+
+	t.Run("cached responses are copies", func(t *testing.T) {
+		c := newCache()
+
+		resp, err := c.getOrQuery(context.Background(), "key", time.Minute, query)
+		require.NoError(t, err)
+		resp.Responses["A"].Frames[0].Fields[1].Set(0, fp(5))
+		resp.Responses["A"].Frames[0].Meta = &data.FrameMeta{ExecutedQueryString: "changed"}
+
+		resp, err = c.getOrQuery(context.Background(), "key", time.Minute, query)
+		require.NoError(t, err)
+		require.Equal(t, newTestQueryDataResponse("A", 2), resp)
+	})
+
+	t.Run("the metadata of cached responses is copied", func(t *testing.T) {
+		type custom struct {
+			Values []string
+		}
+		c := newCache()
+		metaQuery := func(context.Context) (*backend.QueryDataResponse, error) {
+			resp := newTestQueryDataResponse("A", 2)
+			resp.Responses["A"].Frames[0].Meta = &data.FrameMeta{
+				Custom: &custom{Values: []string{"a"}},
+				Stats:  []data.QueryStat{{FieldConfig: data.FieldConfig{DisplayName: "stat"}, Value: 1}},
+			}
+			return resp, nil
+		}
+
+		resp, err := c.getOrQuery(context.Background(), "key", time.Minute, metaQuery)
+		require.NoError(t, err)
+		meta := resp.Responses["A"].Frames[0].Meta
+		meta.Custom.(*custom).Values[0] = "changed"
+		meta.Stats[0].Value = 5
+
+		resp, err = c.getOrQuery(context.Background(), "key", time.Minute, metaQuery)
+		require.NoError(t, err)
+		meta = resp.Responses["A"].Frames[0].Meta
+		require.Equal(t, &custom{Values: []string{"a"}}, meta.Custom)
+		require.Equal(t, float64(1), meta.Stats[0].Value)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := newCache()
+		errFailed := errors.New("failed")
+
+		_, err := c.getOrQuery(context.Background(), "key", time.Minute, func(context.Context) (*backend.QueryDataResponse, error) {
+			return nil, errFailed
+		})
+		require.ErrorIs(t, err, errFailed)
+
+		_, err = c.getOrQuery(context.Background(), "key", time.Minute, func(context.Context) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Error: errFailed}
+			return resp, nil
+		})
+		require.NoError(t, err)
+
+		atomic.StoreInt32(&queries, 0)
+		_, err = c.getOrQuery(context.Background(), "key", time.Minute, query)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&queries))
+	})
+
+	t.Run("concurrent identical queries are merged", func(t *testing.T) {
+		atomic.StoreInt32(&queries, 0)
+		c := newCache()
+		release := make(chan struct{})
+		slowQuery := func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			<-release
+			return query(ctx)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := c.getOrQuery(context.Background(), "key", time.Minute, slowQuery)
+				require.NoError(t, err)
+				require.Equal(t, newTestQueryDataResponse("A", 2), resp)
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), atomic.LoadInt32(&queries))
+		require.Equal(t, float64(5), testutil.ToFloat64(c.hits)+testutil.ToFloat64(c.misses))
+	})
+
+	t.Run("a canceled caller doesn't cancel the merged query", func(t *testing.T) {
+		c := newCache()
+		started := make(chan struct{})
+		release := make(chan struct{})
+		slowQuery := func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			close(started)
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return newTestQueryDataResponse("A", 2), nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			_, err := c.getOrQuery(ctx, "key", time.Minute, slowQuery)
+			done <- err
+		}()
+		<-started
+
+		merged := make(chan *backend.QueryDataResponse)
+		go func() {
+			resp, err := c.getOrQuery(context.Background(), "key", time.Minute, slowQuery)
+			require.NoError(t, err)
+			merged <- resp
+		}()
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		close(release)
+		require.Equal(t, newTestQueryDataResponse("A", 2), <-merged)
+	})
+}
+
+func TestDSNodeCacheKey(t *testing.T) {
+	newNode := func(refID string, query string, from, to int64) *DSNode {
+		return &DSNode{
+			baseNode:      baseNode{refID: refID},
+			query:         json.RawMessage(query),
+			datasourceUID: "prom",
+			orgID:         1,
+			intervalMS:    time.Minute.Milliseconds(),
+			maxDP:         defaultMaxDP,
+			timeRange:     TimeRange{From: time.Unix(from, 0), To: time.Unix(to, 0)},
+		}
+	}
+	key := func(n *DSNode) string {
+		k, err := n.cacheKey(time.Minute)
+		require.NoError(t, err)
+		return k
+	}
+
+	a := newNode("A", `{"refId": "A", "expr": "up"}`, 600, 1200)
+
+	require.Equal(t, key(a), key(newNode("B", `{"expr": "up", "refId": "B"}`, 600, 1200)), "the refId is ignored")
+	require.Equal(t, key(a), key(newNode("A", `{"refId": "A", "expr": "up"}`, 630, 1230)), "the time range is aligned to the interval")
+	require.NotEqual(t, key(a), key(newNode("A", `{"refId": "A", "expr": "up"}`, 660, 1260)))
+
+	// the alignment doesn't depend on the TTL, the responses miss at most one interval of data
+	longTTL := func(n *DSNode) string {
+		k, err := n.cacheKey(time.Hour)
+		require.NoError(t, err)
+		return k
+	}
+	require.NotEqual(t, longTTL(newNode("A", `{"refId": "A", "expr": "up"}`, 600, 1200)), longTTL(newNode("A", `{"refId": "A", "expr": "up"}`, 660, 1260)))
+	require.NotEqual(t, key(a), key(newNode("A", `{"refId": "A", "expr": "down"}`, 600, 1200)))
+
+	otherDS := newNode("A", `{"refId": "A", "expr": "up"}`, 600, 1200)
+	otherDS.datasourceUID = "loki"
+	require.NotEqual(t, key(a), key(otherDS))
+
+	otherOrg := newNode("A", `{"refId": "A", "expr": "up"}`, 600, 1200)
+	otherOrg.orgID = 2
+	require.NotEqual(t, key(a), key(otherOrg))
+
+	otherTTL, err := a.cacheKey(2 * time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, key(a), otherTTL)
+}
+
+func TestServiceQueryDataCached(t *testing.T) {
+	node := &DSNode{
+		baseNode:      baseNode{refID: "A"},
+		query:         json.RawMessage(`{"refId": "A"}`),
+		datasourceUID: "test",
+		orgID:         1,
+	}
+
+	t.Run("without a ttl the cache is not used", func(t *testing.T) {
+		c := NewQueryCache(prometheus.NewRegistry())
+		s := &Service{QueryCache: c}
+		// the service has no data service, so a query fails
+		_, err := s.queryDataCached(context.Background(), node, &backend.QueryDataRequest{})
+		require.Error(t, err)
+		require.Equal(t, float64(0), testutil.ToFloat64(c.misses))
+	})
+
+	t.Run("with a ttl the cache is used", func(t *testing.T) {
+		c := NewQueryCache(prometheus.NewRegistry())
+		key, err := node.cacheKey(time.Minute)
+		require.NoError(t, err)
+		c.set(key, newTestQueryDataResponse("A", 3), time.Minute)
+
+		s := &Service{QueryCache: c, QueryCacheTTL: time.Minute}
+		resp, err := s.queryDataCached(context.Background(), node, &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.Equal(t, newTestQueryDataResponse("A", 3), resp)
+		require.Equal(t, float64(1), testutil.ToFloat64(c.hits))
+	})
+}
+
+func newTestQueryDataResponse(refID string, value float64) *backend.QueryDataResponse {
+	resp := backend.NewQueryDataResponse()
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []*time.Time{utp(1)}),
+		data.NewField("value", data.Labels{"host": "a"}, []*float64{fp(value)}))
+	frame.RefID = refID
+	resp.Responses[refID] = backend.DataResponse{Frames: data.Frames{frame}}
+	return resp
+}
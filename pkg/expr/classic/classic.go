@@ -13,7 +13,10 @@ import (
 // expression operation.
 type ConditionsCmd struct {
 	Conditions []condition
-	refID      string
+	// MultiDimensional makes the command return a result for each set of labels
+	// of the series instead of a single result for all the series.
+	MultiDimensional bool
+	refID            string
 }
 
 // ClassicConditionJSON is the JSON model for a single condition.
@@ -70,6 +73,10 @@ type EvalMatch struct {
 // Execute runs the command and returns the results or an error if the command
 // failed to execute.
 func (ccc *ConditionsCmd) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	if ccc.MultiDimensional {
+		return ccc.executeMultiDimensional(vars)
+	}
+
 	firing := true
 	newRes := mathexp.Results{}
 	noDataFound := true
@@ -137,7 +144,120 @@ func (ccc *ConditionsCmd) Execute(ctx context.Context, vars mathexp.Vars) (mathe
 		nilReducedCount = 0
 	}
 
-	num := mathexp.NewNumber("", nil)
+	newRes.Values = append(newRes.Values, newConditionsResult(nil, firing, noDataFound, matches))
+
+	return newRes, nil
+}
+
+// reducedSeries is a series reduced by the reducer of a condition.
+type reducedSeries struct {
+	name    string
+	reduced mathexp.Number
+}
+
+// executeMultiDimensional evaluates the conditions for each set of labels of the series of the queries,
+// and returns a number with those labels for each. A series without labels is used for every set of labels
+// of the series of the other queries. The series of a query must have distinct labels.
+func (ccc *ConditionsCmd) executeMultiDimensional(vars mathexp.Vars) (mathexp.Results, error) {
+	newRes := mathexp.Results{}
+
+	// the sets of labels in the order they are first found
+	var keys []string
+	labelsByKey := map[string]data.Labels{}
+
+	reduced := make([]map[string]reducedSeries, len(ccc.Conditions))
+	for i, c := range ccc.Conditions {
+		reduced[i] = map[string]reducedSeries{}
+		for _, val := range vars[c.QueryRefID].Values {
+			series, ok := val.(mathexp.Series)
+			if !ok {
+				return newRes, fmt.Errorf("can only reduce type series, got type %v", val.Type())
+			}
+
+			key := series.GetLabels().String()
+			if _, ok := reduced[i][key]; ok {
+				return newRes, fmt.Errorf("query %v returned several series with the labels %v, multi-dimensional classic conditions require distinct labels", c.QueryRefID, key)
+			}
+			reduced[i][key] = reducedSeries{name: series.GetName(), reduced: c.Reducer.Reduce(series)}
+
+			if _, ok := labelsByKey[key]; !ok {
+				keys = append(keys, key)
+				labelsByKey[key] = series.GetLabels()
+			}
+		}
+	}
+
+	// the series without labels only has its own result when all the series are without labels
+	if len(keys) > 1 {
+		if _, ok := labelsByKey[""]; ok {
+			for i, key := range keys {
+				if key == "" {
+					keys = append(keys[:i], keys[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+
+	for _, key := range keys {
+		firing := true
+		noDataFound := true
+		matches := []EvalMatch{}
+
+		for i, c := range ccc.Conditions {
+			rs, ok := reduced[i][key]
+			if !ok {
+				rs, ok = reduced[i][""]
+			}
+
+			thisCondFiring := false
+			thisCondNoData := !ok || rs.reduced.GetFloat64Value() == nil
+			if ok && c.Evaluator.Eval(rs.reduced) {
+				match := EvalMatch{
+					Value:  rs.reduced.GetFloat64Value(),
+					Metric: rs.name,
+				}
+				if rs.reduced.GetLabels() != nil {
+					match.Labels = rs.reduced.GetLabels().Copy()
+				}
+				matches = append(matches, match)
+				thisCondFiring = true
+			}
+
+			if i == 0 {
+				firing = thisCondFiring
+				noDataFound = thisCondNoData
+			}
+
+			if c.Operator == "or" {
+				firing = firing || thisCondFiring
+				noDataFound = noDataFound || thisCondNoData
+			} else {
+				firing = firing && thisCondFiring
+				noDataFound = noDataFound && thisCondNoData
+			}
+
+			if thisCondNoData {
+				matches = append(matches, EvalMatch{
+					Metric: "NoData",
+				})
+				noDataFound = true
+			}
+		}
+
+		newRes.Values = append(newRes.Values, newConditionsResult(labelsByKey[key], firing, noDataFound, matches))
+	}
+
+	return newRes, nil
+}
+
+// newConditionsResult returns the number of a result of the conditions: 1 when firing, 0 when not firing,
+// and nil when there is no data.
+func newConditionsResult(labels data.Labels, firing, noDataFound bool, matches []EvalMatch) mathexp.Number {
+	num := mathexp.NewNumber("", labels)
 
 	num.SetMeta(matches)
 
@@ -152,9 +272,7 @@ func (ccc *ConditionsCmd) Execute(ctx context.Context, vars mathexp.Vars) (mathe
 		num.SetValue(&v)
 	}
 
-	newRes.Values = append(newRes.Values, num)
-
-	return newRes, nil
+	return num
 }
 
 // UnmarshalConditionsCmd creates a new ConditionsCmd.
@@ -172,6 +290,12 @@ func UnmarshalConditionsCmd(rawQuery map[string]interface{}, refID string) (*Con
 		refID: refID,
 	}
 
+	if rawMultiDimensional, ok := rawQuery["multiDimensional"]; ok {
+		if c.MultiDimensional, ok = rawMultiDimensional.(bool); !ok {
+			return nil, fmt.Errorf("expected multiDimensional to be a boolean, got type %T", rawMultiDimensional)
+		}
+	}
+
 	for i, cj := range ccj {
 		cond := condition{}
 
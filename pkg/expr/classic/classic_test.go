@@ -97,6 +97,47 @@ func TestUnmarshalConditionCMD(t *testing.T) {
 			},
 			needsVars: []string{"A"},
 		},
+		{
+			name: "multi-dimensional condition",
+			rawJSON: `{
+				"multiDimensional": true,
+				"conditions": [
+				  {
+					"evaluator": {
+					  "params": [
+						2
+					  ],
+					  "type": "lt"
+					},
+					"operator": {
+					  "type": "and"
+					},
+					"query": {
+					  "params": [
+						"B"
+					  ]
+					},
+					"reducer": {
+					  "params": [],
+					  "type": "last"
+					},
+					"type": "query"
+				  }
+				]
+			}`,
+			expectedCommand: &ConditionsCmd{
+				Conditions: []condition{
+					{
+						QueryRefID: "B",
+						Reducer:    classicReducer("last"),
+						Operator:   "and",
+						Evaluator:  &thresholdEvaluator{Type: "lt", Threshold: 2},
+					},
+				},
+				MultiDimensional: true,
+			},
+			needsVars: []string{"B"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -334,3 +375,160 @@ func TestConditionsCmdExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestConditionsCmdExecuteMultiDimensional(t *testing.T) {
+	gtCondition := func(refID string, operator string, threshold float64) condition {
+		return condition{
+			QueryRefID: refID,
+			Reducer:    classicReducer("avg"),
+			Operator:   operator,
+			Evaluator:  &thresholdEvaluator{Type: "gt", Threshold: threshold},
+		}
+	}
+	labeledNumber := func(labels data.Labels, f *float64, matches []EvalMatch) mathexp.Number {
+		n := mathexp.NewNumber("", labels)
+		n.SetValue(f)
+		n.SetMeta(matches)
+		return n
+	}
+
+	tests := []struct {
+		name          string
+		vars          mathexp.Vars
+		conditions    []condition
+		expected      []mathexp.Value
+		expectedError string
+	}{
+		{
+			name: "a result per series",
+			vars: mathexp.Vars{
+				"A": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(30), ptr.Float64(40)),
+						valBasedSeriesWithLabels(data.Labels{"h": "2"}, ptr.Float64(0), ptr.Float64(10)),
+						valBasedSeriesWithLabels(data.Labels{"h": "3"}),
+					},
+				},
+			},
+			conditions: []condition{gtCondition("A", "and", 34)},
+			expected: []mathexp.Value{
+				labeledNumber(data.Labels{"h": "1"}, ptr.Float64(1), []EvalMatch{{Value: ptr.Float64(35), Labels: data.Labels{"h": "1"}}}),
+				labeledNumber(data.Labels{"h": "2"}, ptr.Float64(0), []EvalMatch{}),
+				labeledNumber(data.Labels{"h": "3"}, nil, []EvalMatch{{Metric: "NoData"}}),
+			},
+		},
+		{
+			name: "conditions are combined per labels",
+			vars: mathexp.Vars{
+				"A": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(40)),
+						valBasedSeriesWithLabels(data.Labels{"h": "2"}, ptr.Float64(40)),
+					},
+				},
+				"B": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "2"}, ptr.Float64(1)),
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(20)),
+					},
+				},
+			},
+			conditions: []condition{gtCondition("A", "", 34), gtCondition("B", "and", 10)},
+			expected: []mathexp.Value{
+				labeledNumber(data.Labels{"h": "1"}, ptr.Float64(1), []EvalMatch{
+					{Value: ptr.Float64(40), Labels: data.Labels{"h": "1"}},
+					{Value: ptr.Float64(20), Labels: data.Labels{"h": "1"}},
+				}),
+				labeledNumber(data.Labels{"h": "2"}, ptr.Float64(0), []EvalMatch{{Value: ptr.Float64(40), Labels: data.Labels{"h": "2"}}}),
+			},
+		},
+		{
+			name: "a series without labels is used for every labels",
+			vars: mathexp.Vars{
+				"A": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(40)),
+						valBasedSeriesWithLabels(data.Labels{"h": "2"}, ptr.Float64(0)),
+					},
+				},
+				"B": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeries(ptr.Float64(20)),
+					},
+				},
+			},
+			conditions: []condition{gtCondition("A", "", 34), gtCondition("B", "or", 10)},
+			expected: []mathexp.Value{
+				labeledNumber(data.Labels{"h": "1"}, ptr.Float64(1), []EvalMatch{
+					{Value: ptr.Float64(40), Labels: data.Labels{"h": "1"}},
+					{Value: ptr.Float64(20)},
+				}),
+				labeledNumber(data.Labels{"h": "2"}, ptr.Float64(1), []EvalMatch{{Value: ptr.Float64(20)}}),
+			},
+		},
+		{
+			name: "a missing series is no data",
+			vars: mathexp.Vars{
+				"A": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(40)),
+					},
+				},
+				"B": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "2"}, ptr.Float64(20)),
+					},
+				},
+			},
+			conditions: []condition{gtCondition("A", "", 34), gtCondition("B", "and", 10)},
+			expected: []mathexp.Value{
+				labeledNumber(data.Labels{"h": "1"}, nil, []EvalMatch{
+					{Value: ptr.Float64(40), Labels: data.Labels{"h": "1"}},
+					{Metric: "NoData"},
+				}),
+				// as with the legacy behavior, no data of a condition can be cleared by the next condition
+				labeledNumber(data.Labels{"h": "2"}, ptr.Float64(0), []EvalMatch{
+					{Metric: "NoData"},
+					{Value: ptr.Float64(20), Labels: data.Labels{"h": "2"}},
+				}),
+			},
+		},
+		{
+			name: "no series",
+			vars: mathexp.Vars{
+				"A": mathexp.Results{},
+			},
+			conditions: []condition{gtCondition("A", "", 34)},
+			expected: []mathexp.Value{
+				labeledNumber(nil, nil, []EvalMatch{{Metric: "NoData"}}),
+			},
+		},
+		{
+			name: "series with the same labels",
+			vars: mathexp.Vars{
+				"A": mathexp.Results{
+					Values: []mathexp.Value{
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(40)),
+						valBasedSeriesWithLabels(data.Labels{"h": "1"}, ptr.Float64(0)),
+					},
+				},
+			},
+			conditions:    []condition{gtCondition("A", "", 34)},
+			expectedError: "query A returned several series with the labels h=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &ConditionsCmd{Conditions: tt.conditions, MultiDimensional: true}
+			res, err := cmd.Execute(context.Background(), tt.vars)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res.Values)
+		})
+	}
+}
@@ -2,10 +2,13 @@ package expr
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 )
@@ -69,19 +72,34 @@ func (gm *MathCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.
 
 // ReduceCommand is an expression command for reduction of a timeseries such as a min, mean, or max.
 type ReduceCommand struct {
-	Reducer     string
-	VarToReduce string
-	refID       string
+	Reducer      string
+	VarToReduce  string
+	refID        string
+	seriesMapper mathexp.ReduceMapper
 }
 
-// NewReduceCommand creates a new ReduceCMD.
-func NewReduceCommand(refID, reducer, varToReduce string) *ReduceCommand {
-	// TODO: validate reducer here, before execution
-	return &ReduceCommand{
-		Reducer:     reducer,
-		VarToReduce: varToReduce,
-		refID:       refID,
+// The modes of a reduce command, which control how null, NaN and infinite values are handled.
+const (
+	// ReduceModeStrict reduces the series as they are, null and NaN values usually make the result NaN.
+	ReduceModeStrict = "strict"
+	// ReduceModeDropNonNumbers drops the null, NaN and infinite values before the reduction.
+	ReduceModeDropNonNumbers = "dropNN"
+	// ReduceModeReplaceNonNumbers replaces the null, NaN and infinite values with a value before the reduction.
+	ReduceModeReplaceNonNumbers = "replaceNN"
+)
+
+// NewReduceCommand creates a new ReduceCMD. It will return an error if the reducer is not supported.
+// The mapper, if not nil, is applied to the series before they are reduced.
+func NewReduceCommand(refID, reducer, varToReduce string, mapper mathexp.ReduceMapper) (*ReduceCommand, error) {
+	if err := mathexp.ValidReducer(reducer); err != nil {
+		return nil, fmt.Errorf("invalid reducer for refId %v: %w", refID, err)
 	}
+	return &ReduceCommand{
+		Reducer:      reducer,
+		VarToReduce:  varToReduce,
+		refID:        refID,
+		seriesMapper: mapper,
+	}, nil
 }
 
 // UnmarshalReduceCommand creates a MathCMD from Grafana's frontend query.
@@ -105,7 +123,53 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 		return nil, fmt.Errorf("expected reducer to be a string, got %T for refId %v", rawReducer, rn.RefID)
 	}
 
-	return NewReduceCommand(rn.RefID, redFunc, varToReduce), nil
+	mapper, err := unmarshalReduceMapper(rn)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper)
+}
+
+// unmarshalReduceMapper returns the mapper of the mode in the optional settings of a reduce command,
+// such as {"mode": "replaceNN", "replaceWithValue": 0}. The strict mode has no mapper.
+func unmarshalReduceMapper(rn *rawNode) (mathexp.ReduceMapper, error) {
+	rawSettings, ok := rn.Query["settings"]
+	if !ok || rawSettings == nil {
+		return nil, nil
+	}
+	settings, ok := rawSettings.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected reduce settings to be an object, got %T for refId %v", rawSettings, rn.RefID)
+	}
+
+	rawMode, ok := settings["mode"]
+	if !ok {
+		return nil, nil
+	}
+	mode, ok := rawMode.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected reduce mode to be a string, got %T for refId %v", rawMode, rn.RefID)
+	}
+
+	switch mode {
+	case "", ReduceModeStrict:
+		return nil, nil
+	case ReduceModeDropNonNumbers:
+		return mathexp.DropNonNumber{}, nil
+	case ReduceModeReplaceNonNumbers:
+		rawValue, ok := settings["replaceWithValue"]
+		if !ok {
+			return nil, fmt.Errorf("no replaceWithValue specified for reduce mode %v for refId %v", mode, rn.RefID)
+		}
+		value, ok := rawValue.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected replaceWithValue to be a number, got %T for refId %v", rawValue, rn.RefID)
+		}
+		return mathexp.ReplaceNonNumberWithValue{Value: value}, nil
+	default:
+		return nil, fmt.Errorf("reduce mode %v is not supported for refId %v", mode, rn.RefID)
+	}
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -123,7 +187,7 @@ func (gr *ReduceCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathex
 		if !ok {
 			return newRes, fmt.Errorf("can only reduce type series, got type %v", val.Type())
 		}
-		num, err := series.Reduce(gr.refID, gr.Reducer)
+		num, err := series.Reduce(gr.refID, gr.Reducer, gr.seriesMapper)
 		if err != nil {
 			return newRes, err
 		}
@@ -133,12 +197,15 @@ func (gr *ReduceCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathex
 }
 
 // ResampleCommand is an expression command for resampling of a timeseries.
+// When AlignTo is set, the series are instead aligned to the timestamps of the series of that variable.
 type ResampleCommand struct {
 	Window        time.Duration
 	VarToResample string
 	Downsampler   string
 	Upsampler     string
 	TimeRange     TimeRange
+	AlignTo       string
+	Options       mathexp.UpsampleOptions
 	refID         string
 }
 
@@ -159,6 +226,17 @@ func NewResampleCommand(refID, rawWindow, varToResample string, downsampler stri
 	}, nil
 }
 
+// NewAlignCommand creates a new ResampleCMD aligning the series of varToResample to
+// the timestamps of the series of alignTo.
+func NewAlignCommand(refID, varToResample, alignTo, upsampler string) *ResampleCommand {
+	return &ResampleCommand{
+		VarToResample: varToResample,
+		Upsampler:     upsampler,
+		AlignTo:       alignTo,
+		refID:         refID,
+	}
+}
+
 // UnmarshalResampleCommand creates a ResampleCMD from Grafana's frontend query.
 func UnmarshalResampleCommand(rn *rawNode) (*ResampleCommand, error) {
 	rawVar, ok := rn.Query["expression"]
@@ -172,6 +250,30 @@ func UnmarshalResampleCommand(rn *rawNode) (*ResampleCommand, error) {
 	varToReduce = strings.TrimPrefix(varToReduce, "$")
 	varToResample := varToReduce
 
+	rawUpsampler, ok := rn.Query["upsampler"]
+	if !ok {
+		return nil, fmt.Errorf("no upsampler function specified in resample command for refId %v", rn.RefID)
+	}
+	upsampler, ok := rawUpsampler.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected resample upsampler to be a string, got type %T for refId %v", rawUpsampler, rn.RefID)
+	}
+
+	opts, err := unmarshalUpsampleOptions(rn, upsampler)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawAlignTo, ok := rn.Query["alignTo"]; ok && rawAlignTo != "" {
+		alignTo, ok := rawAlignTo.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected resample alignTo to be a string, got type %T for refId %v", rawAlignTo, rn.RefID)
+		}
+		cmd := NewAlignCommand(rn.RefID, varToResample, strings.TrimPrefix(alignTo, "$"), upsampler)
+		cmd.Options = opts
+		return cmd, nil
+	}
+
 	rawWindow, ok := rn.Query["window"]
 	if !ok {
 		return nil, fmt.Errorf("no time duration specified for the window in resample command for refId %v", rn.RefID)
@@ -190,21 +292,51 @@ func UnmarshalResampleCommand(rn *rawNode) (*ResampleCommand, error) {
 		return nil, fmt.Errorf("expected resample downsampler to be a string, got type %T for refId %v", downsampler, rn.RefID)
 	}
 
-	rawUpsampler, ok := rn.Query["upsampler"]
-	if !ok {
-		return nil, fmt.Errorf("no downsampler specified in resample command for refId %v", rn.RefID)
+	cmd, err := NewResampleCommand(rn.RefID, window, varToResample, downsampler, upsampler, rn.TimeRange)
+	if err != nil {
+		return nil, err
 	}
-	upsampler, ok := rawUpsampler.(string)
-	if !ok {
-		return nil, fmt.Errorf("expected resample downsampler to be a string, got type %T for refId %v", upsampler, rn.RefID)
+	cmd.Options = opts
+	return cmd, nil
+}
+
+// unmarshalUpsampleOptions reads the optional fillValue and maxGap of a resample command.
+func unmarshalUpsampleOptions(rn *rawNode, upsampler string) (mathexp.UpsampleOptions, error) {
+	opts := mathexp.UpsampleOptions{}
+
+	rawFillValue, ok := rn.Query["fillValue"]
+	if ok {
+		if opts.FillValue, ok = rawFillValue.(float64); !ok {
+			return opts, fmt.Errorf("expected resample fillValue to be a number, got type %T for refId %v", rawFillValue, rn.RefID)
+		}
+	} else if upsampler == "value" {
+		return opts, fmt.Errorf("no fillValue specified for the value upsampler in resample command for refId %v", rn.RefID)
 	}
 
-	return NewResampleCommand(rn.RefID, window, varToResample, downsampler, upsampler, rn.TimeRange)
+	if rawMaxGap, ok := rn.Query["maxGap"]; ok && rawMaxGap != "" {
+		maxGap, ok := rawMaxGap.(string)
+		if !ok {
+			return opts, fmt.Errorf("expected resample maxGap to be a string, got type %T for refId %v", rawMaxGap, rn.RefID)
+		}
+		d, err := gtime.ParseDuration(maxGap)
+		if err != nil {
+			return opts, fmt.Errorf(`failed to parse resample "maxGap" duration field %q: %w`, maxGap, err)
+		}
+		if d < 0 {
+			return opts, fmt.Errorf("resample maxGap must not be negative, got %v for refId %v", maxGap, rn.RefID)
+		}
+		opts.MaxGap = d
+	}
+
+	return opts, nil
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
 // to execute the command and allows the command to fulfill the Command interface.
 func (gr *ResampleCommand) NeedsVars() []string {
+	if gr.AlignTo != "" {
+		return []string{gr.VarToResample, gr.AlignTo}
+	}
 	return []string{gr.VarToResample}
 }
 
@@ -217,7 +349,17 @@ func (gr *ResampleCommand) Execute(ctx context.Context, vars mathexp.Vars) (math
 		if !ok {
 			return newRes, fmt.Errorf("can only resample type series, got type %v", val.Type())
 		}
-		num, err := series.Resample(gr.refID, gr.Window, gr.Downsampler, gr.Upsampler, gr.TimeRange.From, gr.TimeRange.To)
+		var num mathexp.Series
+		var err error
+		if gr.AlignTo != "" {
+			var target mathexp.Series
+			if target, err = alignmentTarget(vars[gr.AlignTo], series.GetLabels(), gr.AlignTo); err != nil {
+				return newRes, err
+			}
+			num, err = series.Align(gr.refID, target, gr.Upsampler, gr.Options)
+		} else {
+			num, err = series.Resample(gr.refID, gr.Window, gr.Downsampler, gr.Upsampler, gr.TimeRange.From, gr.TimeRange.To, gr.Options)
+		}
 		if err != nil {
 			return newRes, err
 		}
@@ -226,6 +368,179 @@ func (gr *ResampleCommand) Execute(ctx context.Context, vars mathexp.Vars) (math
 	return newRes, nil
 }
 
+// alignmentTarget returns the series of res to align a series with the given labels to:
+// the only series of res, or else the series with the same labels.
+func alignmentTarget(res mathexp.Results, labels data.Labels, refID string) (mathexp.Series, error) {
+	var candidates []mathexp.Series
+	for _, val := range res.Values {
+		series, ok := val.(mathexp.Series)
+		if !ok {
+			return mathexp.Series{}, fmt.Errorf("can only align to type series, got type %v", val.Type())
+		}
+		candidates = append(candidates, series)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	for _, series := range candidates {
+		if series.GetLabels().Equals(labels) {
+			return series, nil
+		}
+	}
+	return mathexp.Series{}, fmt.Errorf("no series of %v with the labels %v to align to", refID, labels)
+}
+
+// ThresholdCommand is an expression command comparing the values of a variable with a threshold or a range,
+// such as "$A > 80". Each value becomes 1 if it matches and 0 otherwise, null and NaN values stay as they are.
+type ThresholdCommand struct {
+	ReferenceVar  string
+	ThresholdFunc string
+	Conditions    []float64
+	refID         string
+}
+
+// The functions of a threshold command, they are the same as the evaluators of classic conditions.
+const (
+	ThresholdIsAbove        = "gt"
+	ThresholdIsBelow        = "lt"
+	ThresholdIsWithinRange  = "within_range"
+	ThresholdIsOutsideRange = "outside_range"
+)
+
+// NewThresholdCommand creates a new ThresholdCommand. It will return an error if the function is not supported
+// or if the number of conditions doesn't match the function: one threshold, or the two bounds of a range.
+func NewThresholdCommand(refID, referenceVar, thresholdFunc string, conditions []float64) (*ThresholdCommand, error) {
+	switch thresholdFunc {
+	case ThresholdIsAbove, ThresholdIsBelow:
+		if len(conditions) != 1 {
+			return nil, fmt.Errorf("threshold function %v for refId %v requires 1 parameter, got %v", thresholdFunc, refID, len(conditions))
+		}
+	case ThresholdIsWithinRange, ThresholdIsOutsideRange:
+		if len(conditions) != 2 {
+			return nil, fmt.Errorf("threshold function %v for refId %v requires 2 parameters, got %v", thresholdFunc, refID, len(conditions))
+		}
+	default:
+		return nil, fmt.Errorf("threshold function '%v' for refId %v is not supported", thresholdFunc, refID)
+	}
+	return &ThresholdCommand{
+		ReferenceVar:  referenceVar,
+		ThresholdFunc: thresholdFunc,
+		Conditions:    conditions,
+		refID:         refID,
+	}, nil
+}
+
+// ThresholdConditionJSON is the JSON model of the condition of a threshold command,
+// it has the same shape as the evaluator of a classic condition.
+type ThresholdConditionJSON struct {
+	Evaluator ThresholdEvaluatorJSON `json:"evaluator"`
+}
+
+// ThresholdEvaluatorJSON is the JSON model of the function and parameters of a threshold command.
+type ThresholdEvaluatorJSON struct {
+	Type   string    `json:"type"`
+	Params []float64 `json:"params"`
+}
+
+// UnmarshalThresholdCommand creates a ThresholdCommand from Grafana's frontend query.
+func UnmarshalThresholdCommand(rn *rawNode) (*ThresholdCommand, error) {
+	rawVar, ok := rn.Query["expression"]
+	if !ok {
+		return nil, fmt.Errorf("no variable specified to compare with a threshold for refId %v", rn.RefID)
+	}
+	referenceVar, ok := rawVar.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected threshold variable to be a string, got %T for refId %v", rawVar, rn.RefID)
+	}
+	referenceVar = strings.TrimPrefix(referenceVar, "$")
+
+	rawConditions, ok := rn.Query["conditions"]
+	if !ok {
+		return nil, fmt.Errorf("no threshold conditions specified for refId %v", rn.RefID)
+	}
+	jsonFromM, err := json.Marshal(rawConditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remarshal threshold conditions for refId %v: %w", rn.RefID, err)
+	}
+	var conditions []ThresholdConditionJSON
+	if err := json.Unmarshal(jsonFromM, &conditions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal threshold conditions for refId %v: %w", rn.RefID, err)
+	}
+	if len(conditions) != 1 {
+		return nil, fmt.Errorf("threshold expression requires exactly one condition, got %v for refId %v", len(conditions), rn.RefID)
+	}
+
+	evaluator := conditions[0].Evaluator
+	return NewThresholdCommand(rn.RefID, referenceVar, evaluator.Type, evaluator.Params)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (tc *ThresholdCommand) NeedsVars() []string {
+	return []string{tc.ReferenceVar}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (tc *ThresholdCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	newRes := mathexp.Results{}
+	for _, val := range vars[tc.ReferenceVar].Values {
+		var l data.Labels
+		if val.GetLabels() != nil {
+			l = val.GetLabels().Copy()
+		}
+		switch v := val.(type) {
+		case mathexp.Number:
+			n := mathexp.NewNumber(tc.refID, l)
+			n.SetValue(tc.compare(v.GetFloat64Value()))
+			newRes.Values = append(newRes.Values, n)
+		case mathexp.Series:
+			series := mathexp.NewSeries(tc.refID, l, v.TimeIdx, v.TimeIsNullable, v.ValueIdx, v.ValueIsNullable, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				t, f := v.GetPoint(i)
+				if err := series.SetPoint(i, t, tc.compare(f)); err != nil {
+					return newRes, err
+				}
+			}
+			newRes.Values = append(newRes.Values, series)
+		default:
+			return newRes, fmt.Errorf("can only compare type number or series with a threshold, got type %v", val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+// compare returns 1 if the value matches the threshold function and 0 otherwise,
+// or the value itself if it is null or NaN.
+func (tc *ThresholdCommand) compare(f *float64) *float64 {
+	if f == nil || math.IsNaN(*f) {
+		return f
+	}
+	var match bool
+	switch tc.ThresholdFunc {
+	case ThresholdIsAbove:
+		match = *f > tc.Conditions[0]
+	case ThresholdIsBelow:
+		match = *f < tc.Conditions[0]
+	case ThresholdIsWithinRange:
+		lower, upper := tc.bounds()
+		match = lower < *f && *f < upper
+	case ThresholdIsOutsideRange:
+		lower, upper := tc.bounds()
+		match = *f < lower || *f > upper
+	}
+	res := 0.0
+	if match {
+		res = 1
+	}
+	return &res
+}
+
+// bounds returns the bounds of a range in increasing order, they can be given in any order.
+func (tc *ThresholdCommand) bounds() (float64, float64) {
+	return math.Min(tc.Conditions[0], tc.Conditions[1]), math.Max(tc.Conditions[0], tc.Conditions[1])
+}
+
 // CommandType is the type of the expression command.
 type CommandType int
 
@@ -240,6 +555,8 @@ const (
 	TypeResample
 	// TypeClassicConditions is the CMDType for the classic condition operation.
 	TypeClassicConditions
+	// TypeThreshold is the CMDType for the comparison of a variable with a threshold or a range.
+	TypeThreshold
 )
 
 func (gt CommandType) String() string {
@@ -252,6 +569,8 @@ func (gt CommandType) String() string {
 		return "resample"
 	case TypeClassicConditions:
 		return "classic_conditions"
+	case TypeThreshold:
+		return "threshold"
 	default:
 		return "unknown"
 	}
@@ -268,6 +587,8 @@ func ParseCommandType(s string) (CommandType, error) {
 		return TypeResample, nil
 	case "classic_conditions":
 		return TypeClassicConditions, nil
+	case "threshold":
+		return TypeThreshold, nil
 	default:
 		return TypeUnknown, fmt.Errorf("'%v' is not a recognized expression type", s)
 	}
@@ -0,0 +1,392 @@
+package expr
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+)
+
+func TestUnmarshalReduceCommand(t *testing.T) {
+	var tests = []struct {
+		name           string
+		query          map[string]interface{}
+		expectedErr    string
+		expectedMapper mathexp.ReduceMapper
+	}{
+		{
+			name: "no settings is strict",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "mean",
+			},
+		},
+		{
+			name: "strict mode",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "last",
+				"settings":   map[string]interface{}{"mode": "strict"},
+			},
+		},
+		{
+			name: "drop non numbers mode",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "median",
+				"settings":   map[string]interface{}{"mode": "dropNN"},
+			},
+			expectedMapper: mathexp.DropNonNumber{},
+		},
+		{
+			name: "replace non numbers mode",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "percentile(95)",
+				"settings":   map[string]interface{}{"mode": "replaceNN", "replaceWithValue": float64(-1)},
+			},
+			expectedMapper: mathexp.ReplaceNonNumberWithValue{Value: -1},
+		},
+		{
+			name: "replace non numbers mode without value",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "sum",
+				"settings":   map[string]interface{}{"mode": "replaceNN"},
+			},
+			expectedErr: "no replaceWithValue specified",
+		},
+		{
+			name: "unknown mode",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "sum",
+				"settings":   map[string]interface{}{"mode": "lenient"},
+			},
+			expectedErr: "reduce mode lenient is not supported",
+		},
+		{
+			name: "unknown reducer",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"reducer":    "mode",
+			},
+			expectedErr: "reduction mode not implemented",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := UnmarshalReduceCommand(&rawNode{RefID: "B", Query: tt.query})
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "A", cmd.VarToReduce)
+			require.Equal(t, tt.expectedMapper, cmd.seriesMapper)
+		})
+	}
+}
+
+func TestUnmarshalResampleCommand(t *testing.T) {
+	var tests = []struct {
+		name        string
+		query       map[string]interface{}
+		expectedErr string
+		expected    *ResampleCommand
+	}{
+		{
+			name: "resample with options",
+			query: map[string]interface{}{
+				"expression":  "$A",
+				"window":      "10s",
+				"downsampler": "mean",
+				"upsampler":   "value",
+				"fillValue":   float64(0),
+				"maxGap":      "1m",
+			},
+			expected: &ResampleCommand{
+				Window:        10 * time.Second,
+				VarToResample: "A",
+				Downsampler:   "mean",
+				Upsampler:     "value",
+				Options:       mathexp.UpsampleOptions{FillValue: 0, MaxGap: time.Minute},
+				refID:         "B",
+			},
+		},
+		{
+			name: "align",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"alignTo":    "$C",
+				"upsampler":  "linear",
+			},
+			expected: &ResampleCommand{
+				VarToResample: "A",
+				Upsampler:     "linear",
+				AlignTo:       "C",
+				refID:         "B",
+			},
+		},
+		{
+			name: "value upsampler without value",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"alignTo":    "$C",
+				"upsampler":  "value",
+			},
+			expectedErr: "no fillValue specified",
+		},
+		{
+			name: "negative max gap",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"alignTo":    "$C",
+				"upsampler":  "pad",
+				"maxGap":     "-5m",
+			},
+			expectedErr: "maxGap must not be negative",
+		},
+		{
+			name: "resample without upsampler",
+			query: map[string]interface{}{
+				"expression":  "$A",
+				"window":      "10s",
+				"downsampler": "mean",
+			},
+			expectedErr: "no upsampler function specified",
+		},
+		{
+			name: "upsampler of another type",
+			query: map[string]interface{}{
+				"expression":  "$A",
+				"window":      "10s",
+				"downsampler": "mean",
+				"upsampler":   float64(1),
+			},
+			expectedErr: "expected resample upsampler to be a string, got type float64",
+		},
+		{
+			name: "resample without window",
+			query: map[string]interface{}{
+				"expression":  "$A",
+				"downsampler": "mean",
+				"upsampler":   "pad",
+			},
+			expectedErr: "no time duration specified for the window",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := UnmarshalResampleCommand(&rawNode{RefID: "B", Query: tt.query})
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cmd)
+		})
+	}
+}
+
+func TestResampleCommandAlign(t *testing.T) {
+	newSeries := func(labels data.Labels, points ...int64) mathexp.Series {
+		s := mathexp.NewSeries("", labels, 0, true, 1, true, len(points))
+		for i, p := range points {
+			require.NoError(t, s.SetPoint(i, utp(p), fp(float64(p))))
+		}
+		return s
+	}
+
+	cmd := NewAlignCommand("B", "A", "C", "pad")
+	require.Equal(t, []string{"A", "C"}, cmd.NeedsVars())
+
+	vars := mathexp.Vars{
+		"A": mathexp.Results{Values: []mathexp.Value{
+			newSeries(data.Labels{"host": "a"}, 1, 3),
+			newSeries(data.Labels{"host": "b"}, 2),
+		}},
+	}
+
+	t.Run("to the only series", func(t *testing.T) {
+		vars["C"] = mathexp.Results{Values: []mathexp.Value{newSeries(nil, 2, 4)}}
+		res, err := cmd.Execute(context.Background(), vars)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 2)
+		for i, expected := range [][]*float64{{fp(1), fp(3)}, {fp(2), fp(2)}} {
+			s := res.Values[i].(mathexp.Series)
+			require.Equal(t, vars["A"].Values[i].GetLabels(), s.GetLabels())
+			for j, v := range expected {
+				ts, f := s.GetPoint(j)
+				require.Equal(t, utp(int64(2*j+2)), ts)
+				require.Equal(t, v, f)
+			}
+		}
+	})
+
+	t.Run("to the series with the same labels", func(t *testing.T) {
+		vars["C"] = mathexp.Results{Values: []mathexp.Value{
+			newSeries(data.Labels{"host": "b"}, 5),
+			newSeries(data.Labels{"host": "a"}, 2),
+		}}
+		res, err := cmd.Execute(context.Background(), vars)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 2)
+		ts, f := res.Values[0].(mathexp.Series).GetPoint(0)
+		require.Equal(t, utp(2), ts)
+		require.Equal(t, fp(1), f)
+		ts, f = res.Values[1].(mathexp.Series).GetPoint(0)
+		require.Equal(t, utp(5), ts)
+		require.Equal(t, fp(2), f)
+	})
+
+	t.Run("without a series with the same labels", func(t *testing.T) {
+		vars["C"] = mathexp.Results{Values: []mathexp.Value{
+			newSeries(data.Labels{"host": "c"}, 5),
+			newSeries(data.Labels{"host": "a"}, 2),
+		}}
+		_, err := cmd.Execute(context.Background(), vars)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no series of C with the labels")
+	})
+}
+
+func TestUnmarshalThresholdCommand(t *testing.T) {
+	condition := func(evalType string, params ...float64) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"evaluator": map[string]interface{}{"type": evalType, "params": params},
+			},
+		}
+	}
+
+	var tests = []struct {
+		name        string
+		query       map[string]interface{}
+		expectedErr string
+		expected    *ThresholdCommand
+	}{
+		{
+			name:     "above threshold",
+			query:    map[string]interface{}{"expression": "$A", "conditions": condition("gt", 80)},
+			expected: &ThresholdCommand{ReferenceVar: "A", ThresholdFunc: "gt", Conditions: []float64{80}, refID: "B"},
+		},
+		{
+			name:     "within range",
+			query:    map[string]interface{}{"expression": "A", "conditions": condition("within_range", 10, 20)},
+			expected: &ThresholdCommand{ReferenceVar: "A", ThresholdFunc: "within_range", Conditions: []float64{10, 20}, refID: "B"},
+		},
+		{
+			name:        "range with a single bound",
+			query:       map[string]interface{}{"expression": "$A", "conditions": condition("outside_range", 10)},
+			expectedErr: "requires 2 parameters",
+		},
+		{
+			name:        "unknown function",
+			query:       map[string]interface{}{"expression": "$A", "conditions": condition("no_value")},
+			expectedErr: "'no_value' for refId B is not supported",
+		},
+		{
+			name:        "no conditions",
+			query:       map[string]interface{}{"expression": "$A"},
+			expectedErr: "no threshold conditions",
+		},
+		{
+			name:        "no variable",
+			query:       map[string]interface{}{"conditions": condition("lt", 1)},
+			expectedErr: "no variable specified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := UnmarshalThresholdCommand(&rawNode{RefID: "B", Query: tt.query})
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cmd)
+		})
+	}
+}
+
+func TestThresholdCommandExecute(t *testing.T) {
+	numbers := mathexp.Results{Values: []mathexp.Value{
+		newTestNumber(data.Labels{"host": "a"}, fp(5)),
+		newTestNumber(data.Labels{"host": "b"}, fp(15)),
+		newTestNumber(data.Labels{"host": "c"}, fp(25)),
+		newTestNumber(data.Labels{"host": "d"}, nil),
+	}}
+
+	var tests = []struct {
+		name       string
+		fn         string
+		conditions []float64
+		expected   []*float64
+	}{
+		{name: "gt", fn: "gt", conditions: []float64{15}, expected: []*float64{fp(0), fp(0), fp(1), nil}},
+		{name: "lt", fn: "lt", conditions: []float64{15}, expected: []*float64{fp(1), fp(0), fp(0), nil}},
+		{name: "within_range", fn: "within_range", conditions: []float64{10, 20}, expected: []*float64{fp(0), fp(1), fp(0), nil}},
+		{name: "within_range with reversed bounds", fn: "within_range", conditions: []float64{20, 10}, expected: []*float64{fp(0), fp(1), fp(0), nil}},
+		{name: "outside_range", fn: "outside_range", conditions: []float64{10, 20}, expected: []*float64{fp(1), fp(0), fp(1), nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := NewThresholdCommand("B", "A", tt.fn, tt.conditions)
+			require.NoError(t, err)
+			res, err := cmd.Execute(context.Background(), mathexp.Vars{"A": numbers})
+			require.NoError(t, err)
+
+			require.Len(t, res.Values, len(tt.expected))
+			for i, v := range res.Values {
+				n, ok := v.(mathexp.Number)
+				require.True(t, ok)
+				require.Equal(t, tt.expected[i], n.GetFloat64Value())
+				require.Equal(t, numbers.Values[i].GetLabels(), n.GetLabels())
+			}
+		})
+	}
+
+	t.Run("series", func(t *testing.T) {
+		series := mathexp.NewSeries("A", data.Labels{"host": "a"}, 0, false, 1, true, 3)
+		require.NoError(t, series.SetPoint(0, utp(1), fp(90)))
+		require.NoError(t, series.SetPoint(1, utp(2), fp(70)))
+		nan := math.NaN()
+		require.NoError(t, series.SetPoint(2, utp(3), &nan))
+
+		cmd, err := NewThresholdCommand("B", "A", "gt", []float64{80})
+		require.NoError(t, err)
+		res, err := cmd.Execute(context.Background(), mathexp.Vars{"A": mathexp.Results{Values: []mathexp.Value{series}}})
+		require.NoError(t, err)
+
+		require.Len(t, res.Values, 1)
+		s, ok := res.Values[0].(mathexp.Series)
+		require.True(t, ok)
+		require.Equal(t, data.Labels{"host": "a"}, s.GetLabels())
+		require.Equal(t, 3, s.Len())
+		for i, expected := range []float64{1, 0} {
+			ts, f := s.GetPoint(i)
+			require.Equal(t, utp(int64(i+1)), ts)
+			require.Equal(t, expected, *f)
+		}
+		require.True(t, math.IsNaN(*s.GetValue(2)))
+	})
+}
+
+func newTestNumber(labels data.Labels, f *float64) mathexp.Number {
+	n := mathexp.NewNumber("A", labels)
+	n.SetValue(f)
+	return n
+}
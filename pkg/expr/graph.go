@@ -42,24 +42,131 @@ type Node interface {
 	String() string
 }
 
+// maxConcurrentNodes is the maximum number of nodes of a pipeline executed concurrently.
+const maxConcurrentNodes = 4
+
 // DataPipeline is an ordered set of nodes returned from DPGraph processing.
 type DataPipeline []Node
 
+// nodeResult is the result of the execution of a node.
+type nodeResult struct {
+	// index is the position of the node in the pipeline.
+	index int
+	node  Node
+	res   mathexp.Results
+	err   error
+}
+
 // execute runs all the command/datasource requests in the pipeline return a
-// map of the refId of the of each command
+// map of the refId of the of each command.
+// Nodes are executed concurrently, up to maxConcurrentNodes at a time, as soon as the
+// nodes they depend on are executed. When nodes fail, the error of the earliest failing
+// node of the pipeline is returned, whatever the order in which the nodes complete: an error
+// cancels the nodes after the failing node, the nodes before it are executed until they complete.
 func (dp *DataPipeline) execute(c context.Context, s *Service) (mathexp.Vars, error) {
-	vars := make(mathexp.Vars)
+	// dependencies on nodes that are not in the pipeline are left to the nodes to report
+	inPipeline := make(map[string]struct{}, len(*dp))
 	for _, node := range *dp {
-		res, err := node.Execute(c, vars, s)
-		if err != nil {
-			return nil, err
+		inPipeline[node.RefID()] = struct{}{}
+	}
+	ready := func(node Node, vars mathexp.Vars) bool {
+		for _, refID := range nodeDependencies(node) {
+			if _, ok := inPipeline[refID]; !ok {
+				continue
+			}
+			if _, ok := vars[refID]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	vars := make(mathexp.Vars, len(*dp))
+	pending := make([]int, 0, len(*dp))
+	for i := range *dp {
+		pending = append(pending, i)
+	}
+	results := make(chan nodeResult)
+	// cancels holds the cancel functions of the running nodes by index
+	cancels := make(map[int]context.CancelFunc, maxConcurrentNodes)
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	// errIndex is the index of the earliest failing node, -1 while no node failed
+	errIndex := -1
+	var nodeErr error
+	for {
+		// the nodes are started in pipeline order, the order in which they complete doesn't matter
+		// as every node gets the results of the nodes it depends on.
+		waiting := pending[:0]
+		for _, i := range pending {
+			if errIndex >= 0 && i > errIndex {
+				// the error of the node could not be returned, and its dependencies may have failed
+				continue
+			}
+			node := (*dp)[i]
+			if len(cancels) >= maxConcurrentNodes || !ready(node, vars) {
+				waiting = append(waiting, i)
+				continue
+			}
+			// every node gets its own copy as vars is updated while it is executed
+			nodeVars := make(mathexp.Vars, len(vars))
+			for k, v := range vars {
+				nodeVars[k] = v
+			}
+			ctx, cancel := context.WithCancel(c)
+			cancels[i] = cancel
+			go func(i int, node Node) {
+				res, err := node.Execute(ctx, nodeVars, s)
+				results <- nodeResult{index: i, node: node, res: res, err: err}
+			}(i, node)
+		}
+		pending = waiting
+
+		if len(cancels) == 0 {
+			break
 		}
 
-		vars[node.RefID()] = res
+		r := <-results
+		cancels[r.index]()
+		delete(cancels, r.index)
+		if r.err != nil {
+			if errIndex < 0 || r.index < errIndex {
+				errIndex, nodeErr = r.index, r.err
+				for i, cancel := range cancels {
+					if i > errIndex {
+						cancel()
+					}
+				}
+			}
+			continue
+		}
+		vars[r.node.RefID()] = r.res
+	}
+
+	if nodeErr != nil {
+		return nil, nodeErr
+	}
+	if len(pending) > 0 {
+		nodes := make([]Node, 0, len(pending))
+		for _, i := range pending {
+			nodes = append(nodes, (*dp)[i])
+		}
+		return nil, fmt.Errorf("unable to execute %v as the nodes depend on each other", nodes)
 	}
 	return vars, nil
 }
 
+// nodeDependencies returns the refIDs of the nodes whose results the node needs.
+func nodeDependencies(node Node) []string {
+	if cmdNode, ok := node.(*CMDNode); ok {
+		return cmdNode.Command.NeedsVars()
+	}
+	return nil
+}
+
 // BuildPipeline builds a graph of the nodes, and returns the nodes in an
 // executable order.
 func (s *Service) buildPipeline(req *Request) (DataPipeline, error) {
@@ -1,10 +1,16 @@
 package expr
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
 )
 
 func TestServicebuildPipeLine(t *testing.T) {
@@ -218,3 +224,164 @@ func getRefIDOrder(nodes []Node) []string {
 	}
 	return ids
 }
+
+func TestDataPipelineExecute(t *testing.T) {
+	const latency = 200 * time.Millisecond
+
+	t.Run("independent nodes are executed concurrently", func(t *testing.T) {
+		pipeline := DataPipeline{
+			newFakeNode(1, "A", &fakeCommand{value: 1, latency: latency}),
+			newFakeNode(2, "B", &fakeCommand{value: 2, latency: latency}),
+			newFakeNode(3, "C", &fakeCommand{value: 3, latency: latency}),
+			newFakeNode(4, "D", &fakeCommand{value: 4, latency: latency}),
+		}
+
+		start := time.Now()
+		vars, err := pipeline.execute(context.Background(), &Service{})
+		require.NoError(t, err)
+		require.Less(t, int64(time.Since(start)), int64(3*latency))
+		require.Equal(t, map[string]float64{"A": 1, "B": 2, "C": 3, "D": 4}, scalarValues(t, vars))
+	})
+
+	t.Run("nodes are executed after their dependencies", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			pipeline := DataPipeline{
+				newFakeNode(1, "A", &fakeCommand{value: 1, latency: latency / 4}),
+				newFakeNode(2, "B", &fakeCommand{value: 2}),
+				newFakeNode(3, "C", &fakeCommand{value: 3, needs: []string{"A", "B"}}),
+				newFakeNode(4, "D", &fakeCommand{value: 4, needs: []string{"C"}, latency: latency / 4}),
+				newFakeNode(5, "E", &fakeCommand{value: 5, needs: []string{"B"}}),
+			}
+
+			vars, err := pipeline.execute(context.Background(), &Service{})
+			require.NoError(t, err)
+			// the value of a node is the sum of its own value and the values of its dependencies
+			require.Equal(t, map[string]float64{"A": 1, "B": 2, "C": 6, "D": 10, "E": 7}, scalarValues(t, vars))
+		}
+	})
+
+	t.Run("the number of concurrent nodes is bounded", func(t *testing.T) {
+		var running, maxRunning int32
+		pipeline := make(DataPipeline, 0, 3*maxConcurrentNodes)
+		for i := 0; i < 3*maxConcurrentNodes; i++ {
+			refID := string(rune('A' + i))
+			pipeline = append(pipeline, newFakeNode(int64(i), refID, &fakeCommand{latency: latency / 4, running: &running, maxRunning: &maxRunning}))
+		}
+
+		vars, err := pipeline.execute(context.Background(), &Service{})
+		require.NoError(t, err)
+		require.Len(t, vars, 3*maxConcurrentNodes)
+		require.Equal(t, int32(maxConcurrentNodes), atomic.LoadInt32(&maxRunning))
+	})
+
+	t.Run("the first error cancels the execution", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		slow := &fakeCommand{latency: 10 * latency}
+		dependent := &fakeCommand{needs: []string{"A"}}
+		pipeline := DataPipeline{
+			newFakeNode(1, "A", &fakeCommand{latency: latency / 4, err: errFailed}),
+			newFakeNode(2, "B", slow),
+			newFakeNode(3, "C", dependent),
+		}
+
+		start := time.Now()
+		_, err := pipeline.execute(context.Background(), &Service{})
+		require.ErrorIs(t, err, errFailed)
+		require.Less(t, int64(time.Since(start)), int64(5*latency))
+		require.True(t, slow.canceled())
+		require.False(t, dependent.executed())
+	})
+
+	t.Run("the error of the earliest failing node is returned", func(t *testing.T) {
+		errFirst := errors.New("first node failed")
+		errSecond := errors.New("second node failed")
+		for i := 0; i < 10; i++ {
+			// the later node fails first, and doesn't cancel the earlier node
+			first := &fakeCommand{latency: latency / 4, err: errFirst}
+			pipeline := DataPipeline{
+				newFakeNode(1, "A", first),
+				newFakeNode(2, "B", &fakeCommand{err: errSecond}),
+			}
+
+			_, err := pipeline.execute(context.Background(), &Service{})
+			require.ErrorIs(t, err, errFirst)
+			require.False(t, first.canceled())
+		}
+	})
+}
+
+func newFakeNode(id int64, refID string, cmd *fakeCommand) *CMDNode {
+	cmd.refID = refID
+	return &CMDNode{
+		baseNode: baseNode{id: id, refID: refID},
+		CMDType:  TypeMath,
+		Command:  cmd,
+	}
+}
+
+// fakeCommand returns the sum of its value and the values of the nodes it needs after the given latency.
+type fakeCommand struct {
+	refID   string
+	value   float64
+	needs   []string
+	latency time.Duration
+	err     error
+
+	running    *int32
+	maxRunning *int32
+
+	executions    int32
+	cancellations int32
+}
+
+func (f *fakeCommand) NeedsVars() []string {
+	return f.needs
+}
+
+func (f *fakeCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	atomic.AddInt32(&f.executions, 1)
+	if f.running != nil {
+		n := atomic.AddInt32(f.running, 1)
+		defer atomic.AddInt32(f.running, -1)
+		for {
+			max := atomic.LoadInt32(f.maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(f.maxRunning, max, n) {
+				break
+			}
+		}
+	}
+
+	select {
+	case <-time.After(f.latency):
+	case <-ctx.Done():
+		atomic.AddInt32(&f.cancellations, 1)
+		return mathexp.Results{}, ctx.Err()
+	}
+	if f.err != nil {
+		return mathexp.Results{}, f.err
+	}
+
+	value := f.value
+	for _, refID := range f.needs {
+		value += *vars[refID].Values[0].(mathexp.Scalar).GetFloat64Value()
+	}
+	return mathexp.NewScalarResults(f.refID, &value), nil
+}
+
+func (f *fakeCommand) executed() bool {
+	return atomic.LoadInt32(&f.executions) > 0
+}
+
+func (f *fakeCommand) canceled() bool {
+	return atomic.LoadInt32(&f.cancellations) > 0
+}
+
+func scalarValues(t *testing.T, vars mathexp.Vars) map[string]float64 {
+	t.Helper()
+	values := make(map[string]float64, len(vars))
+	for refID, res := range vars {
+		require.Len(t, res.Values, 1)
+		values[refID] = *res.Values[0].(mathexp.Scalar).GetFloat64Value()
+	}
+	return values
+}
@@ -5,6 +5,8 @@ import (
 	"math"
 	"reflect"
 	"runtime"
+	"sort"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
@@ -242,6 +244,135 @@ func union(aResults, bResults Results) []*Union {
 	return unions
 }
 
+// matchUnion creates Union objects by matching the labels of each Series or Number within two collections
+// as described by the label matching of a binary operation, like in PromQL. Items without a match are dropped.
+// It returns an error if the matching is ambiguous.
+func matchUnion(aResults, bResults Results, m *parse.VectorMatching) ([]*Union, error) {
+	for _, res := range []Results{aResults, bResults} {
+		for _, v := range res.Values {
+			if v.Type() == parse.TypeScalar {
+				return nil, fmt.Errorf("label matching is only allowed between sets, got %v", v.Type())
+			}
+		}
+	}
+
+	// every item of the "many" side is matched with a single item of the "one" side,
+	// in one-to-one matching the left side is the "many" side but it can't have several items per match
+	many, one := aResults, bResults
+	manySide, oneSide := "left", "right"
+	if m.Card == parse.CardOneToMany {
+		many, one = bResults, aResults
+		manySide, oneSide = "right", "left"
+	}
+
+	oneBySignature := make(map[string]Value, len(one.Values))
+	for _, v := range one.Values {
+		sig := matchingSignature(v.GetLabels(), m)
+		if _, ok := oneBySignature[sig]; ok {
+			if m.Card == parse.CardOneToOne {
+				return nil, fmt.Errorf("many-to-many matching is not allowed: multiple items on the %s side match the labels {%s}", oneSide, sig)
+			}
+			return nil, fmt.Errorf("multiple items on the %s side match the labels {%s}, it must have a single item per match", oneSide, sig)
+		}
+		oneBySignature[sig] = v
+	}
+
+	unions := []*Union{}
+	seen := make(map[string]struct{}, len(many.Values))
+	for _, v := range many.Values {
+		sig := matchingSignature(v.GetLabels(), m)
+		o, ok := oneBySignature[sig]
+		if !ok {
+			continue
+		}
+
+		var labels data.Labels
+		if m.Card == parse.CardOneToOne {
+			if _, ok := seen[sig]; ok {
+				return nil, fmt.Errorf("multiple items on the %s side match the labels {%s}, use group_left or group_right for many-to-one matching", manySide, sig)
+			}
+			seen[sig] = struct{}{}
+			labels = oneToOneLabels(v.GetLabels(), m)
+		} else {
+			labels = groupLabels(v.GetLabels(), o.GetLabels(), m.Include)
+			key := labelsSignature(labels, func(string) bool { return true })
+			if _, ok := seen[key]; ok {
+				return nil, fmt.Errorf("multiple items on the %s side have the labels {%s} once matched, the grouping labels must make the results unique", manySide, key)
+			}
+			seen[key] = struct{}{}
+		}
+
+		u := &Union{Labels: labels, A: v, B: o}
+		if m.Card == parse.CardOneToMany {
+			u.A, u.B = o, v
+		}
+		unions = append(unions, u)
+	}
+	return unions, nil
+}
+
+// matchingSignature returns a string identifying the labels used to match an item, which are the labels
+// of the on() list or all the labels but those of the ignoring() list.
+func matchingSignature(labels data.Labels, m *parse.VectorMatching) string {
+	return labelsSignature(labels, func(name string) bool {
+		return containsString(m.MatchingLabels, name) == m.On
+	})
+}
+
+// labelsSignature returns the labels for which include is true as a string sorted by name.
+func labelsSignature(labels data.Labels, include func(name string) bool) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if include(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// oneToOneLabels returns the labels of the result of one-to-one matching,
+// which are the labels of the on() list or all the labels but those of the ignoring() list.
+func oneToOneLabels(labels data.Labels, m *parse.VectorMatching) data.Labels {
+	result := data.Labels{}
+	for name, value := range labels {
+		if containsString(m.MatchingLabels, name) == m.On {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// groupLabels returns the labels of the result of many-to-one or one-to-many matching, which are the labels
+// of the "many" side with the include labels copied from the "one" side, or removed if it doesn't have them.
+func groupLabels(manyLabels, oneLabels data.Labels, include []string) data.Labels {
+	result := make(data.Labels, len(manyLabels)+len(include))
+	for name, value := range manyLabels {
+		result[name] = value
+	}
+	for _, name := range include {
+		if value, ok := oneLabels[name]; ok {
+			result[name] = value
+		} else {
+			delete(result, name)
+		}
+	}
+	return result
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	res := Results{Values{}}
 	ar, err := e.walk(node.Args[0])
@@ -252,7 +383,15 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	if err != nil {
 		return res, err
 	}
-	unions := union(ar, br)
+	var unions []*Union
+	if node.VectorMatching != nil {
+		unions, err = matchUnion(ar, br, node.VectorMatching)
+		if err != nil {
+			return res, err
+		}
+	} else {
+		unions = union(ar, br)
+	}
 	for _, uni := range unions {
 		var value Value
 		switch at := uni.A.(type) {
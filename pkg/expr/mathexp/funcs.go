@@ -1,8 +1,12 @@
 package mathexp
 
 import (
+	"fmt"
 	"math"
+	"strings"
+	"time"
 
+	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
 
@@ -29,6 +33,88 @@ var builtins = map[string]parse.Func{
 		Return: parse.TypeScalar,
 		F:      null,
 	},
+	"round":   perFloatFunc(math.Round),
+	"ceil":    perFloatFunc(math.Ceil),
+	"floor":   perFloatFunc(math.Floor),
+	"sqrt":    perFloatFunc(math.Sqrt),
+	"exp":     perFloatFunc(math.Exp),
+	"log10":   perFloatFunc(math.Log10),
+	"is_nan":  predicateFunc(func(f *float64) bool { return f != nil && math.IsNaN(*f) }),
+	"is_inf":  predicateFunc(func(f *float64) bool { return f != nil && math.IsInf(*f, 0) }),
+	"is_null": predicateFunc(func(f *float64) bool { return f == nil }),
+	"clamp": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar, parse.TypeScalar},
+		VariantReturn: true,
+		F:             clamp,
+		Check:         checkClamp,
+	},
+	"rate": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      rate,
+	},
+	"delta": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      delta,
+	},
+	"shift": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		F:      shift,
+		Check:  checkDurationArg(1, true),
+	},
+	"moving_avg": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		F:      movingAvg,
+		Check:  checkDurationArg(1, false),
+	},
+}
+
+// perFloatFunc returns a function applying floatF to each value of a NumberSet, SeriesSet, or Scalar.
+func perFloatFunc(floatF func(x float64) float64) parse.Func {
+	return parse.Func{
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F: func(e *State, varSet Results) (Results, error) {
+			newRes := Results{}
+			for _, res := range varSet.Values {
+				newVal, err := perFloat(e, res, floatF)
+				if err != nil {
+					return newRes, err
+				}
+				newRes.Values = append(newRes.Values, newVal)
+			}
+			return newRes, nil
+		},
+	}
+}
+
+// predicateFunc returns a function returning 1 for each value of a NumberSet, SeriesSet, or Scalar
+// matching the predicate, and 0 otherwise. Null values are passed as nil.
+func predicateFunc(predicate func(f *float64) bool) parse.Func {
+	return parse.Func{
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F: func(e *State, varSet Results) (Results, error) {
+			newRes := Results{}
+			for _, res := range varSet.Values {
+				newVal, err := perNullableFloat(e, res, func(f *float64) *float64 {
+					nF := 0.0
+					if predicate(f) {
+						nF = 1
+					}
+					return &nF
+				})
+				if err != nil {
+					return newRes, err
+				}
+				newRes.Values = append(newRes.Values, newVal)
+			}
+			return newRes, nil
+		},
+	}
 }
 
 // abs returns the absolute value for each result in NumberSet, SeriesSet, or Scalar
@@ -74,25 +160,254 @@ func null(e *State) Results {
 	return NewScalarResults(e.RefID, nil)
 }
 
+// clamp limits each value of a NumberSet, SeriesSet, or Scalar to the range [min, max]
+func clamp(e *State, varSet Results, minRes Results, maxRes Results) (Results, error) {
+	newRes := Results{}
+	min, err := scalarArg(minRes)
+	if err != nil {
+		return newRes, fmt.Errorf("clamp: invalid min: %w", err)
+	}
+	max, err := scalarArg(maxRes)
+	if err != nil {
+		return newRes, fmt.Errorf("clamp: invalid max: %w", err)
+	}
+	if min > max {
+		return newRes, fmt.Errorf("clamp: min %v is greater than max %v", min, max)
+	}
+	for _, res := range varSet.Values {
+		newVal, err := perFloat(e, res, func(x float64) float64 {
+			return math.Max(min, math.Min(max, x))
+		})
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+// checkClamp checks at parse time that constant min and max are in order.
+func checkClamp(t *parse.Tree, f *parse.FuncNode) error {
+	min, minOk := f.Args[1].(*parse.ScalarNode)
+	max, maxOk := f.Args[2].(*parse.ScalarNode)
+	if minOk && maxOk && min.Float64 > max.Float64 {
+		return fmt.Errorf("parse: min %v of clamp is greater than max %v", min.Float64, max.Float64)
+	}
+	return nil
+}
+
+// rate returns the per second rate of increase between consecutive points of each series, assuming they
+// are counters: a decreasing value is a counter reset and the increase is the value itself.
+// The result has one point less than the series.
+func rate(e *State, varSet Results) (Results, error) {
+	return perConsecutivePoints(e, "rate", varSet, func(prevT, t time.Time, prev, cur float64) *float64 {
+		dt := t.Sub(prevT).Seconds()
+		if dt <= 0 {
+			return nil
+		}
+		increase := cur - prev
+		if cur < prev {
+			increase = cur
+		}
+		r := increase / dt
+		return &r
+	})
+}
+
+// delta returns the difference between consecutive points of each series.
+// The result has one point less than the series.
+func delta(e *State, varSet Results) (Results, error) {
+	return perConsecutivePoints(e, "delta", varSet, func(_, _ time.Time, prev, cur float64) *float64 {
+		d := cur - prev
+		return &d
+	})
+}
+
+// shift moves each point of each series by the offset, a positive offset moves the points forward in time.
+func shift(e *State, varSet Results, offsetArg string) (Results, error) {
+	newRes := Results{}
+	offset, err := parseDurationArg(offsetArg, true)
+	if err != nil {
+		return newRes, err
+	}
+	for _, res := range varSet.Values {
+		series, err := seriesArg("shift", res)
+		if err != nil {
+			return newRes, err
+		}
+		newSeries := NewSeries(e.RefID, series.GetLabels(), series.TimeIdx, series.TimeIsNullable, series.ValueIdx, series.ValueIsNullable, series.Len())
+		for i := 0; i < series.Len(); i++ {
+			t, f := series.GetPoint(i)
+			if t != nil {
+				shifted := t.Add(offset)
+				t = &shifted
+			}
+			if err := newSeries.SetPoint(i, t, f); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// movingAvg returns for each point of each series the average of the non null values of the points in the
+// window ending at that point. The points must be sorted by time. Points without values in their window are null.
+func movingAvg(e *State, varSet Results, windowArg string) (Results, error) {
+	newRes := Results{}
+	window, err := parseDurationArg(windowArg, false)
+	if err != nil {
+		return newRes, err
+	}
+	for _, res := range varSet.Values {
+		series, err := seriesArg("moving_avg", res)
+		if err != nil {
+			return newRes, err
+		}
+		newSeries := NewSeries(e.RefID, series.GetLabels(), series.TimeIdx, series.TimeIsNullable, series.ValueIdx, true, series.Len())
+		for i := 0; i < series.Len(); i++ {
+			t := series.GetTime(i)
+			if t == nil {
+				return newRes, fmt.Errorf("moving_avg: series %v has a null time", series.GetName())
+			}
+			sum, count := 0.0, 0
+			for j := i; j >= 0; j-- {
+				tj := series.GetTime(j)
+				if tj == nil || !tj.After(t.Add(-window)) {
+					break
+				}
+				if f := series.GetValue(j); f != nil {
+					sum += *f
+					count++
+				}
+			}
+			var avg *float64
+			if count > 0 {
+				a := sum / float64(count)
+				avg = &a
+			}
+			if err := newSeries.SetPoint(i, t, avg); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// perConsecutivePoints returns for each series a series with the result of pointF for each pair of consecutive points,
+// at the time of the second point. The result is null if any of the values is null.
+func perConsecutivePoints(e *State, name string, varSet Results, pointF func(prevT, t time.Time, prev, cur float64) *float64) (Results, error) {
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		series, err := seriesArg(name, res)
+		if err != nil {
+			return newRes, err
+		}
+		size := series.Len() - 1
+		if size < 0 {
+			size = 0
+		}
+		newSeries := NewSeries(e.RefID, series.GetLabels(), series.TimeIdx, series.TimeIsNullable, series.ValueIdx, true, size)
+		for i := 1; i < series.Len(); i++ {
+			prevT, prev := series.GetPoint(i - 1)
+			t, cur := series.GetPoint(i)
+			if prevT == nil || t == nil {
+				return newRes, fmt.Errorf("%s: series %v has a null time", name, series.GetName())
+			}
+			var f *float64
+			if prev != nil && cur != nil {
+				f = pointF(*prevT, *t, *prev, *cur)
+			}
+			if err := newSeries.SetPoint(i-1, t, f); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// seriesArg returns the value as a Series, vars are only known to be series at execution time.
+func seriesArg(name string, val Value) (Series, error) {
+	series, ok := val.(Series)
+	if !ok {
+		return Series{}, fmt.Errorf("%s: expected %v, got %v", name, parse.TypeSeriesSet, val.Type())
+	}
+	return series, nil
+}
+
+// scalarArg returns the value of a scalar argument.
+func scalarArg(res Results) (float64, error) {
+	if len(res.Values) != 1 {
+		return 0, fmt.Errorf("expected a single value, got %v", len(res.Values))
+	}
+	scalar, ok := res.Values[0].(Scalar)
+	if !ok {
+		return 0, fmt.Errorf("expected %v, got %v", parse.TypeScalar, res.Values[0].Type())
+	}
+	f := scalar.GetFloat64Value()
+	if f == nil {
+		return 0, fmt.Errorf("expected a value, got null")
+	}
+	return *f, nil
+}
+
+// parseDurationArg parses a duration such as "5m", which can be negative if allowNegative is true.
+// Otherwise it must be positive.
+func parseDurationArg(s string, allowNegative bool) (time.Duration, error) {
+	negative := allowNegative && strings.HasPrefix(s, "-")
+	if negative {
+		s = strings.TrimPrefix(s, "-")
+	}
+	d, err := gtime.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	if negative {
+		return -d, nil
+	}
+	if !allowNegative && d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// checkDurationArg returns a check that the argument at index i is a valid duration at parse time.
+func checkDurationArg(i int, allowNegative bool) func(t *parse.Tree, f *parse.FuncNode) error {
+	return func(t *parse.Tree, f *parse.FuncNode) error {
+		s, ok := f.Args[i].(*parse.StringNode)
+		if !ok {
+			return nil
+		}
+		if _, err := parseDurationArg(s.Text, allowNegative); err != nil {
+			return fmt.Errorf("parse: %s: %w", f.Name, err)
+		}
+		return nil
+	}
+}
+
+// perFloat applies floatF to each value, null values become NaN.
 func perFloat(e *State, val Value, floatF func(x float64) float64) (Value, error) {
-	var newVal Value
-	switch val.Type() {
-	case parse.TypeNumberSet:
-		n := NewNumber(e.RefID, val.GetLabels())
-		f := val.(Number).GetFloat64Value()
+	return perNullableFloat(e, val, func(f *float64) *float64 {
 		nF := math.NaN()
 		if f != nil {
 			nF = floatF(*f)
 		}
-		n.SetValue(&nF)
+		return &nF
+	})
+}
+
+// perNullableFloat applies floatF to each value, floatF must not return nil for values of non nullable series.
+func perNullableFloat(e *State, val Value, floatF func(f *float64) *float64) (Value, error) {
+	var newVal Value
+	switch val.Type() {
+	case parse.TypeNumberSet:
+		n := NewNumber(e.RefID, val.GetLabels())
+		n.SetValue(floatF(val.(Number).GetFloat64Value()))
 		newVal = n
 	case parse.TypeScalar:
-		f := val.(Scalar).GetFloat64Value()
-		nF := math.NaN()
-		if f != nil {
-			nF = floatF(*f)
-		}
-		newVal = NewScalar(e.RefID, &nF)
+		newVal = NewScalar(e.RefID, floatF(val.(Scalar).GetFloat64Value()))
 	case parse.TypeSeriesSet:
 		resSeries := val.(Series)
 		newSeries := NewSeries(
@@ -101,11 +416,7 @@ func perFloat(e *State, val Value, floatF func(x float64) float64) (Value, error
 		)
 		for i := 0; i < resSeries.Len(); i++ {
 			t, f := resSeries.GetPoint(i)
-			nF := math.NaN()
-			if f != nil {
-				nF = floatF(*f)
-			}
-			if err := newSeries.SetPoint(i, t, &nF); err != nil {
+			if err := newSeries.SetPoint(i, t, floatF(f)); err != nil {
 				return newSeries, err
 			}
 		}
@@ -2,6 +2,7 @@ package mathexp
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -73,6 +74,264 @@ func TestFunc(t *testing.T) {
 			vars:     Vars{},
 			newErrIs: assert.Error,
 		},
+		{
+			name: "round on number",
+			expr: "round($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeNumber("", nil, float64Pointer(2.5)),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{makeNumber("", nil, float64Pointer(3))}},
+		},
+		{
+			name:      "ceil on scalar",
+			expr:      "ceil(1.2)",
+			vars:      Vars{},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{NewScalar("", float64Pointer(2))}},
+		},
+		{
+			name:      "floor on scalar",
+			expr:      "floor(1.8)",
+			vars:      Vars{},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{NewScalar("", float64Pointer(1))}},
+		},
+		{
+			name:      "sqrt, exp and log10 on scalars",
+			expr:      "sqrt(16) + exp(0) + log10(1000)",
+			vars:      Vars{},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{NewScalar("", float64Pointer(8))}},
+		},
+		{
+			name: "clamp on series",
+			expr: "clamp($A, 0, 10)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(5, 0), float64Pointer(-5),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(5),
+						}, tp{
+							time.Unix(15, 0), float64Pointer(15),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(5, 0), float64Pointer(0),
+					}, tp{
+						time.Unix(10, 0), float64Pointer(5),
+					}, tp{
+						time.Unix(15, 0), float64Pointer(10),
+					}),
+				},
+			},
+		},
+		{
+			name:     "clamp with min greater than max - should error",
+			expr:     "clamp($A, 10, 0)",
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
+		{
+			name: "is_null on number",
+			expr: "is_null($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeNumber("", nil, nil),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{makeNumber("", nil, float64Pointer(1))}},
+		},
+		{
+			name:      "is_nan and is_inf on scalars",
+			expr:      "is_nan(nan()) + is_inf(inf()) + is_nan(1)",
+			vars:      Vars{},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{NewScalar("", float64Pointer(2))}},
+		},
+		{
+			name: "rate on series with a counter reset",
+			expr: "rate($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(0),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(10),
+						}, tp{
+							time.Unix(20, 0), float64Pointer(30),
+						}, tp{
+							time.Unix(30, 0), float64Pointer(5),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(10, 0), float64Pointer(1),
+					}, tp{
+						time.Unix(20, 0), float64Pointer(2),
+					}, tp{
+						time.Unix(30, 0), float64Pointer(0.5),
+					}),
+				},
+			},
+		},
+		{
+			name: "delta on series with a null value",
+			expr: "delta($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(1),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(4),
+						}, tp{
+							time.Unix(20, 0), nil,
+						}, tp{
+							time.Unix(30, 0), float64Pointer(6),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(10, 0), float64Pointer(3),
+					}, tp{
+						time.Unix(20, 0), nil,
+					}, tp{
+						time.Unix(30, 0), nil,
+					}),
+				},
+			},
+		},
+		{
+			name: "rate on number - should error",
+			expr: "rate($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeNumber("", nil, float64Pointer(1)),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.Error,
+			resultIs:  assert.Equal,
+			results:   Results{},
+		},
+		{
+			name: "shift on series",
+			expr: `shift($A, "1m")`,
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(1),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(2),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(60, 0), float64Pointer(1),
+					}, tp{
+						time.Unix(70, 0), float64Pointer(2),
+					}),
+				},
+			},
+		},
+		{
+			name:     "shift with invalid duration - should error",
+			expr:     `shift($A, "one minute")`,
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
+		{
+			name: "moving_avg on series",
+			expr: `moving_avg($A, "20s")`,
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(1),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(2),
+						}, tp{
+							time.Unix(20, 0), nil,
+						}, tp{
+							time.Unix(30, 0), float64Pointer(6),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(0, 0), float64Pointer(1),
+					}, tp{
+						time.Unix(10, 0), float64Pointer(1.5),
+					}, tp{
+						time.Unix(20, 0), float64Pointer(2),
+					}, tp{
+						time.Unix(30, 0), float64Pointer(6),
+					}),
+				},
+			},
+		},
+		{
+			name:     "moving_avg with negative window - should error",
+			expr:     `moving_avg($A, "-20s")`,
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -56,10 +56,22 @@ const (
 	itemRightParen
 	itemString
 	itemFunc
-	itemVar // e.g. $A
-	itemPow // '**'
+	itemVar        // e.g. $A
+	itemPow        // '**'
+	itemOn         // 'on', label matching of a binary operator
+	itemIgnoring   // 'ignoring', label matching of a binary operator
+	itemGroupLeft  // 'group_left', many-to-one matching of a binary operator
+	itemGroupRight // 'group_right', one-to-many matching of a binary operator
 )
 
+// keywords are the words lexed as keywords instead of function names.
+var keywords = map[string]itemType{
+	"on":          itemOn,
+	"ignoring":    itemIgnoring,
+	"group_left":  itemGroupLeft,
+	"group_right": itemGroupRight,
+}
+
 const eof = -1
 
 // stateFn represents the state of the scanner as a function that returns the next state.
@@ -180,7 +192,7 @@ Loop:
 		case isNumber(r):
 			l.backup()
 			return lexNumber
-		case unicode.IsLetter(r):
+		case unicode.IsLetter(r) || r == '_':
 			return lexFunc
 		case r == '(':
 			l.emit(itemLeftParen)
@@ -276,10 +288,14 @@ func lexSymbol(l *lexer) stateFn {
 func lexFunc(l *lexer) stateFn {
 	for {
 		switch r := l.next(); {
-		case unicode.IsLetter(r):
+		case isVarchar(r):
 			// absorb
 		default:
 			l.backup()
+			if kw, ok := keywords[l.input[l.start:l.pos]]; ok {
+				l.emit(kw)
+				return lexItem
+			}
 			l.emit(itemFunc)
 			return lexItem
 		}
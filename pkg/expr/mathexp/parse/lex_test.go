@@ -113,6 +113,38 @@ var lexTests = []lexTest{
 		{itemVar, 0, "$A"},
 		tEOF,
 	}},
+	{"function names with digits and underscores", "log10($A) is_nan($A)", []item{
+		{itemFunc, 0, "log10"},
+		{itemLeftParen, 0, "("},
+		{itemVar, 0, "$A"},
+		{itemRightParen, 0, ")"},
+		{itemFunc, 0, "is_nan"},
+		{itemLeftParen, 0, "("},
+		{itemVar, 0, "$A"},
+		{itemRightParen, 0, ")"},
+		tEOF,
+	}},
+	{"label matching keywords", "$A / on(host, _id) group_left(team) $B", []item{
+		{itemVar, 0, "$A"},
+		{itemDiv, 0, "/"},
+		{itemOn, 0, "on"},
+		{itemLeftParen, 0, "("},
+		{itemFunc, 0, "host"},
+		{itemComma, 0, ","},
+		{itemFunc, 0, "_id"},
+		{itemRightParen, 0, ")"},
+		{itemGroupLeft, 0, "group_left"},
+		{itemLeftParen, 0, "("},
+		{itemFunc, 0, "team"},
+		{itemRightParen, 0, ")"},
+		{itemVar, 0, "$B"},
+		tEOF,
+	}},
+	{"ignoring and group_right", "ignoring group_right", []item{
+		{itemIgnoring, 0, "ignoring"},
+		{itemGroupRight, 0, "group_right"},
+		tEOF,
+	}},
 	// errors
 	{"unclosed quote", "\"", []item{
 		{itemError, 0, "unterminated string"},
@@ -9,6 +9,7 @@ package parse
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // A Node is an element in the parse tree. The interface is trivial.
@@ -298,24 +299,56 @@ type BinaryNode struct {
 	Args     [2]Node
 	Operator item
 	OpStr    string
+	// VectorMatching is how the items of the arguments are matched by their labels,
+	// it is nil if the operator has no label matching.
+	VectorMatching *VectorMatching
 }
 
-func newBinary(operator item, arg1, arg2 Node) *BinaryNode {
-	return &BinaryNode{NodeType: NodeBinary, Pos: operator.pos, Args: [2]Node{arg1, arg2}, Operator: operator, OpStr: operator.val}
+func newBinary(operator item, arg1, arg2 Node, matching *VectorMatching) *BinaryNode {
+	return &BinaryNode{NodeType: NodeBinary, Pos: operator.pos, Args: [2]Node{arg1, arg2}, Operator: operator, OpStr: operator.val, VectorMatching: matching}
 }
 
 // String returns the string representation of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) String() string {
+	if b.VectorMatching != nil {
+		return fmt.Sprintf("%s %s %s %s", b.Args[0], b.Operator.val, b.VectorMatching, b.Args[1])
+	}
 	return fmt.Sprintf("%s %s %s", b.Args[0], b.Operator.val, b.Args[1])
 }
 
 // StringAST returns the string representation of abstract syntax tree of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) StringAST() string {
+	if b.VectorMatching != nil {
+		return fmt.Sprintf("%s %s(%s, %s)", b.Operator.val, b.VectorMatching, b.Args[0], b.Args[1])
+	}
 	return fmt.Sprintf("%s(%s, %s)", b.Operator.val, b.Args[0], b.Args[1])
 }
 
 // Check performs parse time checking on the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) Check(t *Tree) error {
+	for _, arg := range b.Args {
+		if err := arg.Check(t); err != nil {
+			return err
+		}
+	}
+	if b.VectorMatching == nil {
+		return nil
+	}
+	for _, arg := range b.Args {
+		if arg.Return() == TypeScalar {
+			return fmt.Errorf("parse: label matching in %s is only allowed between sets, got %v", b, TypeScalar)
+		}
+	}
+	if !b.VectorMatching.On {
+		return nil
+	}
+	for _, include := range b.VectorMatching.Include {
+		for _, l := range b.VectorMatching.MatchingLabels {
+			if include == l {
+				return fmt.Errorf("parse: label %q must not occur in on() and the grouping labels at once in %s", l, b)
+			}
+		}
+	}
 	return nil
 }
 
@@ -329,6 +362,51 @@ func (b *BinaryNode) Return() ReturnType {
 	return t0
 }
 
+// VectorMatchCardinality is the cardinality of the matching of the items of a binary operator.
+type VectorMatchCardinality int
+
+const (
+	// CardOneToOne matches each item with at most one item of the other side.
+	CardOneToOne VectorMatchCardinality = iota
+	// CardManyToOne matches many items of the left side with one item of the right side, with group_left.
+	CardManyToOne
+	// CardOneToMany matches one item of the left side with many items of the right side, with group_right.
+	CardOneToMany
+)
+
+// VectorMatching describes how the items of the two sets of a binary operator are matched by their labels,
+// as in PromQL.
+type VectorMatching struct {
+	// On is true if the items are matched on the MatchingLabels only,
+	// and false if they are matched on all their labels but the MatchingLabels.
+	On             bool
+	MatchingLabels []string
+	Card           VectorMatchCardinality
+	// Include are the labels of the "one" side copied to the results with group_left and group_right.
+	Include []string
+}
+
+// String returns the string representation of the VectorMatching, as it would be written in an expression.
+func (m *VectorMatching) String() string {
+	s := "ignoring"
+	if m.On {
+		s = "on"
+	}
+	s += "(" + strings.Join(m.MatchingLabels, ", ") + ")"
+	switch m.Card {
+	case CardManyToOne:
+		s += " group_left"
+	case CardOneToMany:
+		s += " group_right"
+	default:
+		return s
+	}
+	if len(m.Include) > 0 {
+		s += "(" + strings.Join(m.Include, ", ") + ")"
+	}
+	return s
+}
+
 // UnaryNode holds one argument and an operator.
 type UnaryNode struct {
 	NodeType
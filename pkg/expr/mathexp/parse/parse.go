@@ -165,16 +165,18 @@ func (t *Tree) parse() {
 }
 
 /* Grammar:
-O -> A {"||" A}
-A -> C {"&&" C}
-C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") P}
-P -> M {( "+" | "-" ) M}
-M -> E {( "*" | "/" ) F}
-E -> F {( "**" ) F}
+O -> A {"||" [Match] A}
+A -> C {"&&" [Match] C}
+C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") [Match] P}
+P -> M {( "+" | "-" ) [Match] M}
+M -> E {( "*" | "/" ) [Match] F}
+E -> F {( "**" ) [Match] F}
 F -> v | "(" O ")" | "!" O | "-" O
 v -> number | func(..) | queryVar
 Func -> name "(" param {"," param} ")"
 param -> number | "string" | queryVar
+Match -> ( "on" | "ignoring" ) Labels [( "group_left" | "group_right" ) [Labels]]
+Labels -> "(" [name {"," name}] ")"
 */
 
 // expr:
@@ -185,7 +187,7 @@ func (t *Tree) O() Node {
 	for {
 		switch t.peek().typ {
 		case itemOr:
-			n = newBinary(t.next(), n, t.A())
+			n = t.binary(t.next(), n, t.A)
 		default:
 			return n
 		}
@@ -198,7 +200,7 @@ func (t *Tree) A() Node {
 	for {
 		switch t.peek().typ {
 		case itemAnd:
-			n = newBinary(t.next(), n, t.C())
+			n = t.binary(t.next(), n, t.C)
 		default:
 			return n
 		}
@@ -211,7 +213,7 @@ func (t *Tree) C() Node {
 	for {
 		switch t.peek().typ {
 		case itemEq, itemNotEq, itemGreater, itemGreaterEq, itemLess, itemLessEq:
-			n = newBinary(t.next(), n, t.P())
+			n = t.binary(t.next(), n, t.P)
 		default:
 			return n
 		}
@@ -224,7 +226,7 @@ func (t *Tree) P() Node {
 	for {
 		switch t.peek().typ {
 		case itemPlus, itemMinus:
-			n = newBinary(t.next(), n, t.M())
+			n = t.binary(t.next(), n, t.M)
 		default:
 			return n
 		}
@@ -237,7 +239,7 @@ func (t *Tree) M() Node {
 	for {
 		switch t.peek().typ {
 		case itemMult, itemDiv, itemMod:
-			n = newBinary(t.next(), n, t.E())
+			n = t.binary(t.next(), n, t.E)
 		default:
 			return n
 		}
@@ -250,13 +252,71 @@ func (t *Tree) E() Node {
 	for {
 		switch t.peek().typ {
 		case itemPow:
-			n = newBinary(t.next(), n, t.F())
+			n = t.binary(t.next(), n, t.F)
 		default:
 			return n
 		}
 	}
 }
 
+// binary parses the optional label matching and the right hand side of a binary operator.
+func (t *Tree) binary(operator item, lhs Node, rhs func() Node) Node {
+	matching := t.vectorMatching()
+	return newBinary(operator, lhs, rhs(), matching)
+}
+
+// vectorMatching is the optional Match in the grammar, it returns nil if there is none.
+func (t *Tree) vectorMatching() *VectorMatching {
+	var m *VectorMatching
+	switch t.peek().typ {
+	case itemOn, itemIgnoring:
+		m = &VectorMatching{On: t.next().typ == itemOn, Card: CardOneToOne}
+		m.MatchingLabels = t.labels("label matching")
+	default:
+		return nil
+	}
+
+	switch t.peek().typ {
+	case itemGroupLeft:
+		t.next()
+		m.Card = CardManyToOne
+	case itemGroupRight:
+		t.next()
+		m.Card = CardOneToMany
+	default:
+		return m
+	}
+	if t.peek().typ == itemLeftParen {
+		m.Include = t.labels("grouping")
+	}
+	return m
+}
+
+// labels is Labels in the grammar.
+func (t *Tree) labels(context string) []string {
+	t.expect(itemLeftParen, context)
+	labels := []string{}
+	for {
+		token := t.next()
+		switch token.typ {
+		case itemRightParen:
+			return labels
+		case itemFunc, itemOn, itemIgnoring, itemGroupLeft, itemGroupRight:
+			// the keywords are label names inside a label list
+			labels = append(labels, token.val)
+		default:
+			t.unexpected(token, context)
+		}
+		switch token = t.next(); token.typ {
+		case itemComma:
+		case itemRightParen:
+			return labels
+		default:
+			t.unexpected(token, context)
+		}
+	}
+}
+
 // F is v | "(" O ")" | "!" O | "-" O in the grammar.
 func (t *Tree) F() Node {
 	switch token := t.peek(); token.typ {
@@ -3,6 +3,10 @@ package mathexp
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
@@ -69,13 +73,206 @@ func Count(fv *Float64Field) *float64 {
 	return &f
 }
 
-// Reduce turns the Series into a Number based on the given reduction function
-func (s Series) Reduce(refID, rFunc string) (Number, error) {
+// CountNonNull returns the number of values that are neither null nor NaN.
+func CountNonNull(fv *Float64Field) *float64 {
+	var f float64
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v != nil && !math.IsNaN(*v) {
+			f++
+		}
+	}
+	return &f
+}
+
+// Last returns the value of the last point, NaN if the series is empty or the value is null.
+func Last(fv *Float64Field) *float64 {
+	if fv.Len() == 0 {
+		nan := math.NaN()
+		return &nan
+	}
+	v := fv.GetValue(fv.Len() - 1)
+	if v == nil {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *v
+	return &f
+}
+
+// Diff returns the difference between the last and the first values,
+// NaN if the series is empty or one of them is null.
+func Diff(fv *Float64Field) *float64 {
+	if fv.Len() == 0 {
+		nan := math.NaN()
+		return &nan
+	}
+	first, last := fv.GetValue(0), fv.GetValue(fv.Len()-1)
+	if first == nil || last == nil {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *last - *first
+	return &f
+}
+
+// StdDev returns the population standard deviation of the values.
+// If any values are null or NaN, or if the series is empty, NaN is returned.
+func StdDev(fv *Float64Field) *float64 {
+	values, ok := sortedValues(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	f := math.Sqrt(squares / float64(len(values)))
+	return &f
+}
+
+// Median returns the median of the values.
+// If any values are null or NaN, or if the series is empty, NaN is returned.
+func Median(fv *Float64Field) *float64 {
+	return Percentile(fv, 50)
+}
+
+// Percentile returns the p-th percentile of the values, between 0 and 100, interpolating linearly
+// between the closest ranks. If any values are null or NaN, or if the series is empty, NaN is returned.
+func Percentile(fv *Float64Field, p float64) *float64 {
+	values, ok := sortedValues(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	f := values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+	return &f
+}
+
+// sortedValues returns the values sorted in increasing order,
+// it returns false if the field is empty or any value is null or NaN.
+func sortedValues(fv *Float64Field) ([]float64, bool) {
+	if fv.Len() == 0 {
+		return nil, false
+	}
+	values := make([]float64, fv.Len())
+	for i := range values {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return nil, false
+		}
+		values[i] = *v
+	}
+	sort.Float64s(values)
+	return values, true
+}
+
+// ReduceMapper changes the points of a series before it is reduced.
+type ReduceMapper interface {
+	MapInput(s Series) Series
+}
+
+// DropNonNumber is a ReduceMapper removing the points whose value is null, NaN or infinite.
+type DropNonNumber struct{}
+
+// MapInput returns a new series without the points whose value is null, NaN or infinite.
+func (DropNonNumber) MapInput(s Series) Series {
+	return mapNonNumbers(s, func(_ *float64) *float64 { return nil })
+}
+
+// ReplaceNonNumberWithValue is a ReduceMapper replacing the values that are null, NaN or infinite with Value.
+type ReplaceNonNumberWithValue struct {
+	Value float64
+}
+
+// MapInput returns a new series where the values that are null, NaN or infinite are replaced with Value.
+func (r ReplaceNonNumberWithValue) MapInput(s Series) Series {
+	return mapNonNumbers(s, func(_ *float64) *float64 {
+		v := r.Value
+		return &v
+	})
+}
+
+// mapNonNumbers returns a copy of the series where the values that are null, NaN or infinite
+// are replaced with the result of replace, the point is dropped if the result is nil.
+func mapNonNumbers(s Series, replace func(f *float64) *float64) Series {
+	type point struct {
+		t *time.Time
+		f *float64
+	}
+	points := make([]point, 0, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		t, f := s.GetPoint(i)
+		if f == nil || math.IsNaN(*f) || math.IsInf(*f, 0) {
+			f = replace(f)
+			if f == nil {
+				continue
+			}
+		}
+		points = append(points, point{t: t, f: f})
+	}
+
+	newSeries := NewSeries(s.Frame.Fields[s.ValueIdx].Name, s.GetLabels(), s.TimeIdx, s.TimeIsNullable, s.ValueIdx, s.ValueIsNullable, len(points))
+	for i, p := range points {
+		// the points come from the series, so they fit its nullability
+		_ = newSeries.SetPoint(i, p.t, p.f)
+	}
+	return newSeries
+}
+
+// ParsePercentileReducer returns the percentile of a reducer such as "percentile(95)",
+// false if the reducer isn't a percentile, or an error if the percentile isn't between 0 and 100.
+func ParsePercentileReducer(rFunc string) (float64, bool, error) {
+	if !strings.HasPrefix(rFunc, "percentile(") || !strings.HasSuffix(rFunc, ")") {
+		return 0, false, nil
+	}
+	raw := strings.TrimSuffix(strings.TrimPrefix(rFunc, "percentile("), ")")
+	p, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid percentile %q: %w", raw, err)
+	}
+	if math.IsNaN(p) || p < 0 || p > 100 {
+		return 0, true, fmt.Errorf("invalid percentile %v: must be between 0 and 100", p)
+	}
+	return p, true, nil
+}
+
+// ValidReducer returns an error if the reduction function isn't supported.
+func ValidReducer(rFunc string) error {
+	switch rFunc {
+	case "sum", "mean", "min", "max", "count", "count_non_null", "last", "median", "stddev", "diff":
+		return nil
+	}
+	_, ok, err := ParsePercentileReducer(rFunc)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("reduction %v not implemented", rFunc)
+	}
+	return nil
+}
+
+// Reduce turns the Series into a Number based on the given reduction function.
+// If mapper is not nil, the series is passed through it before the reduction.
+func (s Series) Reduce(refID, rFunc string, mapper ReduceMapper) (Number, error) {
 	var l data.Labels
 	if s.GetLabels() != nil {
 		l = s.GetLabels().Copy()
 	}
 	number := NewNumber(refID, l)
+	if mapper != nil {
+		s = mapper.MapInput(s)
+	}
 	var f *float64
 	fVec := s.Frame.Fields[s.ValueIdx]
 	floatField := Float64Field(*fVec)
@@ -90,8 +287,25 @@ func (s Series) Reduce(refID, rFunc string) (Number, error) {
 		f = Max(&floatField)
 	case "count":
 		f = Count(&floatField)
+	case "count_non_null":
+		f = CountNonNull(&floatField)
+	case "last":
+		f = Last(&floatField)
+	case "median":
+		f = Median(&floatField)
+	case "stddev":
+		f = StdDev(&floatField)
+	case "diff":
+		f = Diff(&floatField)
 	default:
-		return number, fmt.Errorf("reduction %v not implemented", rFunc)
+		p, ok, err := ParsePercentileReducer(rFunc)
+		if err != nil {
+			return number, err
+		}
+		if !ok {
+			return number, fmt.Errorf("reduction %v not implemented", rFunc)
+		}
+		f = Percentile(&floatField, p)
 	}
 	number.SetValue(f)
 
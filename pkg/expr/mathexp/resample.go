@@ -2,20 +2,36 @@ package mathexp
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
+// UpsampleOptions configures how the samples without a point are filled.
+type UpsampleOptions struct {
+	// FillValue is the value of the samples filled by the "value" upsampler.
+	FillValue float64
+	// MaxGap, when positive, is the longest gap between points that is filled,
+	// the samples in longer gaps stay null.
+	MaxGap time.Duration
+}
+
+// point is a point of a series used to fill a sample without a point.
+type point struct {
+	t time.Time
+	v *float64
+}
+
 // Resample turns the Series into a Number based on the given reduction function
-func (s Series) Resample(refID string, interval time.Duration, downsampler string, upsampler string, from, to time.Time) (Series, error) {
+func (s Series) Resample(refID string, interval time.Duration, downsampler string, upsampler string, from, to time.Time, opts UpsampleOptions) (Series, error) {
 	newSeriesLength := int(float64(to.Sub(from).Nanoseconds()) / float64(interval.Nanoseconds()))
 	if newSeriesLength <= 0 {
 		return s, fmt.Errorf("the series cannot be sampled further; the time range is shorter than the interval")
 	}
 	resampled := NewSeries(refID, s.GetLabels(), s.TimeIdx, true, s.ValueIdx, true, newSeriesLength+1)
 	bookmark := 0
-	var lastSeen *float64
+	var lastSeen *point
 	idx := 0
 	t := from
 	for !t.After(to) && idx <= newSeriesLength {
@@ -31,28 +47,19 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 			}
 			bookmark++
 			sIdx++
-			lastSeen = v
+			lastSeen = &point{t: *st, v: v}
 			vals = append(vals, v)
 		}
 		var value *float64
 		if len(vals) == 0 { // upsampling
-			switch upsampler {
-			case "pad":
-				if lastSeen != nil {
-					value = lastSeen
-				} else {
-					value = nil
-				}
-			case "backfilling":
-				if sIdx == s.Len() { // no vals left
-					value = nil
-				} else {
-					_, value = s.GetPoint(sIdx)
-				}
-			case "fillna":
-				value = nil
-			default:
-				return s, fmt.Errorf("upsampling %v not implemented", upsampler)
+			var next *point
+			if sIdx < s.Len() {
+				st, v := s.GetPoint(sIdx)
+				next = &point{t: *st, v: v}
+			}
+			var err error
+			if value, err = upsample(upsampler, t, lastSeen, next, opts); err != nil {
+				return s, err
 			}
 		} else { // downsampling
 			fVec := data.NewField("", s.GetLabels(), vals)
@@ -81,3 +88,109 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 	}
 	return resampled, nil
 }
+
+// Align returns a Series with the timestamps of the target Series. The value at a timestamp is the value
+// of the point of the series at that timestamp, or is filled by the upsampler when there is no such point.
+// Both series must be sorted by time, the points of the series with a null timestamp are skipped.
+func (s Series) Align(refID string, target Series, upsampler string, opts UpsampleOptions) (Series, error) {
+	if err := validateUpsampler(upsampler); err != nil {
+		return s, err
+	}
+
+	// the indices of the points with a timestamp
+	points := make([]int, 0, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		if s.GetTime(i) != nil {
+			points = append(points, i)
+		}
+	}
+
+	aligned := NewSeries(refID, s.GetLabels(), s.TimeIdx, true, s.ValueIdx, true, target.Len())
+	for idx := 0; idx < target.Len(); idx++ {
+		t := target.GetTime(idx)
+		if t == nil {
+			return s, fmt.Errorf("can not align to a series with null timestamps")
+		}
+
+		// the first point at or after the timestamp
+		pIdx := sort.Search(len(points), func(i int) bool {
+			return !s.GetTime(points[i]).Before(*t)
+		})
+
+		var value *float64
+		if pIdx < len(points) && s.GetTime(points[pIdx]).Equal(*t) {
+			value = s.GetValue(points[pIdx])
+		} else {
+			var prev, next *point
+			if pIdx > 0 {
+				st, v := s.GetPoint(points[pIdx-1])
+				prev = &point{t: *st, v: v}
+			}
+			if pIdx < len(points) {
+				st, v := s.GetPoint(points[pIdx])
+				next = &point{t: *st, v: v}
+			}
+			var err error
+			if value, err = upsample(upsampler, *t, prev, next, opts); err != nil {
+				return s, err
+			}
+		}
+
+		tv := *t
+		if err := aligned.SetPoint(idx, &tv, value); err != nil {
+			return aligned, err
+		}
+	}
+	return aligned, nil
+}
+
+// validateUpsampler returns an error if the upsampler is not supported.
+func validateUpsampler(upsampler string) error {
+	switch upsampler {
+	case "pad", "backfilling", "linear", "value", "fillna":
+		return nil
+	default:
+		return fmt.Errorf("upsampling %v not implemented", upsampler)
+	}
+}
+
+// upsample returns the value of a sample at t without a point, given the points before and after it if any.
+func upsample(upsampler string, t time.Time, prev, next *point, opts UpsampleOptions) (*float64, error) {
+	withinGap := func(d time.Duration) bool {
+		return opts.MaxGap <= 0 || d <= opts.MaxGap
+	}
+
+	switch upsampler {
+	case "pad":
+		if prev == nil || !withinGap(t.Sub(prev.t)) {
+			return nil, nil
+		}
+		return prev.v, nil
+	case "backfilling":
+		if next == nil || !withinGap(next.t.Sub(t)) {
+			return nil, nil
+		}
+		return next.v, nil
+	case "linear":
+		if prev == nil || next == nil || prev.v == nil || next.v == nil || !withinGap(next.t.Sub(prev.t)) {
+			return nil, nil
+		}
+		if !next.t.After(prev.t) {
+			return prev.v, nil
+		}
+		ratio := float64(t.Sub(prev.t)) / float64(next.t.Sub(prev.t))
+		v := *prev.v + (*next.v-*prev.v)*ratio
+		return &v, nil
+	case "value":
+		// the samples before the first point or after the last one are in a gap of unknown length
+		if opts.MaxGap > 0 && (prev == nil || next == nil || !withinGap(next.t.Sub(prev.t))) {
+			return nil, nil
+		}
+		v := opts.FillValue
+		return &v, nil
+	case "fillna":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("upsampling %v not implemented", upsampler)
+	}
+}
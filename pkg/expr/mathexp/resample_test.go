@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,6 +16,7 @@ func TestResampleSeries(t *testing.T) {
 		interval         time.Duration
 		downsampler      string
 		upsampler        string
+		opts             UpsampleOptions
 		timeRange        backend.TimeRange
 		seriesToResample Series
 		series           Series
@@ -273,10 +275,111 @@ func TestResampleSeries(t *testing.T) {
 				unixTimePointer(10, 0), nil,
 			}),
 		},
+		{
+			name:        "resample series: upsampling (mean / linear )",
+			interval:    time.Second * 2,
+			downsampler: "mean",
+			upsampler:   "linear",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(10, 0), float64Pointer(6),
+			}),
+			series: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(0, 0), nil,
+			}, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(4, 0), float64Pointer(3),
+			}, nullTimeTP{
+				unixTimePointer(6, 0), float64Pointer(4),
+			}, nullTimeTP{
+				unixTimePointer(8, 0), float64Pointer(5),
+			}, nullTimeTP{
+				unixTimePointer(10, 0), float64Pointer(6),
+			}),
+		},
+		{
+			name:        "resample series: upsampling (mean / value ) with max gap",
+			interval:    time.Second * 2,
+			downsampler: "mean",
+			upsampler:   "value",
+			opts:        UpsampleOptions{FillValue: 0, MaxGap: 4 * time.Second},
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(6, 0), float64Pointer(5),
+			}, nullTimeTP{
+				unixTimePointer(11, 0), float64Pointer(1),
+			}),
+			series: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(0, 0), nil,
+			}, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(4, 0), float64Pointer(0),
+			}, nullTimeTP{
+				unixTimePointer(6, 0), float64Pointer(5),
+			}, nullTimeTP{
+				unixTimePointer(8, 0), nil,
+			}, nullTimeTP{
+				unixTimePointer(10, 0), nil,
+			}),
+		},
+		{
+			name:        "resample series: upsampling (mean / pad ) with max gap",
+			interval:    time.Second * 2,
+			downsampler: "mean",
+			upsampler:   "pad",
+			opts:        UpsampleOptions{MaxGap: 3 * time.Second},
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(7, 0), float64Pointer(1),
+			}),
+			series: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(0, 0), nil,
+			}, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(4, 0), float64Pointer(2),
+			}, nullTimeTP{
+				unixTimePointer(6, 0), nil,
+			}, nullTimeTP{
+				unixTimePointer(8, 0), float64Pointer(1),
+			}, nullTimeTP{
+				unixTimePointer(10, 0), float64Pointer(1),
+			}),
+		},
+		{
+			name:        "resample series: unknown upsampler",
+			interval:    time.Second * 2,
+			downsampler: "mean",
+			upsampler:   "cubic",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeriesNullableTime("", nil, nullTimeTP{
+				unixTimePointer(2, 0), float64Pointer(2),
+			}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			series, err := tt.seriesToResample.Resample("", tt.interval, tt.downsampler, tt.upsampler, tt.timeRange.From, tt.timeRange.To)
+			series, err := tt.seriesToResample.Resample("", tt.interval, tt.downsampler, tt.upsampler, tt.timeRange.From, tt.timeRange.To, tt.opts)
 			if tt.series.Frame == nil {
 				require.Error(t, err)
 			} else {
@@ -286,3 +389,80 @@ func TestResampleSeries(t *testing.T) {
 		})
 	}
 }
+
+func TestAlignSeries(t *testing.T) {
+	// a series every 10s from 0s to 40s
+	target := makeSeriesNullableTime("", nil, nullTimeTP{
+		unixTimePointer(0, 0), float64Pointer(100),
+	}, nullTimeTP{
+		unixTimePointer(10, 0), float64Pointer(100),
+	}, nullTimeTP{
+		unixTimePointer(20, 0), float64Pointer(100),
+	}, nullTimeTP{
+		unixTimePointer(30, 0), float64Pointer(100),
+	}, nullTimeTP{
+		unixTimePointer(40, 0), float64Pointer(100),
+	})
+	// a series at 5s, 10s and 35s
+	series := makeSeriesNullableTime("", data.Labels{"host": "a"}, nullTimeTP{
+		unixTimePointer(5, 0), float64Pointer(1),
+	}, nullTimeTP{
+		unixTimePointer(10, 0), float64Pointer(2),
+	}, nullTimeTP{
+		unixTimePointer(35, 0), float64Pointer(7),
+	})
+
+	var tests = []struct {
+		name      string
+		upsampler string
+		opts      UpsampleOptions
+		expected  []*float64
+	}{
+		{name: "fillna", upsampler: "fillna", expected: []*float64{nil, float64Pointer(2), nil, nil, nil}},
+		{name: "pad", upsampler: "pad", expected: []*float64{nil, float64Pointer(2), float64Pointer(2), float64Pointer(2), float64Pointer(7)}},
+		{name: "backfilling", upsampler: "backfilling", expected: []*float64{float64Pointer(1), float64Pointer(2), float64Pointer(7), float64Pointer(7), nil}},
+		{name: "linear", upsampler: "linear", expected: []*float64{nil, float64Pointer(2), float64Pointer(4), float64Pointer(6), nil}},
+		{name: "value", upsampler: "value", opts: UpsampleOptions{FillValue: -1}, expected: []*float64{float64Pointer(-1), float64Pointer(2), float64Pointer(-1), float64Pointer(-1), float64Pointer(-1)}},
+		{name: "pad with max gap", upsampler: "pad", opts: UpsampleOptions{MaxGap: 15 * time.Second}, expected: []*float64{nil, float64Pointer(2), float64Pointer(2), nil, float64Pointer(7)}},
+		{name: "linear with max gap", upsampler: "linear", opts: UpsampleOptions{MaxGap: 20 * time.Second}, expected: []*float64{nil, float64Pointer(2), nil, nil, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aligned, err := series.Align("B", target, tt.upsampler, tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, data.Labels{"host": "a"}, aligned.GetLabels())
+			require.Equal(t, target.Len(), aligned.Len())
+			for i, expected := range tt.expected {
+				ts, v := aligned.GetPoint(i)
+				require.Equal(t, target.GetTime(i), ts)
+				require.Equal(t, expected, v, "point %d", i)
+			}
+		})
+	}
+
+	t.Run("unknown upsampler", func(t *testing.T) {
+		_, err := series.Align("B", target, "cubic", UpsampleOptions{})
+		require.Error(t, err)
+
+		// the upsampler is validated even if no sample is filled
+		_, err = target.Align("B", target, "cubic", UpsampleOptions{})
+		require.Error(t, err)
+	})
+
+	t.Run("the points with a null timestamp are skipped", func(t *testing.T) {
+		withNullTime := makeSeriesNullableTime("", nil, nullTimeTP{
+			unixTimePointer(10, 0), float64Pointer(2),
+		}, nullTimeTP{
+			nil, float64Pointer(5),
+		}, nullTimeTP{
+			unixTimePointer(30, 0), float64Pointer(3),
+		})
+		aligned, err := withNullTime.Align("B", target, "pad", UpsampleOptions{})
+		require.NoError(t, err)
+		expected := []*float64{nil, float64Pointer(2), float64Pointer(2), float64Pointer(3), float64Pointer(3)}
+		for i := range expected {
+			require.Equal(t, expected[i], aligned.GetValue(i), "point %d", i)
+		}
+	})
+}
@@ -5,6 +5,9 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
 
 func Test_union(t *testing.T) {
@@ -242,3 +245,210 @@ func Test_union(t *testing.T) {
 		})
 	}
 }
+
+func Test_matchUnion(t *testing.T) {
+	var tests = []struct {
+		name     string
+		aResults Results
+		bResults Results
+		matching *parse.VectorMatching
+		errIs    assert.ErrorAssertionFunc
+		unions   []*Union
+	}{
+		{
+			name: "on matches only on the listed labels",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					makeNumber("a", data.Labels{"host": "b", "pod": "2"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a"},
+					A:      makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					B:      makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+				},
+			},
+		},
+		{
+			name: "ignoring matches on all the other labels",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a", "pod": "2"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{MatchingLabels: []string{"pod"}},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a"},
+					A:      makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					B:      makeNumber("b", data.Labels{"host": "a", "pod": "2"}, nil),
+				},
+			},
+		},
+		{
+			name: "one-to-one matching with several items on a side is ambiguous",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					makeNumber("a", data.Labels{"host": "a", "pod": "2"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}},
+			errIs:    assert.Error,
+		},
+		{
+			name: "group_left matches many items of the left side and includes the labels of the right side",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					makeNumber("a", data.Labels{"host": "a", "pod": "2"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}, Card: parse.CardManyToOne, Include: []string{"team"}},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a", "pod": "1", "team": "x"},
+					A:      makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					B:      makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+				},
+				{
+					Labels: data.Labels{"host": "a", "pod": "2", "team": "x"},
+					A:      makeNumber("a", data.Labels{"host": "a", "pod": "2"}, nil),
+					B:      makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+				},
+			},
+		},
+		{
+			name: "group_right keeps the left side as A",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a", "pod": "1"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}, Card: parse.CardOneToMany},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a", "pod": "1"},
+					A:      makeNumber("a", data.Labels{"host": "a"}, nil),
+					B:      makeNumber("b", data.Labels{"host": "a", "pod": "1"}, nil),
+				},
+			},
+		},
+		{
+			name: "group_left with several items on the right side is ambiguous",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+					makeNumber("b", data.Labels{"host": "a", "team": "y"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}, Card: parse.CardManyToOne},
+			errIs:    assert.Error,
+		},
+		{
+			name: "group_left with results having the same labels is ambiguous",
+			aResults: Results{
+				Values: Values{
+					makeNumber("a", data.Labels{"host": "a", "pod": "1"}, nil),
+					makeNumber("a", data.Labels{"host": "a", "pod": "1", "team": "y"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("b", data.Labels{"host": "a", "team": "x"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}, Card: parse.CardManyToOne, Include: []string{"team"}},
+			errIs:    assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unions, err := matchUnion(tt.aResults, tt.bResults, tt.matching)
+			tt.errIs(t, err)
+			if err == nil {
+				assert.EqualValues(t, tt.unions, unions)
+			}
+		})
+	}
+}
+
+func TestBinaryLabelMatching(t *testing.T) {
+	vars := Vars{
+		"A": Results{
+			Values: Values{
+				makeNumber("", data.Labels{"host": "a", "pod": "1"}, float64Pointer(4)),
+				makeNumber("", data.Labels{"host": "b", "pod": "2"}, float64Pointer(9)),
+			},
+		},
+		"B": Results{
+			Values: Values{
+				makeNumber("", data.Labels{"host": "a"}, float64Pointer(2)),
+				makeNumber("", data.Labels{"host": "b"}, float64Pointer(3)),
+			},
+		},
+	}
+
+	e, err := New("$A / on(host) $B")
+	require.NoError(t, err)
+	res, err := e.Execute("", vars)
+	require.NoError(t, err)
+	require.Equal(t, Results{
+		Values: Values{
+			makeNumber("", data.Labels{"host": "a"}, float64Pointer(2)),
+			makeNumber("", data.Labels{"host": "b"}, float64Pointer(3)),
+		},
+	}, res)
+
+	_, err = New("$A / on(host) 2")
+	require.Error(t, err)
+
+	t.Run("the keywords are label names inside a label list", func(t *testing.T) {
+		e, err := New("$A / ignoring(on, ignoring) group_left(group_left, group_right) $B")
+		require.NoError(t, err)
+		node, ok := e.Root.(*parse.BinaryNode)
+		require.True(t, ok)
+		require.Equal(t, &parse.VectorMatching{
+			MatchingLabels: []string{"on", "ignoring"},
+			Card:           parse.CardManyToOne,
+			Include:        []string{"group_left", "group_right"},
+		}, node.VectorMatching)
+	})
+}
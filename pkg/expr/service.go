@@ -2,6 +2,7 @@ package expr
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/setting"
@@ -24,6 +25,11 @@ const DatasourceUID = "-100"
 type Service struct {
 	Cfg         *setting.Cfg
 	DataService *tsdb.Service
+
+	// QueryCache, when set with a positive QueryCacheTTL, caches the responses of
+	// the data source queries of the pipelines for QueryCacheTTL.
+	QueryCache    *QueryCache
+	QueryCacheTTL time.Duration
 }
 
 func (s *Service) isDisabled() bool {
@@ -9,7 +9,7 @@ import (
 	redis "gopkg.in/redis.v5"
 )
 
-func Test_parseRedisConnStr(t *testing.T) {
+func Test_ParseRedisConnStr(t *testing.T) {
 	cases := map[string]struct {
 		InputConnStr  string
 		OutputOptions *redis.Options
@@ -87,7 +87,7 @@ func Test_parseRedisConnStr(t *testing.T) {
 	}
 
 	for reason, testCase := range cases {
-		options, err := parseRedisConnStr(testCase.InputConnStr)
+		options, err := ParseRedisConnStr(testCase.InputConnStr)
 		if testCase.ShouldErr {
 			assert.Error(t, err, fmt.Sprintf("error cases should return non-nil error for test case %v", reason))
 			assert.Nil(t, options, fmt.Sprintf("error cases should return nil for redis options for test case %v", reason))
@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -98,3 +99,96 @@ type GetLiveMessageQuery struct {
 	OrgId   int64
 	Channel string
 }
+
+// LiveChannelFrame is a frame of the history of a managed stream channel.
+type LiveChannelFrame struct {
+	OrgId   int64
+	Channel string
+	Data    json.RawMessage
+	Created time.Time
+}
+
+// SaveLiveChannelFramesCommand saves a batch of frames of the history of the channels.
+type SaveLiveChannelFramesCommand struct {
+	Frames []*LiveChannelFrame
+}
+
+// GetLiveChannelFramesQuery returns the latest frames of a channel created since a time, oldest first.
+type GetLiveChannelFramesQuery struct {
+	OrgId   int64
+	Channel string
+	Since   time.Time
+	// Limit is the maximum number of frames, 0 means no limit.
+	Limit int
+}
+
+// DeleteLiveChannelFramesCommand deletes the frames of all the channels created before a time.
+type DeleteLiveChannelFramesCommand struct {
+	Before time.Time
+}
+
+// TrimLiveChannelFramesCommand deletes the frames of a channel but the latest ones.
+type TrimLiveChannelFramesCommand struct {
+	OrgId   int64
+	Channel string
+	// Keep is the number of latest frames kept.
+	Keep int
+}
+
+var (
+	ErrLiveChannelRuleNotFound = errors.New("live channel rule not found")
+	ErrLiveChannelRuleExists   = errors.New("a live channel rule with the same pattern already exists")
+)
+
+// LiveChannelRule restricts who can subscribe and publish to the Live channels matching
+// a pattern. An action is allowed to the users with its role, or with its permission when
+// access control is enabled. An action without a role nor a permission is allowed to all
+// the users of the organization. The publications of the matching managed stream channels
+// to their subscribers can also be throttled: PublishIntervalMs is the minimum interval
+// between two publications and Aggregation how the frames pushed meanwhile are combined,
+// their zero values keep the defaults of the [live] settings.
+type LiveChannelRule struct {
+	Id                  int64     `json:"id"`
+	OrgId               int64     `json:"orgId"`
+	Pattern             string    `json:"pattern"`
+	SubscribeRole       RoleType  `json:"subscribeRole,omitempty"`
+	SubscribePermission string    `json:"subscribePermission,omitempty"`
+	PublishRole         RoleType  `json:"publishRole,omitempty"`
+	PublishPermission   string    `json:"publishPermission,omitempty"`
+	PublishIntervalMs   int64     `json:"publishIntervalMs,omitempty"`
+	Aggregation         string    `json:"aggregation,omitempty"`
+	Created             time.Time `json:"created"`
+	Updated             time.Time `json:"updated"`
+}
+
+type ListLiveChannelRulesQuery struct {
+	OrgId int64
+}
+
+type CreateLiveChannelRuleCommand struct {
+	OrgId               int64    `json:"-"`
+	Pattern             string   `json:"pattern"`
+	SubscribeRole       RoleType `json:"subscribeRole"`
+	SubscribePermission string   `json:"subscribePermission"`
+	PublishRole         RoleType `json:"publishRole"`
+	PublishPermission   string   `json:"publishPermission"`
+	PublishIntervalMs   int64    `json:"publishIntervalMs"`
+	Aggregation         string   `json:"aggregation"`
+}
+
+type UpdateLiveChannelRuleCommand struct {
+	Id                  int64    `json:"-"`
+	OrgId               int64    `json:"-"`
+	Pattern             string   `json:"pattern"`
+	SubscribeRole       RoleType `json:"subscribeRole"`
+	SubscribePermission string   `json:"subscribePermission"`
+	PublishRole         RoleType `json:"publishRole"`
+	PublishPermission   string   `json:"publishPermission"`
+	PublishIntervalMs   int64    `json:"publishIntervalMs"`
+	Aggregation         string   `json:"aggregation"`
+}
+
+type DeleteLiveChannelRuleCommand struct {
+	Id    int64
+	OrgId int64
+}
@@ -15,6 +15,9 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	ngstore "github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -23,6 +26,7 @@ type CleanUpService struct {
 	Cfg               *setting.Cfg                  `inject:""`
 	ServerLockService *serverlock.ServerLockService `inject:""`
 	ShortURLService   *shorturls.ShortURLService    `inject:""`
+	SQLStore          *sqlstore.SQLStore            `inject:""`
 }
 
 func init() {
@@ -50,6 +54,7 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.cleanUpOldAnnotations(ctxWithTimeout)
 			srv.expireOldUserInvites()
 			srv.deleteStaleShortURLs()
+			srv.deleteOldAlertStateHistory()
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
 				time.Minute*10, func() {
 					srv.deleteOldLoginAttempts()
@@ -179,3 +184,27 @@ func (srv *CleanUpService) deleteStaleShortURLs() {
 		srv.log.Debug("Deleted short urls", "rows affected", cmd.NumDeleted)
 	}
 }
+
+func (srv *CleanUpService) deleteOldAlertStateHistory() {
+	srv.deleteAlertStateHistoryOlderThanMaxAge(ngstore.DBstore{SQLStore: srv.SQLStore}, time.Now())
+}
+
+// alertStateHistoryDeleter is the part of the ngalert store deleting the old alert state history.
+type alertStateHistoryDeleter interface {
+	DeleteAlertStateHistory(cmd *ngmodels.DeleteAlertStateHistoryCommand) error
+}
+
+func (srv *CleanUpService) deleteAlertStateHistoryOlderThanMaxAge(store alertStateHistoryDeleter, now time.Time) {
+	if !srv.Cfg.IsNgAlertEnabled() || !srv.Cfg.StateHistoryEnabled || srv.Cfg.StateHistoryMaxAge <= 0 {
+		return
+	}
+
+	cmd := ngmodels.DeleteAlertStateHistoryCommand{
+		OlderThan: now.Add(-srv.Cfg.StateHistoryMaxAge),
+	}
+	if err := store.DeleteAlertStateHistory(&cmd); err != nil {
+		srv.log.Error("Problem deleting old alert state history", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted old alert state history", "rows affected", cmd.DeletedRows)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCleanUpTmpFiles(t *testing.T) {
@@ -39,3 +42,45 @@ func TestCleanUpTmpFiles(t *testing.T) {
 		})
 	})
 }
+
+type fakeAlertStateHistoryDeleter struct {
+	cmds []*ngmodels.DeleteAlertStateHistoryCommand
+}
+
+func (f *fakeAlertStateHistoryDeleter) DeleteAlertStateHistory(cmd *ngmodels.DeleteAlertStateHistoryCommand) error {
+	f.cmds = append(f.cmds, cmd)
+	return nil
+}
+
+func TestDeleteOldAlertStateHistory(t *testing.T) {
+	now := time.Unix(100000, 0)
+	newService := func(enabled bool, maxAge time.Duration) *CleanUpService {
+		return &CleanUpService{
+			Cfg: &setting.Cfg{
+				FeatureToggles:      map[string]bool{"ngalert": true},
+				StateHistoryEnabled: enabled,
+				StateHistoryMaxAge:  maxAge,
+			},
+			log: log.New("cleanup"),
+		}
+	}
+
+	t.Run("the state history older than its max age is deleted", func(t *testing.T) {
+		store := &fakeAlertStateHistoryDeleter{}
+		newService(true, time.Hour).deleteAlertStateHistoryOlderThanMaxAge(store, now)
+		require.Len(t, store.cmds, 1)
+		require.Equal(t, now.Add(-time.Hour), store.cmds[0].OlderThan)
+	})
+
+	t.Run("the state history is kept without a max age", func(t *testing.T) {
+		store := &fakeAlertStateHistoryDeleter{}
+		newService(true, 0).deleteAlertStateHistoryOlderThanMaxAge(store, now)
+		require.Empty(t, store.cmds)
+	})
+
+	t.Run("nothing is deleted when the state history is disabled", func(t *testing.T) {
+		store := &fakeAlertStateHistoryDeleter{}
+		newService(false, time.Hour).deleteAlertStateHistoryOlderThanMaxAge(store, now)
+		require.Empty(t, store.cmds)
+	})
+}
@@ -0,0 +1,80 @@
+package live
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/channelrule"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// HandleChannelRulesListHTTP returns the channel rules of the organization.
+func (g *GrafanaLive) HandleChannelRulesListHTTP(c *models.ReqContext) response.Response {
+	rules, err := g.storage.ListLiveChannelRules(&models.ListLiveChannelRulesQuery{OrgId: c.OrgId})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get channel rules", err)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{"rules": rules})
+}
+
+// HandleChannelRuleCreateHTTP creates a channel rule in the organization.
+func (g *GrafanaLive) HandleChannelRuleCreateHTTP(c *models.ReqContext, cmd models.CreateLiveChannelRuleCommand) response.Response {
+	if err := channelrule.ValidateRule(cmd.Pattern, cmd.SubscribeRole, cmd.PublishRole); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	if err := channelrule.ValidateThrottling(cmd.PublishIntervalMs, cmd.Aggregation); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	cmd.OrgId = c.OrgId
+	rule, err := g.storage.CreateLiveChannelRule(&cmd)
+	if err != nil {
+		if errors.Is(err, models.ErrLiveChannelRuleExists) {
+			return response.Error(http.StatusConflict, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to create channel rule", err)
+	}
+	g.ChannelRules.Invalidate(c.OrgId)
+	return response.JSON(http.StatusOK, rule)
+}
+
+// HandleChannelRuleUpdateHTTP updates a channel rule of the organization.
+func (g *GrafanaLive) HandleChannelRuleUpdateHTTP(c *models.ReqContext, cmd models.UpdateLiveChannelRuleCommand) response.Response {
+	if err := channelrule.ValidateRule(cmd.Pattern, cmd.SubscribeRole, cmd.PublishRole); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	if err := channelrule.ValidateThrottling(cmd.PublishIntervalMs, cmd.Aggregation); err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	cmd.Id = c.ParamsInt64(":id")
+	cmd.OrgId = c.OrgId
+	rule, err := g.storage.UpdateLiveChannelRule(&cmd)
+	if err != nil {
+		if errors.Is(err, models.ErrLiveChannelRuleNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		if errors.Is(err, models.ErrLiveChannelRuleExists) {
+			return response.Error(http.StatusConflict, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to update channel rule", err)
+	}
+	g.ChannelRules.Invalidate(c.OrgId)
+	return response.JSON(http.StatusOK, rule)
+}
+
+// HandleChannelRuleDeleteHTTP deletes a channel rule of the organization.
+func (g *GrafanaLive) HandleChannelRuleDeleteHTTP(c *models.ReqContext) response.Response {
+	err := g.storage.DeleteLiveChannelRule(&models.DeleteLiveChannelRuleCommand{
+		Id:    c.ParamsInt64(":id"),
+		OrgId: c.OrgId,
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrLiveChannelRuleNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete channel rule", err)
+	}
+	g.ChannelRules.Invalidate(c.OrgId)
+	return response.Success("Channel rule deleted")
+}
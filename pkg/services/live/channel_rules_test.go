@@ -0,0 +1,184 @@
+package live
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/live/channelrule"
+	"github.com/grafana/grafana/pkg/services/live/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// setupChannelRulesTest returns a GrafanaLive with the storage of the channel rules.
+func setupChannelRulesTest(t *testing.T) *GrafanaLive {
+	t.Helper()
+	t.Cleanup(registry.ClearOverrides)
+
+	// the migrations of Live only run when it is enabled
+	cfg := setting.NewCfg()
+	cfg.FeatureToggles = map[string]bool{"live": true}
+	g := NewGrafanaLive()
+	g.Cfg = cfg
+	registry.RegisterOverride(func(descriptor registry.Descriptor) (*registry.Descriptor, bool) {
+		if _, ok := descriptor.Instance.(*GrafanaLive); ok {
+			return &registry.Descriptor{
+				Name:         descriptor.Name,
+				Instance:     g,
+				InitPriority: descriptor.InitPriority,
+			}, true
+		}
+		return nil, false
+	})
+
+	cache := localcache.New(time.Hour, time.Hour)
+	g.storage = database.NewStorage(sqlstore.InitTestDB(t), cache)
+	g.ChannelRules = channelrule.NewChecker(g.storage, cache, nil)
+	return g
+}
+
+func channelRulesReqContext(orgID int64, id int64) *models.ReqContext {
+	ctx := &macaron.Context{Req: macaron.Request{Request: &http.Request{}}}
+	ctx.ReplaceAllParams(map[string]string{":id": strconv.FormatInt(id, 10)})
+	return &models.ReqContext{
+		Context:      ctx,
+		SignedInUser: &models.SignedInUser{OrgId: orgID, OrgRole: models.ROLE_ADMIN},
+	}
+}
+
+func listChannelRules(t *testing.T, g *GrafanaLive, orgID int64) []*models.LiveChannelRule {
+	t.Helper()
+	resp := g.HandleChannelRulesListHTTP(channelRulesReqContext(orgID, 0))
+	require.Equal(t, http.StatusOK, resp.Status())
+	var result struct {
+		Rules []*models.LiveChannelRule `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body(), &result))
+	return result.Rules
+}
+
+func TestChannelRulesHTTP(t *testing.T) {
+	g := setupChannelRulesTest(t)
+
+	createCmd := models.CreateLiveChannelRuleCommand{
+		Pattern:           "stream/telegraf/*",
+		PublishRole:       models.ROLE_EDITOR,
+		PublishIntervalMs: 1000,
+		Aggregation:       "mean",
+	}
+	var created models.LiveChannelRule
+
+	t.Run("create a rule", func(t *testing.T) {
+		resp := g.HandleChannelRuleCreateHTTP(channelRulesReqContext(1, 0), createCmd)
+		require.Equal(t, http.StatusOK, resp.Status())
+		require.NoError(t, json.Unmarshal(resp.Body(), &created))
+		require.NotZero(t, created.Id)
+		require.Equal(t, int64(1), created.OrgId)
+		require.Equal(t, "stream/telegraf/*", created.Pattern)
+		require.Equal(t, models.ROLE_EDITOR, created.PublishRole)
+
+		rules := listChannelRules(t, g, 1)
+		require.Len(t, rules, 1)
+		require.Equal(t, created.Id, rules[0].Id)
+		require.Empty(t, listChannelRules(t, g, 2))
+	})
+
+	t.Run("create an invalid rule", func(t *testing.T) {
+		for _, cmd := range []models.CreateLiveChannelRuleCommand{
+			{Pattern: ""},
+			{Pattern: "stream/[", PublishRole: models.ROLE_EDITOR},
+			{Pattern: "stream/a", PublishRole: "Owner"},
+			{Pattern: "stream/a", PublishIntervalMs: -1},
+			{Pattern: "stream/a", Aggregation: "median"},
+		} {
+			resp := g.HandleChannelRuleCreateHTTP(channelRulesReqContext(1, 0), cmd)
+			require.Equal(t, http.StatusBadRequest, resp.Status(), "pattern %q", cmd.Pattern)
+		}
+	})
+
+	t.Run("create a rule with an existing pattern", func(t *testing.T) {
+		resp := g.HandleChannelRuleCreateHTTP(channelRulesReqContext(1, 0), createCmd)
+		require.Equal(t, http.StatusConflict, resp.Status())
+
+		// the patterns are unique per organization
+		resp = g.HandleChannelRuleCreateHTTP(channelRulesReqContext(2, 0), createCmd)
+		require.Equal(t, http.StatusOK, resp.Status())
+	})
+
+	t.Run("the rules are used by the checker", func(t *testing.T) {
+		rule, err := g.ChannelRules.Rule(1, "stream/telegraf/cpu")
+		require.NoError(t, err)
+		require.NotNil(t, rule)
+		require.Equal(t, created.Id, rule.Id)
+	})
+
+	t.Run("update a rule", func(t *testing.T) {
+		resp := g.HandleChannelRuleUpdateHTTP(channelRulesReqContext(1, created.Id), models.UpdateLiveChannelRuleCommand{
+			Pattern:       "stream/telegraf/cpu",
+			SubscribeRole: models.ROLE_VIEWER,
+		})
+		require.Equal(t, http.StatusOK, resp.Status())
+		var updated models.LiveChannelRule
+		require.NoError(t, json.Unmarshal(resp.Body(), &updated))
+		require.Equal(t, created.Id, updated.Id)
+		require.Equal(t, "stream/telegraf/cpu", updated.Pattern)
+		require.Equal(t, models.ROLE_VIEWER, updated.SubscribeRole)
+		require.Empty(t, updated.PublishRole)
+
+		// the cached rules of the checker are invalidated
+		rule, err := g.ChannelRules.Rule(1, "stream/telegraf/memory")
+		require.NoError(t, err)
+		require.Nil(t, rule)
+	})
+
+	t.Run("update an invalid rule", func(t *testing.T) {
+		resp := g.HandleChannelRuleUpdateHTTP(channelRulesReqContext(1, created.Id), models.UpdateLiveChannelRuleCommand{})
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+	})
+
+	t.Run("update a missing rule", func(t *testing.T) {
+		cmd := models.UpdateLiveChannelRuleCommand{Pattern: "stream/a"}
+		resp := g.HandleChannelRuleUpdateHTTP(channelRulesReqContext(1, created.Id+100), cmd)
+		require.Equal(t, http.StatusNotFound, resp.Status())
+
+		// the rules of the other organizations can not be updated
+		resp = g.HandleChannelRuleUpdateHTTP(channelRulesReqContext(2, created.Id), cmd)
+		require.Equal(t, http.StatusNotFound, resp.Status())
+	})
+
+	t.Run("update a rule with the pattern of another rule", func(t *testing.T) {
+		resp := g.HandleChannelRuleCreateHTTP(channelRulesReqContext(1, 0), models.CreateLiveChannelRuleCommand{Pattern: "stream/other"})
+		require.Equal(t, http.StatusOK, resp.Status())
+
+		resp = g.HandleChannelRuleUpdateHTTP(channelRulesReqContext(1, created.Id), models.UpdateLiveChannelRuleCommand{Pattern: "stream/other"})
+		require.Equal(t, http.StatusConflict, resp.Status())
+	})
+
+	t.Run("delete a rule", func(t *testing.T) {
+		// the rules of the other organizations can not be deleted
+		resp := g.HandleChannelRuleDeleteHTTP(channelRulesReqContext(2, created.Id))
+		require.Equal(t, http.StatusNotFound, resp.Status())
+
+		resp = g.HandleChannelRuleDeleteHTTP(channelRulesReqContext(1, created.Id))
+		require.Equal(t, http.StatusOK, resp.Status())
+		rules := listChannelRules(t, g, 1)
+		require.Len(t, rules, 1)
+		require.Equal(t, "stream/other", rules[0].Pattern)
+
+		rule, err := g.ChannelRules.Rule(1, "stream/telegraf/cpu")
+		require.NoError(t, err)
+		require.Nil(t, rule)
+
+		resp = g.HandleChannelRuleDeleteHTTP(channelRulesReqContext(1, created.Id))
+		require.Equal(t, http.StatusNotFound, resp.Status())
+	})
+}
@@ -0,0 +1,140 @@
+// Package channelrule evaluates the rules restricting the access to the Grafana Live channels.
+package channelrule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+// rulesCacheTTL is how long the rules of an organization are cached. The rules changed
+// through another Grafana server are applied after it.
+const rulesCacheTTL = 10 * time.Second
+
+// Storage stores the channel rules.
+type Storage interface {
+	ListLiveChannelRules(query *models.ListLiveChannelRulesQuery) ([]*models.LiveChannelRule, error)
+}
+
+// Checker tells whether users can subscribe and publish to channels according to the channel rules
+// of their organization. The channels without a matching rule are open to all the users of the organization.
+type Checker struct {
+	storage       Storage
+	cache         *localcache.CacheService
+	accessControl accesscontrol.AccessControl
+}
+
+// NewChecker creates new Checker. accessControl is optional, the permissions of the rules
+// never allow an action without it.
+func NewChecker(storage Storage, cache *localcache.CacheService, accessControl accesscontrol.AccessControl) *Checker {
+	return &Checker{
+		storage:       storage,
+		cache:         cache,
+		accessControl: accessControl,
+	}
+}
+
+// CanSubscribe tells whether the user can subscribe to the channel, without the organization prefix.
+func (c *Checker) CanSubscribe(ctx context.Context, user *models.SignedInUser, channel string) (bool, error) {
+	rule, err := c.Rule(user.OrgId, channel)
+	if err != nil || rule == nil {
+		return err == nil, err
+	}
+	return c.allowed(ctx, user, rule.SubscribeRole, rule.SubscribePermission)
+}
+
+// CanPublish tells whether the user can publish to the channel, without the organization prefix.
+func (c *Checker) CanPublish(ctx context.Context, user *models.SignedInUser, channel string) (bool, error) {
+	rule, err := c.Rule(user.OrgId, channel)
+	if err != nil || rule == nil {
+		return err == nil, err
+	}
+	return c.allowed(ctx, user, rule.PublishRole, rule.PublishPermission)
+}
+
+// Invalidate removes the cached rules of the organization, after they are changed.
+func (c *Checker) Invalidate(orgID int64) {
+	c.cache.Delete(cacheKey(orgID))
+}
+
+func (c *Checker) allowed(ctx context.Context, user *models.SignedInUser, role models.RoleType, permission string) (bool, error) {
+	if role == "" && permission == "" {
+		return true, nil
+	}
+	if role != "" && user.OrgRole.Includes(role) {
+		return true, nil
+	}
+	if permission == "" || c.accessControl == nil || c.accessControl.IsDisabled() {
+		return false, nil
+	}
+	return c.accessControl.Evaluate(ctx, user, permission)
+}
+
+// Rule returns the rule of the organization applying to the channel, without the organization prefix, if any.
+func (c *Checker) Rule(orgID int64, channel string) (*models.LiveChannelRule, error) {
+	key := cacheKey(orgID)
+	if cached, ok := c.cache.Get(key); ok {
+		if rules, ok := cached.([]*models.LiveChannelRule); ok {
+			return MatchingRule(rules, channel), nil
+		}
+	}
+	rules, err := c.storage.ListLiveChannelRules(&models.ListLiveChannelRulesQuery{OrgId: orgID})
+	if err != nil {
+		return nil, fmt.Errorf("error getting live channel rules: %w", err)
+	}
+	c.cache.Set(key, rules, rulesCacheTTL)
+	return MatchingRule(rules, channel), nil
+}
+
+func cacheKey(orgID int64) string {
+	return fmt.Sprintf("live_channel_rules_%d", orgID)
+}
+
+// MatchingRule returns the rule with the longest pattern matching the channel, if any.
+// Patterns use the syntax of path.Match, so a * matches any part of a channel between two slashes.
+func MatchingRule(rules []*models.LiveChannelRule, channel string) *models.LiveChannelRule {
+	var match *models.LiveChannelRule
+	for _, r := range rules {
+		if ok, _ := path.Match(r.Pattern, channel); !ok {
+			continue
+		}
+		if match == nil || len(r.Pattern) > len(match.Pattern) {
+			match = r
+		}
+	}
+	return match
+}
+
+// ValidateRule returns an error if the pattern or the roles of a rule are not valid.
+func ValidateRule(pattern string, subscribeRole, publishRole models.RoleType) error {
+	if pattern == "" {
+		return errors.New("the pattern is required")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	for _, role := range []models.RoleType{subscribeRole, publishRole} {
+		if role != "" && !role.IsValid() {
+			return fmt.Errorf("invalid role %q", role)
+		}
+	}
+	return nil
+}
+
+// ValidateThrottling returns an error if the publish interval or the aggregation of a rule are not valid.
+func ValidateThrottling(publishIntervalMs int64, aggregation string) error {
+	if publishIntervalMs < 0 {
+		return fmt.Errorf("invalid publish interval %d", publishIntervalMs)
+	}
+	if aggregation == "" {
+		return nil
+	}
+	return managedstream.ValidateAggregation(aggregation)
+}
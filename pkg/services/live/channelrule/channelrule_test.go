@@ -0,0 +1,134 @@
+package channelrule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStorage struct {
+	rules   []*models.LiveChannelRule
+	queries int
+}
+
+func (s *fakeStorage) ListLiveChannelRules(query *models.ListLiveChannelRulesQuery) ([]*models.LiveChannelRule, error) {
+	s.queries++
+	var rules []*models.LiveChannelRule
+	for _, r := range s.rules {
+		if r.OrgId == query.OrgId {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+type fakeAccessControl struct {
+	disabled    bool
+	permissions map[string]bool
+}
+
+func (ac *fakeAccessControl) Evaluate(_ context.Context, _ *models.SignedInUser, permission string, _ ...string) (bool, error) {
+	return ac.permissions[permission], nil
+}
+
+func (ac *fakeAccessControl) GetUserPermissions(_ context.Context, _ *models.SignedInUser) ([]*accesscontrol.Permission, error) {
+	return nil, nil
+}
+
+func (ac *fakeAccessControl) IsDisabled() bool {
+	return ac.disabled
+}
+
+func TestMatchingRule(t *testing.T) {
+	rules := []*models.LiveChannelRule{
+		{Id: 1, Pattern: "stream/*/*"},
+		{Id: 2, Pattern: "stream/devices/*"},
+		{Id: 3, Pattern: "grafana/broadcast/*"},
+	}
+
+	require.Equal(t, int64(2), MatchingRule(rules, "stream/devices/temperature").Id)
+	require.Equal(t, int64(1), MatchingRule(rules, "stream/other/temperature").Id)
+	require.Equal(t, int64(3), MatchingRule(rules, "grafana/broadcast/a").Id)
+	require.Nil(t, MatchingRule(rules, "stream/devices/temperature/extra"))
+	require.Nil(t, MatchingRule(rules, "plugin/testdata/random-2s-stream"))
+}
+
+func TestValidateRule(t *testing.T) {
+	require.NoError(t, ValidateRule("stream/devices/*", models.ROLE_VIEWER, models.ROLE_EDITOR))
+	require.NoError(t, ValidateRule("stream/devices/*", "", ""))
+	require.Error(t, ValidateRule("", "", ""))
+	require.Error(t, ValidateRule("stream/[devices", "", ""))
+	require.Error(t, ValidateRule("stream/devices/*", "Owner", ""))
+}
+
+func TestValidateThrottling(t *testing.T) {
+	require.NoError(t, ValidateThrottling(0, ""))
+	require.NoError(t, ValidateThrottling(1000, "mean"))
+	require.Error(t, ValidateThrottling(-1, ""))
+	require.Error(t, ValidateThrottling(1000, "max"))
+}
+
+func TestChecker(t *testing.T) {
+	storage := &fakeStorage{rules: []*models.LiveChannelRule{
+		{Id: 1, OrgId: 1, Pattern: "stream/devices/*", SubscribeRole: models.ROLE_VIEWER, PublishRole: models.ROLE_ADMIN, PublishPermission: "live.devices:publish"},
+		{Id: 2, OrgId: 1, Pattern: "stream/admin/*", SubscribeRole: models.ROLE_ADMIN},
+		{Id: 3, OrgId: 1, Pattern: "stream/permission/*", SubscribePermission: "live.permission:read"},
+	}}
+	ac := &fakeAccessControl{permissions: map[string]bool{}}
+	checker := NewChecker(storage, localcache.New(time.Minute, time.Minute), ac)
+
+	viewer := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	editor := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR}
+	admin := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN}
+	otherOrgViewer := &models.SignedInUser{OrgId: 2, OrgRole: models.ROLE_VIEWER}
+
+	check := func(can func(context.Context, *models.SignedInUser, string) (bool, error), user *models.SignedInUser, channel string) bool {
+		allowed, err := can(context.Background(), user, channel)
+		require.NoError(t, err)
+		return allowed
+	}
+
+	t.Run("channels without a rule are open", func(t *testing.T) {
+		require.True(t, check(checker.CanSubscribe, viewer, "stream/other/a"))
+		require.True(t, check(checker.CanPublish, viewer, "stream/other/a"))
+		require.True(t, check(checker.CanPublish, otherOrgViewer, "stream/devices/a"))
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		require.True(t, check(checker.CanSubscribe, viewer, "stream/devices/a"))
+		require.False(t, check(checker.CanPublish, viewer, "stream/devices/a"))
+		require.False(t, check(checker.CanPublish, editor, "stream/devices/a"))
+		require.True(t, check(checker.CanPublish, admin, "stream/devices/a"))
+		require.False(t, check(checker.CanSubscribe, editor, "stream/admin/a"))
+		require.True(t, check(checker.CanPublish, editor, "stream/admin/a"))
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		require.False(t, check(checker.CanSubscribe, admin, "stream/permission/a"))
+		ac.permissions["live.permission:read"] = true
+		ac.permissions["live.devices:publish"] = true
+		require.True(t, check(checker.CanSubscribe, viewer, "stream/permission/a"))
+		require.True(t, check(checker.CanPublish, editor, "stream/devices/a"))
+
+		ac.disabled = true
+		require.False(t, check(checker.CanSubscribe, viewer, "stream/permission/a"))
+		require.False(t, check(checker.CanPublish, editor, "stream/devices/a"))
+	})
+
+	t.Run("the rules are cached until invalidated", func(t *testing.T) {
+		queries := storage.queries
+		require.True(t, check(checker.CanSubscribe, viewer, "stream/devices/a"))
+		require.Equal(t, queries, storage.queries)
+
+		storage.rules = append(storage.rules, &models.LiveChannelRule{Id: 4, OrgId: 1, Pattern: "stream/devices/secret", SubscribeRole: models.ROLE_ADMIN})
+		require.True(t, check(checker.CanSubscribe, viewer, "stream/devices/secret"))
+		checker.Invalidate(1)
+		require.False(t, check(checker.CanSubscribe, viewer, "stream/devices/secret"))
+		require.Equal(t, queries+1, storage.queries)
+	})
+}
@@ -3,14 +3,39 @@ package convert
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana-live-sdk/telemetry"
 	"github.com/grafana/grafana-live-sdk/telemetry/telegraf"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
+// Input formats of the pushed data.
+const (
+	// InputFormatInflux is the Influx line protocol.
+	InputFormatInflux = "influx"
+	// InputFormatJSON is JSON documents converted with a JSONMapping.
+	InputFormatJSON = "json"
+	// InputFormatPrometheus is the Prometheus text exposition format.
+	InputFormatPrometheus = "prometheus"
+	// InputFormatFrame is a data frame in JSON.
+	InputFormatFrame = "frame"
+)
+
+// Options tell how pushed data is converted to frames.
+type Options struct {
+	// InputFormat is the format of the data, InputFormatInflux when empty.
+	InputFormat string
+	// FrameFormat is the layout of the frames of the Influx line protocol, wide or labels_column.
+	FrameFormat string
+	// JSONMapping is the mapping of the JSON input format.
+	JSONMapping JSONMapping
+}
+
 type Converter struct {
 	telegrafConverterWide         *telegraf.Converter
 	telegrafConverterLabelsColumn *telegraf.Converter
+	now                           func() time.Time
 }
 
 func NewConverter() *Converter {
@@ -22,20 +47,35 @@ func NewConverter() *Converter {
 			telegraf.WithUseLabelsColumn(true),
 			telegraf.WithFloat64Numbers(true),
 		),
+		now: time.Now,
 	}
 }
 
-var ErrUnsupportedFrameFormat = errors.New("unsupported frame format")
+var (
+	ErrUnsupportedFrameFormat = errors.New("unsupported frame format")
+	ErrUnsupportedInputFormat = errors.New("unsupported input format")
+)
 
-func (c *Converter) Convert(data []byte, frameFormat string) ([]telemetry.FrameWrapper, error) {
+func (c *Converter) Convert(data []byte, opts Options) ([]telemetry.FrameWrapper, error) {
 	var converter telemetry.Converter
-	switch frameFormat {
-	case "wide":
-		converter = c.telegrafConverterWide
-	case "labels_column":
-		converter = c.telegrafConverterLabelsColumn
+	switch opts.InputFormat {
+	case InputFormatInflux, "":
+		switch opts.FrameFormat {
+		case "wide":
+			converter = c.telegrafConverterWide
+		case "labels_column":
+			converter = c.telegrafConverterLabelsColumn
+		default:
+			return nil, ErrUnsupportedFrameFormat
+		}
+	case InputFormatJSON:
+		converter = &jsonConverter{mapping: opts.JSONMapping, now: c.now}
+	case InputFormatPrometheus:
+		converter = &prometheusConverter{now: c.now}
+	case InputFormatFrame:
+		converter = &frameConverter{}
 	default:
-		return nil, ErrUnsupportedFrameFormat
+		return nil, ErrUnsupportedInputFormat
 	}
 
 	metricFrames, err := converter.Convert(data)
@@ -44,3 +84,17 @@ func (c *Converter) Convert(data []byte, frameFormat string) ([]telemetry.FrameW
 	}
 	return metricFrames, nil
 }
+
+// frameWrapper is a frame published to the channel of its key in a stream.
+type frameWrapper struct {
+	key   string
+	frame *data.Frame
+}
+
+func (w *frameWrapper) Key() string {
+	return w.key
+}
+
+func (w *frameWrapper) Frame() *data.Frame {
+	return w.frame
+}
@@ -0,0 +1,175 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConverter(now time.Time) *Converter {
+	c := NewConverter()
+	c.now = func() time.Time { return now }
+	return c
+}
+
+func TestConverter_UnsupportedFormats(t *testing.T) {
+	c := NewConverter()
+	_, err := c.Convert([]byte(`cpu value=1`), Options{InputFormat: "xml"})
+	require.ErrorIs(t, err, ErrUnsupportedInputFormat)
+	_, err = c.Convert([]byte(`cpu value=1`), Options{FrameFormat: "long"})
+	require.ErrorIs(t, err, ErrUnsupportedFrameFormat)
+}
+
+func TestConverter_JSON(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := newTestConverter(now)
+
+	t.Run("all the top level fields without a mapping", func(t *testing.T) {
+		frames, err := c.Convert([]byte(`{"temperature": 21.5, "status": "ok", "on": true, "nested": {"a": 1}, "empty": null}`), Options{InputFormat: InputFormatJSON})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "json", frames[0].Key())
+		require.Equal(t, data.NewFrame("json",
+			data.NewField("time", nil, []time.Time{now}),
+			data.NewField("on", nil, []*bool{boolPtr(true)}),
+			data.NewField("status", nil, []*string{stringPtr("ok")}),
+			data.NewField("temperature", nil, []*float64{float64Ptr(21.5)}),
+		), frames[0].Frame())
+	})
+
+	t.Run("documents with a mapping", func(t *testing.T) {
+		body := `[
+			{"ts": 1000, "device": {"id": "a"}, "values": {"temperature": 20}},
+			{"ts": "1970-01-01T00:00:02Z", "device": {"id": "b"}, "values": {"temperature": 30, "humidity": 50}},
+			{"ts": 3000, "device": {"id": "a"}, "values": {"temperature": 21, "humidity": 40}}
+		]`
+		frames, err := c.Convert([]byte(body), Options{InputFormat: InputFormatJSON, JSONMapping: JSONMapping{
+			Name:        "sensors",
+			TimeField:   "ts",
+			ValueFields: []string{"values.temperature", "values.humidity"},
+			LabelFields: []string{"device.id"},
+		}})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "sensors", frames[0].Key())
+		require.Equal(t, data.NewFrame("sensors",
+			data.NewField("time", nil, []time.Time{time.Unix(1, 0).UTC(), time.Unix(2, 0).UTC(), time.Unix(3, 0).UTC()}),
+			data.NewField("values.temperature", data.Labels{"device.id": "a"}, []*float64{float64Ptr(20), nil, float64Ptr(21)}),
+			data.NewField("values.temperature", data.Labels{"device.id": "b"}, []*float64{nil, float64Ptr(30), nil}),
+			data.NewField("values.humidity", data.Labels{"device.id": "b"}, []*float64{nil, float64Ptr(50), nil}),
+			data.NewField("values.humidity", data.Labels{"device.id": "a"}, []*float64{nil, nil, float64Ptr(40)}),
+		), frames[0].Frame())
+	})
+
+	t.Run("values of different types", func(t *testing.T) {
+		_, err := c.Convert([]byte(`[{"value": 1}, {"value": "1"}]`), Options{InputFormat: InputFormatJSON})
+		require.Error(t, err)
+	})
+
+	t.Run("missing time", func(t *testing.T) {
+		_, err := c.Convert([]byte(`{"value": 1}`), Options{InputFormat: InputFormatJSON, JSONMapping: JSONMapping{TimeField: "ts"}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := c.Convert([]byte(`{"value": `), Options{InputFormat: InputFormatJSON})
+		require.Error(t, err)
+	})
+}
+
+func TestConverter_Prometheus(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := newTestConverter(now)
+
+	body := `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"} 3 1395066363000
+# TYPE temperature gauge
+temperature 21.5
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 4773
+rpc_duration_seconds_sum 17560473
+rpc_duration_seconds_count 2693
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.5"} 129389
+request_duration_seconds_bucket{le="+Inf"} 144320
+request_duration_seconds_sum 53423
+request_duration_seconds_count 144320
+`
+	frames, err := c.Convert([]byte(body), Options{InputFormat: InputFormatPrometheus})
+	require.NoError(t, err)
+	require.Len(t, frames, 4)
+
+	require.Equal(t, "http_requests_total", frames[0].Key())
+	require.Equal(t, data.NewFrame("http_requests_total",
+		data.NewField("time", nil, []time.Time{time.Unix(1395066363, 0)}),
+		data.NewField("http_requests_total", data.Labels{"method": "post", "code": "200"}, []float64{1027}),
+		data.NewField("http_requests_total", data.Labels{"method": "post", "code": "400"}, []float64{3}),
+	), frames[0].Frame())
+
+	require.Equal(t, "request_duration_seconds", frames[1].Key())
+	require.Equal(t, data.NewFrame("request_duration_seconds",
+		data.NewField("time", nil, []time.Time{now}),
+		data.NewField("request_duration_seconds_bucket", data.Labels{"le": "0.5"}, []float64{129389}),
+		data.NewField("request_duration_seconds_bucket", data.Labels{"le": "+Inf"}, []float64{144320}),
+		data.NewField("request_duration_seconds_sum", data.Labels{}, []float64{53423}),
+		data.NewField("request_duration_seconds_count", data.Labels{}, []float64{144320}),
+	), frames[1].Frame())
+
+	require.Equal(t, "rpc_duration_seconds", frames[2].Key())
+	require.Equal(t, data.NewFrame("rpc_duration_seconds",
+		data.NewField("time", nil, []time.Time{now}),
+		data.NewField("rpc_duration_seconds", data.Labels{"quantile": "0.5"}, []float64{4773}),
+		data.NewField("rpc_duration_seconds_sum", data.Labels{}, []float64{17560473}),
+		data.NewField("rpc_duration_seconds_count", data.Labels{}, []float64{2693}),
+	), frames[2].Frame())
+
+	require.Equal(t, "temperature", frames[3].Key())
+	require.Equal(t, data.NewFrame("temperature",
+		data.NewField("time", nil, []time.Time{now}),
+		data.NewField("temperature", data.Labels{}, []float64{21.5}),
+	), frames[3].Frame())
+
+	_, err = c.Convert([]byte("temperature{ 21.5"), Options{InputFormat: InputFormatPrometheus})
+	require.Error(t, err)
+}
+
+func TestConverter_Frame(t *testing.T) {
+	c := NewConverter()
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0).UTC()}),
+		data.NewField("value", data.Labels{"host": "a"}, []float64{0.5}),
+	)
+	body, err := json.Marshal(frame)
+	require.NoError(t, err)
+
+	frames, err := c.Convert(body, Options{InputFormat: InputFormatFrame})
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Equal(t, "cpu", frames[0].Key())
+	require.Equal(t, "cpu", frames[0].Frame().Name)
+	require.Equal(t, 2, len(frames[0].Frame().Fields))
+	require.Equal(t, 0.5, frames[0].Frame().Fields[1].At(0))
+
+	frame.Name = ""
+	body, err = json.Marshal(frame)
+	require.NoError(t, err)
+	_, err = c.Convert(body, Options{InputFormat: InputFormatFrame})
+	require.Error(t, err)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
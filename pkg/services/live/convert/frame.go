@@ -0,0 +1,23 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/grafana/grafana-live-sdk/telemetry"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// frameConverter decodes a data frame in JSON, published to the channel of its name.
+type frameConverter struct{}
+
+func (c *frameConverter) Convert(body []byte) ([]telemetry.FrameWrapper, error) {
+	var frame data.Frame
+	if err := json.Unmarshal(body, &frame); err != nil {
+		return nil, err
+	}
+	if frame.Name == "" {
+		return nil, errors.New("the frame has no name, it is the path of its channel")
+	}
+	return []telemetry.FrameWrapper{&frameWrapper{key: frame.Name, frame: &frame}}, nil
+}
@@ -0,0 +1,216 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-live-sdk/telemetry"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// defaultJSONFrameName is the name of the frames of JSON documents when the mapping has none.
+const defaultJSONFrameName = "json"
+
+// JSONMapping tells how JSON documents are converted to a frame. The fields of nested
+// objects are referred to by their path with dots, e.g. "sensor.temperature".
+type JSONMapping struct {
+	// Name is the name of the frame, which is also the path of its channel. Defaults to "json".
+	Name string
+	// TimeField is the field with the time of a document, either a number of milliseconds
+	// since the Unix epoch or an RFC 3339 string. When empty, the time of the push is used.
+	TimeField string
+	// ValueFields are the fields converted to fields of the frame. When empty, all the numbers,
+	// strings and booleans at the top level of the documents except the time and the labels are.
+	ValueFields []string
+	// LabelFields are the fields whose values are the labels of the fields of the frame.
+	LabelFields []string
+}
+
+// jsonConverter converts a JSON object, or an array of JSON objects, to a frame with a row per object.
+// The values of the documents with different labels are in different fields of the frame.
+type jsonConverter struct {
+	mapping JSONMapping
+	now     func() time.Time
+}
+
+// jsonColumn is a field of the frame of JSON documents.
+type jsonColumn struct {
+	name   string
+	labels data.Labels
+	field  *data.Field
+}
+
+func (c *jsonConverter) Convert(body []byte) ([]telemetry.FrameWrapper, error) {
+	var docs []map[string]interface{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &docs); err != nil {
+			return nil, err
+		}
+	} else {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	name := c.mapping.Name
+	if name == "" {
+		name = defaultJSONFrameName
+	}
+
+	now := c.now()
+	times := make([]time.Time, len(docs))
+	var columns []*jsonColumn
+	columnsByKey := map[string]*jsonColumn{}
+
+	for i, doc := range docs {
+		t, err := c.documentTime(doc, now)
+		if err != nil {
+			return nil, err
+		}
+		times[i] = t
+
+		labels, err := c.documentLabels(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fieldName := range c.valueFields(doc) {
+			v, ok := doc[fieldName]
+			if len(c.mapping.ValueFields) > 0 {
+				v, ok = lookupJSONField(doc, fieldName)
+			}
+			if !ok || v == nil {
+				continue
+			}
+
+			var fieldType data.FieldType
+			var value interface{}
+			switch v := v.(type) {
+			case float64:
+				fieldType, value = data.FieldTypeNullableFloat64, &v
+			case string:
+				fieldType, value = data.FieldTypeNullableString, &v
+			case bool:
+				fieldType, value = data.FieldTypeNullableBool, &v
+			default:
+				if len(c.mapping.ValueFields) == 0 {
+					// only the supported fields are converted when they are not listed
+					continue
+				}
+				return nil, fmt.Errorf("unsupported value of the field %s, it must be a number, a string or a boolean", fieldName)
+			}
+
+			key := fieldName + labels.String()
+			col, ok := columnsByKey[key]
+			if !ok {
+				col = &jsonColumn{
+					name:   fieldName,
+					labels: labels,
+					field:  data.NewFieldFromFieldType(fieldType, len(docs)),
+				}
+				columnsByKey[key] = col
+				columns = append(columns, col)
+			}
+			if col.field.Type() != fieldType {
+				return nil, fmt.Errorf("the field %s has values of different types", fieldName)
+			}
+			col.field.Set(i, value)
+		}
+	}
+
+	fields := make([]*data.Field, 0, len(columns)+1)
+	fields = append(fields, data.NewField("time", nil, times))
+	for _, col := range columns {
+		col.field.Name = col.name
+		col.field.Labels = col.labels
+		fields = append(fields, col.field)
+	}
+	return []telemetry.FrameWrapper{&frameWrapper{key: name, frame: data.NewFrame(name, fields...)}}, nil
+}
+
+func (c *jsonConverter) documentTime(doc map[string]interface{}, now time.Time) (time.Time, error) {
+	if c.mapping.TimeField == "" {
+		return now, nil
+	}
+	v, ok := lookupJSONField(doc, c.mapping.TimeField)
+	if !ok {
+		return time.Time{}, fmt.Errorf("missing time field %s", c.mapping.TimeField)
+	}
+	switch v := v.(type) {
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Millisecond))).UTC(), nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time field %s: %w", c.mapping.TimeField, err)
+		}
+		return t.UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid time field %s, it must be a number or a string", c.mapping.TimeField)
+	}
+}
+
+func (c *jsonConverter) documentLabels(doc map[string]interface{}) (data.Labels, error) {
+	if len(c.mapping.LabelFields) == 0 {
+		return nil, nil
+	}
+	labels := data.Labels{}
+	for _, fieldName := range c.mapping.LabelFields {
+		v, ok := lookupJSONField(doc, fieldName)
+		if !ok || v == nil {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			labels[fieldName] = v
+		case float64:
+			labels[fieldName] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			labels[fieldName] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("unsupported value of the label field %s, it must be a number, a string or a boolean", fieldName)
+		}
+	}
+	return labels, nil
+}
+
+// valueFields returns the names of the fields of the document converted to fields of the frame.
+func (c *jsonConverter) valueFields(doc map[string]interface{}) []string {
+	if len(c.mapping.ValueFields) > 0 {
+		return c.mapping.ValueFields
+	}
+	excluded := map[string]struct{}{c.mapping.TimeField: {}}
+	for _, l := range c.mapping.LabelFields {
+		excluded[l] = struct{}{}
+	}
+	names := make([]string, 0, len(doc))
+	for k := range doc {
+		if _, ok := excluded[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupJSONField returns the value of the field at the path in the document.
+func lookupJSONField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = doc
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[name]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,102 @@
+package convert
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-live-sdk/telemetry"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// prometheusConverter converts metrics in the Prometheus text exposition format to a frame per metric family,
+// published to the channel of the family name. A frame has a single row, with a field per series.
+// The series of summaries and histograms are named like in Prometheus, with the _sum, _count
+// and _bucket suffixes and the quantile and le labels.
+type prometheusConverter struct {
+	now func() time.Time
+}
+
+func (c *prometheusConverter) Convert(body []byte) ([]telemetry.FrameWrapper, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := c.now()
+	frameWrappers := make([]telemetry.FrameWrapper, 0, len(families))
+	for _, name := range names {
+		family := families[name]
+		t := time.Time{}
+		fields := []*data.Field{}
+		addField := func(name string, labels data.Labels, value float64) {
+			fields = append(fields, data.NewField(name, labels, []float64{value}))
+		}
+
+		for _, m := range family.GetMetric() {
+			if m.TimestampMs != nil {
+				if mt := time.Unix(0, m.GetTimestampMs()*int64(time.Millisecond)); mt.After(t) {
+					t = mt
+				}
+			}
+			labels := data.Labels{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				addField(name, labels, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				addField(name, labels, m.GetGauge().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				for _, q := range summary.GetQuantile() {
+					addField(name, withLabel(labels, "quantile", formatPrometheusFloat(q.GetQuantile())), q.GetValue())
+				}
+				addField(name+"_sum", labels, summary.GetSampleSum())
+				addField(name+"_count", labels, float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				histogram := m.GetHistogram()
+				for _, b := range histogram.GetBucket() {
+					addField(name+"_bucket", withLabel(labels, "le", formatPrometheusFloat(b.GetUpperBound())), float64(b.GetCumulativeCount()))
+				}
+				addField(name+"_sum", labels, histogram.GetSampleSum())
+				addField(name+"_count", labels, float64(histogram.GetSampleCount()))
+			default:
+				addField(name, labels, m.GetUntyped().GetValue())
+			}
+		}
+
+		if t.IsZero() {
+			t = now
+		}
+		fields = append([]*data.Field{data.NewField("time", nil, []time.Time{t})}, fields...)
+		frameWrappers = append(frameWrappers, &frameWrapper{key: name, frame: data.NewFrame(name, fields...)})
+	}
+	return frameWrappers, nil
+}
+
+func withLabel(labels data.Labels, name, value string) data.Labels {
+	l := labels.Copy()
+	l[name] = value
+	return l
+}
+
+func formatPrometheusFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -21,4 +21,50 @@ func AddLiveChannelMigrations(mg *migrator.Migrator) {
 	//
 	//mg.AddMigration("create live message table", migrator.NewAddTableMigration(liveMessage))
 	//mg.AddMigration("add index live_message.org_id_channel_unique", migrator.NewAddIndexMigration(liveMessage, liveMessage.Indices[0]))
+
+	liveChannelFrame := migrator.Table{
+		Name: "live_channel_frame",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "channel", Type: migrator.DB_NVarchar, Length: 189, Nullable: false},
+			{Name: "data", Type: migrator.DB_MediumText, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "channel", "created"}},
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create live channel frame table", migrator.NewAddTableMigration(liveChannelFrame))
+	mg.AddMigration("add index live_channel_frame.org_id_channel_created", migrator.NewAddIndexMigration(liveChannelFrame, liveChannelFrame.Indices[0]))
+	mg.AddMigration("add index live_channel_frame.created", migrator.NewAddIndexMigration(liveChannelFrame, liveChannelFrame.Indices[1]))
+
+	liveChannelRule := migrator.Table{
+		Name: "live_channel_rule",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "pattern", Type: migrator.DB_NVarchar, Length: 189, Nullable: false},
+			{Name: "subscribe_role", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "subscribe_permission", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "publish_role", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "publish_permission", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "pattern"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create live channel rule table", migrator.NewAddTableMigration(liveChannelRule))
+	mg.AddMigration("add unique index live_channel_rule.org_id_pattern", migrator.NewAddIndexMigration(liveChannelRule, liveChannelRule.Indices[0]))
+	mg.AddMigration("add column publish_interval_ms to live_channel_rule", migrator.NewAddColumnMigration(liveChannelRule, &migrator.Column{
+		Name: "publish_interval_ms", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column aggregation to live_channel_rule", migrator.NewAddColumnMigration(liveChannelRule, &migrator.Column{
+		Name: "aggregation", Type: migrator.DB_NVarchar, Length: 20, Nullable: false, Default: "''",
+	}))
 }
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -64,3 +66,178 @@ func (s *Storage) GetLiveMessage(query *models.GetLiveMessageQuery) (models.Live
 	}
 	return msg, true, nil
 }
+
+// liveChannelFrame is a row of the live_channel_frame table.
+type liveChannelFrame struct {
+	Id      int64
+	OrgId   int64
+	Channel string
+	Data    string
+	Created time.Time
+}
+
+func (s *Storage) SaveLiveChannelFrames(cmd *models.SaveLiveChannelFramesCommand) error {
+	if len(cmd.Frames) == 0 {
+		return nil
+	}
+	rows := make([]*liveChannelFrame, 0, len(cmd.Frames))
+	for _, f := range cmd.Frames {
+		rows = append(rows, &liveChannelFrame{
+			OrgId:   f.OrgId,
+			Channel: f.Channel,
+			Data:    string(f.Data),
+			Created: f.Created,
+		})
+	}
+	return s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		_, err := sess.Table("live_channel_frame").Insert(&rows)
+		return err
+	})
+}
+
+func (s *Storage) GetLiveChannelFrames(query *models.GetLiveChannelFramesQuery) ([]*models.LiveChannelFrame, error) {
+	var rows []*liveChannelFrame
+	err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		q := sess.Table("live_channel_frame").
+			Where("org_id=? AND channel=? AND created>=?", query.OrgId, query.Channel, query.Since).
+			Desc("id")
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		return q.Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The latest frames are selected, they are returned oldest first.
+	frames := make([]*models.LiveChannelFrame, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		frames = append(frames, &models.LiveChannelFrame{
+			OrgId:   rows[i].OrgId,
+			Channel: rows[i].Channel,
+			Data:    json.RawMessage(rows[i].Data),
+			Created: rows[i].Created,
+		})
+	}
+	return frames, nil
+}
+
+func (s *Storage) DeleteLiveChannelFrames(cmd *models.DeleteLiveChannelFramesCommand) (int64, error) {
+	var affected int64
+	err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		var err error
+		affected, err = sess.Table("live_channel_frame").Where("created<?", cmd.Before).Delete(&liveChannelFrame{})
+		return err
+	})
+	return affected, err
+}
+
+func (s *Storage) TrimLiveChannelFrames(cmd *models.TrimLiveChannelFramesCommand) (int64, error) {
+	var affected int64
+	err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		// the latest frame which is not kept, the frames up to it are deleted
+		var rows []*liveChannelFrame
+		err := sess.Table("live_channel_frame").
+			Where("org_id=? AND channel=?", cmd.OrgId, cmd.Channel).
+			Cols("id").
+			Desc("id").
+			Limit(1, cmd.Keep).
+			Find(&rows)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		affected, err = sess.Table("live_channel_frame").
+			Where("org_id=? AND channel=? AND id<=?", cmd.OrgId, cmd.Channel, rows[0].Id).
+			Delete(&liveChannelFrame{})
+		return err
+	})
+	return affected, err
+}
+
+func (s *Storage) ListLiveChannelRules(query *models.ListLiveChannelRulesQuery) ([]*models.LiveChannelRule, error) {
+	rules := make([]*models.LiveChannelRule, 0)
+	err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id=?", query.OrgId).Asc("pattern").Find(&rules)
+	})
+	return rules, err
+}
+
+func (s *Storage) CreateLiveChannelRule(cmd *models.CreateLiveChannelRuleCommand) (*models.LiveChannelRule, error) {
+	now := time.Now()
+	rule := &models.LiveChannelRule{
+		OrgId:               cmd.OrgId,
+		Pattern:             cmd.Pattern,
+		SubscribeRole:       cmd.SubscribeRole,
+		SubscribePermission: cmd.SubscribePermission,
+		PublishRole:         cmd.PublishRole,
+		PublishPermission:   cmd.PublishPermission,
+		PublishIntervalMs:   cmd.PublishIntervalMs,
+		Aggregation:         cmd.Aggregation,
+		Created:             now,
+		Updated:             now,
+	}
+	err := s.store.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id=? AND pattern=?", cmd.OrgId, cmd.Pattern).Exist(&models.LiveChannelRule{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrLiveChannelRuleExists
+		}
+		_, err = sess.Insert(rule)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *Storage) UpdateLiveChannelRule(cmd *models.UpdateLiveChannelRuleCommand) (*models.LiveChannelRule, error) {
+	var rule models.LiveChannelRule
+	err := s.store.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		found, err := sess.Where("id=? AND org_id=?", cmd.Id, cmd.OrgId).Get(&rule)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return models.ErrLiveChannelRuleNotFound
+		}
+		exists, err := sess.Where("org_id=? AND pattern=? AND id<>?", cmd.OrgId, cmd.Pattern, cmd.Id).Exist(&models.LiveChannelRule{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrLiveChannelRuleExists
+		}
+
+		rule.Pattern = cmd.Pattern
+		rule.SubscribeRole = cmd.SubscribeRole
+		rule.SubscribePermission = cmd.SubscribePermission
+		rule.PublishRole = cmd.PublishRole
+		rule.PublishPermission = cmd.PublishPermission
+		rule.PublishIntervalMs = cmd.PublishIntervalMs
+		rule.Aggregation = cmd.Aggregation
+		rule.Updated = time.Now()
+		_, err = sess.ID(rule.Id).AllCols().Update(&rule)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *Storage) DeleteLiveChannelRule(cmd *models.DeleteLiveChannelRuleCommand) error {
+	return s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("id=? AND org_id=?", cmd.Id, cmd.OrgId).Delete(&models.LiveChannelRule{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return models.ErrLiveChannelRuleNotFound
+		}
+		return nil
+	})
+}
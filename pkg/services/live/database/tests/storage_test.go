@@ -4,7 +4,9 @@ package tests
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 
@@ -59,3 +61,123 @@ func TestLiveMessage(t *testing.T) {
 	require.Equal(t, json.RawMessage(`{"input": "hello"}`), msg2.Data)
 	require.NotZero(t, msg2.Published)
 }
+
+func TestLiveChannelFrames(t *testing.T) {
+	storage := SetupTestStorage(t)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var batch []*models.LiveChannelFrame
+	for i := 0; i < 4; i++ {
+		batch = append(batch, &models.LiveChannelFrame{
+			OrgId:   1,
+			Channel: "stream/test/cpu",
+			Data:    json.RawMessage(fmt.Sprintf(`{"i": %d}`, i)),
+			Created: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	batch = append(batch, &models.LiveChannelFrame{
+		OrgId:   1,
+		Channel: "stream/test/memory",
+		Data:    json.RawMessage(`{}`),
+		Created: start,
+	})
+	err := storage.SaveLiveChannelFrames(&models.SaveLiveChannelFramesCommand{Frames: batch})
+	require.NoError(t, err)
+
+	frames, err := storage.GetLiveChannelFrames(&models.GetLiveChannelFramesQuery{
+		OrgId:   1,
+		Channel: "stream/test/cpu",
+		Since:   start.Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	require.Equal(t, json.RawMessage(`{"i": 1}`), frames[0].Data)
+	require.Equal(t, json.RawMessage(`{"i": 3}`), frames[2].Data)
+
+	frames, err = storage.GetLiveChannelFrames(&models.GetLiveChannelFramesQuery{
+		OrgId:   1,
+		Channel: "stream/test/cpu",
+		Since:   start,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	require.Equal(t, json.RawMessage(`{"i": 2}`), frames[0].Data)
+	require.Equal(t, json.RawMessage(`{"i": 3}`), frames[1].Data)
+
+	deleted, err := storage.DeleteLiveChannelFrames(&models.DeleteLiveChannelFramesCommand{Before: start.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+
+	frames, err = storage.GetLiveChannelFrames(&models.GetLiveChannelFramesQuery{
+		OrgId:   1,
+		Channel: "stream/test/cpu",
+		Since:   start,
+	})
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+
+	trimmed, err := storage.TrimLiveChannelFrames(&models.TrimLiveChannelFramesCommand{OrgId: 1, Channel: "stream/test/cpu", Keep: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), trimmed)
+
+	frames, err = storage.GetLiveChannelFrames(&models.GetLiveChannelFramesQuery{
+		OrgId:   1,
+		Channel: "stream/test/cpu",
+		Since:   start,
+	})
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Equal(t, json.RawMessage(`{"i": 3}`), frames[0].Data)
+
+	trimmed, err = storage.TrimLiveChannelFrames(&models.TrimLiveChannelFramesCommand{OrgId: 1, Channel: "stream/test/cpu", Keep: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), trimmed)
+}
+
+func TestLiveChannelRules(t *testing.T) {
+	storage := SetupTestStorage(t)
+
+	rules, err := storage.ListLiveChannelRules(&models.ListLiveChannelRulesQuery{OrgId: 1})
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	rule, err := storage.CreateLiveChannelRule(&models.CreateLiveChannelRuleCommand{
+		OrgId:         1,
+		Pattern:       "stream/devices/*",
+		SubscribeRole: models.ROLE_VIEWER,
+		PublishRole:   models.ROLE_EDITOR,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, rule.Id)
+
+	_, err = storage.CreateLiveChannelRule(&models.CreateLiveChannelRuleCommand{OrgId: 1, Pattern: "stream/devices/*"})
+	require.ErrorIs(t, err, models.ErrLiveChannelRuleExists)
+	_, err = storage.CreateLiveChannelRule(&models.CreateLiveChannelRuleCommand{OrgId: 2, Pattern: "stream/devices/*"})
+	require.NoError(t, err)
+
+	updated, err := storage.UpdateLiveChannelRule(&models.UpdateLiveChannelRuleCommand{
+		Id:                rule.Id,
+		OrgId:             1,
+		Pattern:           "stream/devices/*",
+		PublishPermission: "live.devices:publish",
+		PublishIntervalMs: 1000,
+		Aggregation:       "mean",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.RoleType(""), updated.PublishRole)
+	require.Equal(t, "live.devices:publish", updated.PublishPermission)
+
+	_, err = storage.UpdateLiveChannelRule(&models.UpdateLiveChannelRuleCommand{Id: rule.Id, OrgId: 2, Pattern: "stream/*/*"})
+	require.ErrorIs(t, err, models.ErrLiveChannelRuleNotFound)
+
+	rules, err = storage.ListLiveChannelRules(&models.ListLiveChannelRulesQuery{OrgId: 1})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, "live.devices:publish", rules[0].PublishPermission)
+	require.Equal(t, int64(1000), rules[0].PublishIntervalMs)
+	require.Equal(t, "mean", rules[0].Aggregation)
+
+	require.NoError(t, storage.DeleteLiveChannelRule(&models.DeleteLiveChannelRuleCommand{Id: rule.Id, OrgId: 1}))
+	require.ErrorIs(t, storage.DeleteLiveChannelRule(&models.DeleteLiveChannelRuleCommand{Id: rule.Id, OrgId: 1}), models.ErrLiveChannelRuleNotFound)
+}
@@ -20,7 +20,9 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/manager"
 	"github.com/grafana/grafana/pkg/plugins/plugincontext"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/live/channelrule"
 	"github.com/grafana/grafana/pkg/services/live/database"
 	"github.com/grafana/grafana/pkg/services/live/features"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
@@ -38,11 +40,17 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+	redis "gopkg.in/redis.v5"
 )
 
 var (
 	logger   = log.New("live")
 	loggerCF = log.New("live.centrifuge")
+
+	// streamThrottlerMetrics are shared by the throttlers of the service, they can only be registered once.
+	streamThrottlerMetrics = managedstream.NewThrottlerMetrics(prometheus.DefaultRegisterer)
 )
 
 func init() {
@@ -73,14 +81,15 @@ type CoreGrafanaScope struct {
 // it starts receiving all messages published into this channel. Thus GrafanaLive is a PUB/SUB
 // server.
 type GrafanaLive struct {
-	PluginContextProvider *plugincontext.Provider  `inject:""`
-	Cfg                   *setting.Cfg             `inject:""`
-	RouteRegister         routing.RouteRegister    `inject:""`
-	LogsService           *cloudwatch.LogsService  `inject:""`
-	PluginManager         *manager.PluginManager   `inject:""`
-	CacheService          *localcache.CacheService `inject:""`
-	DatasourceCache       datasources.CacheService `inject:""`
-	SQLStore              *sqlstore.SQLStore       `inject:""`
+	PluginContextProvider *plugincontext.Provider     `inject:""`
+	Cfg                   *setting.Cfg                `inject:""`
+	RouteRegister         routing.RouteRegister       `inject:""`
+	LogsService           *cloudwatch.LogsService     `inject:""`
+	PluginManager         *manager.PluginManager      `inject:""`
+	CacheService          *localcache.CacheService    `inject:""`
+	DatasourceCache       datasources.CacheService    `inject:""`
+	SQLStore              *sqlstore.SQLStore          `inject:""`
+	AccessControl         accesscontrol.AccessControl `inject:""`
 
 	node *centrifuge.Node
 
@@ -97,9 +106,16 @@ type GrafanaLive struct {
 
 	ManagedStreamRunner *managedstream.Runner
 
+	// ChannelRules restricts the access to the channels.
+	ChannelRules *channelrule.Checker
+
 	contextGetter    *pluginContextGetter
 	runStreamManager *runstream.Manager
 	storage          *database.Storage
+	channelHistory   *managedstream.History
+	streamThrottler  *managedstream.Throttler
+	// redisClient is the client of the frame cache of the Redis HA engine, nil without it.
+	redisClient *redis.Client
 }
 
 func (g *GrafanaLive) getStreamPlugin(pluginID string) (backend.StreamHandler, error) {
@@ -126,11 +142,52 @@ func (g *GrafanaLive) AddMigration(mg *migrator.Migrator) {
 func (g *GrafanaLive) Run(ctx context.Context) error {
 	if g.runStreamManager != nil {
 		// Only run stream manager if GrafanaLive properly initialized.
-		return g.runStreamManager.Run(ctx)
+		group, ctx := errgroup.WithContext(ctx)
+		group.Go(func() error {
+			return g.runStreamManager.Run(ctx)
+		})
+		if g.channelHistory != nil {
+			group.Go(func() error {
+				return g.channelHistory.Run(ctx)
+			})
+		}
+		group.Go(func() error {
+			return g.streamThrottler.Run(ctx)
+		})
+		if g.redisClient != nil {
+			group.Go(func() error {
+				<-ctx.Done()
+				if err := g.redisClient.Close(); err != nil {
+					logger.Warn("Error closing Live Redis client", "error", err)
+				}
+				return nil
+			})
+		}
+		return group.Wait()
 	}
 	return nil
 }
 
+// streamThrottleOptions returns the throttle options of a managed stream channel, from the channel
+// rule applying to it or else from the [live] settings.
+func (g *GrafanaLive) streamThrottleOptions(orgID int64, channel string) (managedstream.ThrottleOptions, error) {
+	opts := managedstream.ThrottleOptions{
+		Interval:    g.Cfg.LiveStreamPublishInterval,
+		Aggregation: g.Cfg.LiveStreamAggregation,
+	}
+	rule, err := g.ChannelRules.Rule(orgID, channel)
+	if err != nil || rule == nil {
+		return opts, err
+	}
+	if rule.PublishIntervalMs > 0 {
+		opts.Interval = time.Duration(rule.PublishIntervalMs) * time.Millisecond
+	}
+	if rule.Aggregation != "" {
+		opts.Aggregation = rule.Aggregation
+	}
+	return opts, nil
+}
+
 var clientConcurrency = 8
 
 // Init initializes Live service.
@@ -156,6 +213,15 @@ func (g *GrafanaLive) Init() error {
 	}
 	g.node = node
 
+	var frameCache managedstream.FrameCache = managedstream.NewMemoryFrameCache()
+	if g.Cfg.LiveHAEngine != "" {
+		logger.Debug("Live HA engine", "type", g.Cfg.LiveHAEngine)
+		frameCache, g.redisClient, err = setupRedisEngine(node, g.Cfg.LiveHAEngineConnStr)
+		if err != nil {
+			return err
+		}
+	}
+
 	g.contextGetter = newPluginContextGetter(g.PluginContextProvider)
 	channelSender := newPluginChannelSender(node)
 	presenceGetter := newPluginPresenceGetter(node)
@@ -167,11 +233,20 @@ func (g *GrafanaLive) Init() error {
 		ClientCount: g.ClientCount,
 	}
 	g.storage = database.NewStorage(g.SQLStore, g.CacheService)
+	g.ChannelRules = channelrule.NewChecker(g.storage, g.CacheService, g.AccessControl)
 	g.GrafanaScope.Dashboards = dash
 	g.GrafanaScope.Features["dashboard"] = dash
 	g.GrafanaScope.Features["broadcast"] = features.NewBroadcastRunner(g.storage)
 
-	g.ManagedStreamRunner = managedstream.NewRunner(g.Publish)
+	if g.Cfg.LiveHistoryMaxCount > 0 {
+		var historyStorage managedstream.HistoryStorage
+		if g.Cfg.LiveHistoryPersist {
+			historyStorage = g.storage
+		}
+		g.channelHistory = managedstream.NewHistory(g.Cfg.LiveHistoryMaxCount, g.Cfg.LiveHistoryMaxAge, historyStorage)
+	}
+	g.streamThrottler = managedstream.NewThrottler(g.Publish, g.streamThrottleOptions, streamThrottlerMetrics)
+	g.ManagedStreamRunner = managedstream.NewRunner(g.Publish, frameCache, g.channelHistory, g.streamThrottler)
 
 	// Set ConnectHandler called when client successfully connected to Node. Your code
 	// inside handler must be synchronized since it will be called concurrently from
@@ -236,7 +311,7 @@ func (g *GrafanaLive) Init() error {
 		WriteBufferSize: 1024,
 	})
 
-	pushWSHandler := pushws.NewHandler(g.ManagedStreamRunner, pushws.Config{
+	pushWSHandler := pushws.NewHandler(g.ManagedStreamRunner, g.ChannelRules, pushws.Config{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 	})
@@ -336,6 +411,16 @@ func (g *GrafanaLive) handleOnSubscribe(client *centrifuge.Client, e centrifuge.
 		return centrifuge.SubscribeReply{}, centrifuge.ErrorPermissionDenied
 	}
 
+	allowed, err := g.ChannelRules.CanSubscribe(client.Context(), user, channel)
+	if err != nil {
+		logger.Error("Error checking channel rules", "user", client.UserID(), "client", client.ID(), "channel", e.Channel, "error", err)
+		return centrifuge.SubscribeReply{}, centrifuge.ErrorInternal
+	}
+	if !allowed {
+		logger.Info("Error subscribing: denied by channel rule", "user", client.UserID(), "client", client.ID(), "channel", e.Channel)
+		return centrifuge.SubscribeReply{}, centrifuge.ErrorPermissionDenied
+	}
+
 	handler, addr, err := g.GetChannelHandler(user, channel)
 	if err != nil {
 		if errors.Is(err, live.ErrInvalidChannelID) {
@@ -391,6 +476,16 @@ func (g *GrafanaLive) handleOnPublish(client *centrifuge.Client, e centrifuge.Pu
 		return centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied
 	}
 
+	allowed, err := g.ChannelRules.CanPublish(client.Context(), user, channel)
+	if err != nil {
+		logger.Error("Error checking channel rules", "user", client.UserID(), "client", client.ID(), "channel", e.Channel, "error", err)
+		return centrifuge.PublishReply{}, centrifuge.ErrorInternal
+	}
+	if !allowed {
+		logger.Info("Error publishing: denied by channel rule", "user", client.UserID(), "client", client.ID(), "channel", e.Channel)
+		return centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied
+	}
+
 	handler, addr, err := g.GetChannelHandler(user, channel)
 	if err != nil {
 		if errors.Is(err, live.ErrInvalidChannelID) {
@@ -596,6 +691,15 @@ func (g *GrafanaLive) HandleHTTPPublish(ctx *models.ReqContext, cmd dtos.LivePub
 
 	logger.Debug("Publish API cmd", "user", ctx.SignedInUser.UserId, "channel", cmd.Channel)
 
+	allowed, err := g.ChannelRules.CanPublish(ctx.Req.Context(), ctx.SignedInUser, cmd.Channel)
+	if err != nil {
+		logger.Error("Error checking channel rules", "error", err, "channel", cmd.Channel)
+		return response.Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), nil)
+	}
+	if !allowed {
+		return response.Error(http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+	}
+
 	channelHandler, addr, err := g.GetChannelHandler(ctx.SignedInUser, cmd.Channel)
 	if err != nil {
 		logger.Error("Error getting channels handler", "error", err, "channel", cmd.Channel)
@@ -625,9 +729,9 @@ func (g *GrafanaLive) HandleHTTPPublish(ctx *models.ReqContext, cmd dtos.LivePub
 // HandleListHTTP returns metadata so the UI can build a nice form
 func (g *GrafanaLive) HandleListHTTP(c *models.ReqContext) response.Response {
 	info := util.DynMap{}
-	channels := make([]util.DynMap, 0)
-	for k, v := range g.ManagedStreamRunner.Streams(c.SignedInUser.OrgId) {
-		channels = append(channels, v.ListChannels(c.SignedInUser.OrgId, "stream/"+k+"/")...)
+	channels, err := g.ManagedStreamRunner.ListChannels(c.SignedInUser.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), err)
 	}
 
 	// Hardcode sample streams
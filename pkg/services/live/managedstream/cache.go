@@ -0,0 +1,19 @@
+package managedstream
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameCache keeps the last frame pushed to each channel of the managed streams.
+// Its implementations may be shared between Grafana server instances.
+type FrameCache interface {
+	// GetActiveChannels returns the schema of the last frame of each channel of an organization.
+	GetActiveChannels(orgID int64) (map[string]json.RawMessage, error)
+	// GetFrame returns the last frame of a channel, with its schema and data.
+	GetFrame(orgID int64, channel string) (json.RawMessage, bool, error)
+	// Update saves the last frame of a channel and returns whether its schema
+	// is the same as the one of the previous frame.
+	Update(orgID int64, channel string, frameJSON data.FrameJSONCache) (bool, error)
+}
@@ -0,0 +1,53 @@
+package managedstream
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// MemoryFrameCache is a FrameCache local to the Grafana server.
+type MemoryFrameCache struct {
+	mu     sync.RWMutex
+	frames map[int64]map[string]data.FrameJSONCache
+}
+
+// NewMemoryFrameCache creates new MemoryFrameCache.
+func NewMemoryFrameCache() *MemoryFrameCache {
+	return &MemoryFrameCache{
+		frames: map[int64]map[string]data.FrameJSONCache{},
+	}
+}
+
+func (c *MemoryFrameCache) GetActiveChannels(orgID int64) (map[string]json.RawMessage, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	info := make(map[string]json.RawMessage, len(c.frames[orgID]))
+	for k, v := range c.frames[orgID] {
+		info[k] = v.Bytes(data.IncludeSchemaOnly)
+	}
+	return info, nil
+}
+
+func (c *MemoryFrameCache) GetFrame(orgID int64, channel string) (json.RawMessage, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cachedFrame, ok := c.frames[orgID][channel]
+	if !ok {
+		return nil, false, nil
+	}
+	return cachedFrame.Bytes(data.IncludeAll), true, nil
+}
+
+func (c *MemoryFrameCache) Update(orgID int64, channel string, frameJSON data.FrameJSONCache) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.frames[orgID]; !ok {
+		c.frames[orgID] = map[string]data.FrameJSONCache{}
+	}
+	cachedFrame, exists := c.frames[orgID][channel]
+	c.frames[orgID][channel] = frameJSON
+	return exists && cachedFrame.SameSchema(&frameJSON), nil
+}
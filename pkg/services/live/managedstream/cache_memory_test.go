@@ -0,0 +1,67 @@
+package managedstream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFrameCache(t *testing.T) {
+	testFrameCache(t, NewMemoryFrameCache())
+}
+
+// testFrameCache checks the behavior shared by all the FrameCache implementations.
+func testFrameCache(t *testing.T, c FrameCache) {
+	t.Helper()
+
+	var orgID int64 = 1
+	channels, err := c.GetActiveChannels(orgID)
+	require.NoError(t, err)
+	require.Empty(t, channels)
+
+	_, ok, err := c.GetFrame(orgID, "stream/a/test")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	frame := func(value float64) data.FrameJSONCache {
+		msg, err := data.FrameToJSONCache(data.NewFrame("test", data.NewField("value", nil, []float64{value})))
+		require.NoError(t, err)
+		return msg
+	}
+
+	unchanged, err := c.Update(orgID, "stream/a/test", frame(1))
+	require.NoError(t, err)
+	require.False(t, unchanged, "the first frame has a new schema")
+
+	unchanged, err = c.Update(orgID, "stream/a/test", frame(2))
+	require.NoError(t, err)
+	require.True(t, unchanged)
+
+	msg, err := data.FrameToJSONCache(data.NewFrame("test", data.NewField("other", nil, []float64{3})))
+	require.NoError(t, err)
+	unchanged, err = c.Update(orgID, "stream/a/test", msg)
+	require.NoError(t, err)
+	require.False(t, unchanged)
+
+	unchanged, err = c.Update(orgID, "stream/a/test", frame(4))
+	require.NoError(t, err)
+	require.False(t, unchanged)
+
+	f, ok, err := c.GetFrame(orgID, "stream/a/test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	last := frame(4)
+	require.JSONEq(t, string(last.Bytes(data.IncludeAll)), string(f))
+
+	channels, err = c.GetActiveChannels(orgID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]json.RawMessage{
+		"stream/a/test": last.Bytes(data.IncludeSchemaOnly),
+	}, channels)
+
+	channels, err = c.GetActiveChannels(2)
+	require.NoError(t, err)
+	require.Empty(t, channels)
+}
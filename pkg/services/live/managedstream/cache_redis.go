@@ -0,0 +1,94 @@
+package managedstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	redis "gopkg.in/redis.v5"
+)
+
+// redisFrameCacheExpiration is how long the frames of an organization are kept
+// after the last update of any of its channels.
+const redisFrameCacheExpiration = 24 * time.Hour
+
+// redisUpdateScript saves the schema and the frame of a channel and returns 1 when
+// the schema is the same as the previous one, 0 otherwise.
+// KEYS: schemas hash, frames hash. ARGV: channel, schema, frame, expiration in seconds.
+const redisUpdateScript = `
+local previous = redis.call('hget', KEYS[1], ARGV[1])
+redis.call('hset', KEYS[1], ARGV[1], ARGV[2])
+redis.call('hset', KEYS[2], ARGV[1], ARGV[3])
+redis.call('expire', KEYS[1], ARGV[4])
+redis.call('expire', KEYS[2], ARGV[4])
+if previous == ARGV[2] then
+	return 1
+end
+return 0
+`
+
+// RedisFrameCache is a FrameCache stored in Redis, shared by the Grafana servers using the same Redis.
+// The schemas and the frames of the channels of an organization are kept in two hashes.
+type RedisFrameCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisFrameCache creates new RedisFrameCache with keys starting with prefix.
+func NewRedisFrameCache(client *redis.Client, prefix string) *RedisFrameCache {
+	return &RedisFrameCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (c *RedisFrameCache) schemasKey(orgID int64) string {
+	return fmt.Sprintf("%s.managed_stream.%d.schemas", c.prefix, orgID)
+}
+
+func (c *RedisFrameCache) framesKey(orgID int64) string {
+	return fmt.Sprintf("%s.managed_stream.%d.frames", c.prefix, orgID)
+}
+
+func (c *RedisFrameCache) GetActiveChannels(orgID int64) (map[string]json.RawMessage, error) {
+	schemas, err := c.client.HGetAll(c.schemasKey(orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	info := make(map[string]json.RawMessage, len(schemas))
+	for k, v := range schemas {
+		info[k] = json.RawMessage(v)
+	}
+	return info, nil
+}
+
+func (c *RedisFrameCache) GetFrame(orgID int64, channel string) (json.RawMessage, bool, error) {
+	frame, err := c.client.HGet(c.framesKey(orgID), channel).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return json.RawMessage(frame), true, nil
+}
+
+func (c *RedisFrameCache) Update(orgID int64, channel string, frameJSON data.FrameJSONCache) (bool, error) {
+	res, err := c.client.Eval(
+		redisUpdateScript,
+		[]string{c.schemasKey(orgID), c.framesKey(orgID)},
+		channel,
+		string(frameJSON.Bytes(data.IncludeSchemaOnly)),
+		string(frameJSON.Bytes(data.IncludeAll)),
+		int64(redisFrameCacheExpiration.Seconds()),
+	).Result()
+	if err != nil {
+		return false, err
+	}
+	unchanged, ok := res.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected result %v of the update of the channel %s", res, channel)
+	}
+	return unchanged == 1, nil
+}
@@ -0,0 +1,26 @@
+package managedstream
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	redis "gopkg.in/redis.v5"
+)
+
+func TestRedisFrameCache(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+
+	testFrameCache(t, NewRedisFrameCache(client, "test"))
+
+	require.True(t, mr.Exists("test.managed_stream.1.schemas"))
+	require.True(t, mr.Exists("test.managed_stream.1.frames"))
+	require.Equal(t, redisFrameCacheExpiration, mr.TTL("test.managed_stream.1.frames"))
+}
@@ -0,0 +1,372 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// historyCleanupInterval is the interval between two removals of the expired frames of a History.
+const historyCleanupInterval = time.Minute
+
+// historyFlushInterval is the interval between two writes of the frames queued by a History to its storage.
+const historyFlushInterval = time.Second
+
+// historySaveBatchSize is the maximum number of frames written to the storage at once.
+const historySaveBatchSize = 100
+
+// HistoryStorage persists the frames of the history of the channels.
+type HistoryStorage interface {
+	SaveLiveChannelFrames(cmd *models.SaveLiveChannelFramesCommand) error
+	GetLiveChannelFrames(query *models.GetLiveChannelFramesQuery) ([]*models.LiveChannelFrame, error)
+	DeleteLiveChannelFrames(cmd *models.DeleteLiveChannelFramesCommand) (int64, error)
+	TrimLiveChannelFrames(cmd *models.TrimLiveChannelFramesCommand) (int64, error)
+}
+
+// History keeps the recent frames of the channels of the managed streams, so that they can be
+// replayed to new subscribers. The frames of a channel are in a ring buffer bounded by a number
+// of frames and an age. With a storage, the frames are also persisted in batches by Run, and the
+// history of a channel is loaded from the storage the first time it is used, e.g. after a restart.
+type History struct {
+	maxCount int
+	maxAge   time.Duration
+	storage  HistoryStorage
+	now      func() time.Time
+
+	mu       sync.Mutex
+	channels map[historyKey]*frameRing
+	// pending are the frames of the channels not persisted yet, at most maxCount per channel.
+	pending map[historyKey][]*models.LiveChannelFrame
+}
+
+type historyKey struct {
+	orgID   int64
+	channel string
+}
+
+type historyEntry struct {
+	frame   *data.Frame
+	created time.Time
+}
+
+// NewHistory creates new History keeping at most maxCount frames per channel, for at most maxAge
+// when it is positive. storage is optional.
+func NewHistory(maxCount int, maxAge time.Duration, storage HistoryStorage) *History {
+	return &History{
+		maxCount: maxCount,
+		maxAge:   maxAge,
+		storage:  storage,
+		now:      time.Now,
+		channels: map[historyKey]*frameRing{},
+		pending:  map[historyKey][]*models.LiveChannelFrame{},
+	}
+}
+
+// Add adds a frame to the history of a channel. With a storage, the frame is queued to be persisted.
+func (h *History) Add(orgID int64, channel string, frame *data.Frame) error {
+	now := h.now()
+	key := historyKey{orgID: orgID, channel: channel}
+	if err := h.load(key, now); err != nil {
+		return err
+	}
+
+	var frameJSON []byte
+	if h.storage != nil {
+		var err error
+		if frameJSON, err = json.Marshal(frame); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ring(key).push(historyEntry{frame: frame, created: now})
+	if h.storage == nil {
+		return nil
+	}
+	// the frames which would not be kept by the storage are not persisted
+	pending := append(h.pending[key], &models.LiveChannelFrame{
+		OrgId:   orgID,
+		Channel: channel,
+		Data:    frameJSON,
+		Created: now,
+	})
+	if len(pending) > h.maxCount {
+		pending = pending[len(pending)-h.maxCount:]
+	}
+	h.pending[key] = pending
+	return nil
+}
+
+// Get returns a frame with the rows of the frames of the history of a channel, since the last change of schema.
+func (h *History) Get(orgID int64, channel string) (*data.Frame, bool, error) {
+	now := h.now()
+	key := historyKey{orgID: orgID, channel: channel}
+	if err := h.load(key, now); err != nil {
+		return nil, false, err
+	}
+
+	h.mu.Lock()
+	ring := h.ring(key)
+	ring.dropBefore(h.oldest(now))
+	entries := ring.entries()
+	h.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil, false, nil
+	}
+	frames := make([]*data.Frame, 0, len(entries))
+	for _, e := range entries {
+		frames = append(frames, e.frame)
+	}
+	return mergeFrames(frames), true, nil
+}
+
+// Run persists the queued frames and removes the expired frames of the history until the context is done.
+// The queued frames are persisted before it returns.
+func (h *History) Run(ctx context.Context) error {
+	var flushC, cleanupC <-chan time.Time
+	if h.storage != nil {
+		flushTicker := time.NewTicker(historyFlushInterval)
+		defer flushTicker.Stop()
+		flushC = flushTicker.C
+	}
+	if h.maxAge > 0 {
+		cleanupTicker := time.NewTicker(historyCleanupInterval)
+		defer cleanupTicker.Stop()
+		cleanupC = cleanupTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if h.storage != nil {
+				h.flush()
+			}
+			return ctx.Err()
+		case <-flushC:
+			h.flush()
+		case <-cleanupC:
+			h.cleanup()
+		}
+	}
+}
+
+// flush persists the queued frames, and deletes the persisted frames of their channels beyond maxCount.
+func (h *History) flush() {
+	h.mu.Lock()
+	pending := h.pending
+	h.pending = map[historyKey][]*models.LiveChannelFrame{}
+	h.mu.Unlock()
+
+	var frames []*models.LiveChannelFrame
+	for _, channelFrames := range pending {
+		frames = append(frames, channelFrames...)
+	}
+	for len(frames) > 0 {
+		batch := frames
+		if len(batch) > historySaveBatchSize {
+			batch = batch[:historySaveBatchSize]
+		}
+		frames = frames[len(batch):]
+		if err := h.storage.SaveLiveChannelFrames(&models.SaveLiveChannelFramesCommand{Frames: batch}); err != nil {
+			logger.Error("Error saving channel frames", "error", err, "count", len(batch))
+		}
+	}
+
+	for key := range pending {
+		_, err := h.storage.TrimLiveChannelFrames(&models.TrimLiveChannelFramesCommand{
+			OrgId:   key.orgID,
+			Channel: key.channel,
+			Keep:    h.maxCount,
+		})
+		if err != nil {
+			logger.Error("Error deleting the oldest channel frames", "error", err, "channel", key.channel)
+		}
+	}
+}
+
+func (h *History) cleanup() {
+	oldest := h.oldest(h.now())
+
+	h.mu.Lock()
+	for k, ring := range h.channels {
+		ring.dropBefore(oldest)
+		if ring.size == 0 {
+			delete(h.channels, k)
+		}
+	}
+	h.mu.Unlock()
+
+	if h.storage == nil {
+		return
+	}
+	deleted, err := h.storage.DeleteLiveChannelFrames(&models.DeleteLiveChannelFramesCommand{Before: oldest})
+	if err != nil {
+		logger.Error("Error deleting expired channel frames", "error", err)
+		return
+	}
+	logger.Debug("Deleted expired channel frames", "count", deleted)
+}
+
+// oldest returns the creation time of the oldest frame kept at now.
+func (h *History) oldest(now time.Time) time.Time {
+	if h.maxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-h.maxAge)
+}
+
+// load loads the history of a channel from the storage if it is not loaded yet. The storage is read
+// without holding h.mu, so that the other channels are not blocked.
+func (h *History) load(key historyKey, now time.Time) error {
+	h.mu.Lock()
+	_, ok := h.channels[key]
+	h.mu.Unlock()
+	if ok || h.storage == nil {
+		return nil
+	}
+
+	stored, err := h.storage.GetLiveChannelFrames(&models.GetLiveChannelFramesQuery{
+		OrgId:   key.orgID,
+		Channel: key.channel,
+		Since:   h.oldest(now),
+		Limit:   h.maxCount,
+	})
+	if err != nil {
+		return err
+	}
+	ring := newFrameRing(h.maxCount)
+	for _, f := range stored {
+		var frame data.Frame
+		if err := json.Unmarshal(f.Data, &frame); err != nil {
+			logger.Warn("Skipping invalid stored channel frame", "channel", key.channel, "error", err)
+			continue
+		}
+		ring.push(historyEntry{frame: &frame, created: f.Created})
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// the channel may have been loaded concurrently
+	if _, ok := h.channels[key]; !ok {
+		h.channels[key] = ring
+	}
+	return nil
+}
+
+// ring returns the ring buffer of a channel, a new one if there is none. h.mu must be held.
+func (h *History) ring(key historyKey) *frameRing {
+	ring, ok := h.channels[key]
+	if !ok {
+		ring = newFrameRing(h.maxCount)
+		h.channels[key] = ring
+	}
+	return ring
+}
+
+// frameRing is a ring buffer of the last frames of a channel.
+type frameRing struct {
+	buf   []historyEntry
+	start int
+	size  int
+}
+
+func newFrameRing(capacity int) *frameRing {
+	return &frameRing{buf: make([]historyEntry, capacity)}
+}
+
+// push adds an entry, replacing the oldest one when the ring is full.
+func (r *frameRing) push(e historyEntry) {
+	if len(r.buf) == 0 {
+		return
+	}
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = e
+		r.size++
+		return
+	}
+	r.buf[r.start] = e
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// dropBefore removes the entries created before t.
+func (r *frameRing) dropBefore(t time.Time) {
+	for r.size > 0 && r.buf[r.start].created.Before(t) {
+		r.buf[r.start] = historyEntry{}
+		r.start = (r.start + 1) % len(r.buf)
+		r.size--
+	}
+}
+
+// entries returns the entries, oldest first.
+func (r *frameRing) entries() []historyEntry {
+	entries := make([]historyEntry, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		entries = append(entries, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return entries
+}
+
+// mergeFrames returns a frame with the rows of the last frames with the same schema as the last one.
+func mergeFrames(frames []*data.Frame) *data.Frame {
+	last := frames[len(frames)-1]
+	first := len(frames) - 1
+	for first > 0 && sameSchema(frames[first-1], last) {
+		first--
+	}
+
+	fields := make([]*data.Field, 0, len(last.Fields))
+	for _, f := range last.Fields {
+		field := data.NewFieldFromFieldType(f.Type(), 0)
+		field.Name = f.Name
+		field.Labels = f.Labels
+		field.Config = f.Config
+		fields = append(fields, field)
+	}
+	merged := data.NewFrame(last.Name, fields...)
+	merged.Meta = last.Meta
+
+	for _, frame := range frames[first:] {
+		rows, ok := frameRows(frame)
+		if !ok {
+			continue
+		}
+		for row := 0; row < rows; row++ {
+			for i, f := range frame.Fields {
+				fields[i].Append(f.CopyAt(row))
+			}
+		}
+	}
+	return merged
+}
+
+func sameSchema(a, b *data.Frame) bool {
+	if a.Name != b.Name || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name ||
+			a.Fields[i].Type() != b.Fields[i].Type() ||
+			a.Fields[i].Labels.String() != b.Fields[i].Labels.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// frameRows returns the number of rows of a frame, if all its fields have the same length.
+func frameRows(frame *data.Frame) (int, bool) {
+	rows := 0
+	for i, f := range frame.Fields {
+		if i > 0 && f.Len() != rows {
+			return 0, false
+		}
+		rows = f.Len()
+	}
+	return rows, true
+}
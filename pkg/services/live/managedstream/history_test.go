@@ -0,0 +1,286 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHistoryStorage is a HistoryStorage in memory.
+type fakeHistoryStorage struct {
+	mu      sync.Mutex
+	frames  []*models.LiveChannelFrame
+	batches int
+}
+
+func (s *fakeHistoryStorage) SaveLiveChannelFrames(cmd *models.SaveLiveChannelFramesCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = append(s.frames, cmd.Frames...)
+	s.batches++
+	return nil
+}
+
+func (s *fakeHistoryStorage) saved() []*models.LiveChannelFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*models.LiveChannelFrame(nil), s.frames...)
+}
+
+func (s *fakeHistoryStorage) GetLiveChannelFrames(query *models.GetLiveChannelFramesQuery) ([]*models.LiveChannelFrame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var frames []*models.LiveChannelFrame
+	for _, f := range s.frames {
+		if f.OrgId == query.OrgId && f.Channel == query.Channel && !f.Created.Before(query.Since) {
+			frames = append(frames, f)
+		}
+	}
+	if query.Limit > 0 && len(frames) > query.Limit {
+		frames = frames[len(frames)-query.Limit:]
+	}
+	return frames, nil
+}
+
+func (s *fakeHistoryStorage) DeleteLiveChannelFrames(cmd *models.DeleteLiveChannelFramesCommand) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []*models.LiveChannelFrame
+	for _, f := range s.frames {
+		if !f.Created.Before(cmd.Before) {
+			kept = append(kept, f)
+		}
+	}
+	deleted := int64(len(s.frames) - len(kept))
+	s.frames = kept
+	return deleted, nil
+}
+
+func (s *fakeHistoryStorage) TrimLiveChannelFrames(cmd *models.TrimLiveChannelFramesCommand) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []*models.LiveChannelFrame
+	count := 0
+	for i := len(s.frames) - 1; i >= 0; i-- {
+		f := s.frames[i]
+		if f.OrgId == cmd.OrgId && f.Channel == cmd.Channel {
+			if count >= cmd.Keep {
+				continue
+			}
+			count++
+		}
+		kept = append([]*models.LiveChannelFrame{f}, kept...)
+	}
+	deleted := int64(len(s.frames) - len(kept))
+	s.frames = kept
+	return deleted, nil
+}
+
+func newHistoryTestFrame(values ...float64) *data.Frame {
+	times := make([]time.Time, 0, len(values))
+	for i := range values {
+		times = append(times, time.Unix(int64(values[i]), 0).UTC())
+	}
+	return data.NewFrame("test",
+		data.NewField("time", nil, times),
+		data.NewField("value", nil, values),
+	)
+}
+
+func TestHistory(t *testing.T) {
+	now := time.Unix(1000, 0)
+	newHistory := func(maxCount int, maxAge time.Duration, storage HistoryStorage) *History {
+		h := NewHistory(maxCount, maxAge, storage)
+		h.now = func() time.Time { return now }
+		return h
+	}
+
+	t.Run("empty history", func(t *testing.T) {
+		h := newHistory(3, time.Minute, nil)
+		_, ok, err := h.Get(1, "stream/a/test")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("the frames are merged", func(t *testing.T) {
+		h := newHistory(3, time.Minute, nil)
+		require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(1)))
+		require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(2, 3)))
+		require.NoError(t, h.Add(1, "stream/a/other", newHistoryTestFrame(10)))
+
+		frame, ok, err := h.Get(1, "stream/a/test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, newHistoryTestFrame(1, 2, 3), frame)
+
+		_, ok, err = h.Get(2, "stream/a/test")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("the number of frames is limited", func(t *testing.T) {
+		h := newHistory(2, 0, nil)
+		for i := 1; i <= 5; i++ {
+			require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(float64(i))))
+		}
+		frame, ok, err := h.Get(1, "stream/a/test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, newHistoryTestFrame(4, 5), frame)
+	})
+
+	t.Run("the age of frames is limited", func(t *testing.T) {
+		h := newHistory(10, time.Minute, nil)
+		require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(1)))
+		now = now.Add(40 * time.Second)
+		require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(2)))
+		now = now.Add(40 * time.Second)
+
+		frame, ok, err := h.Get(1, "stream/a/test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, newHistoryTestFrame(2), frame)
+
+		now = now.Add(time.Minute)
+		h.cleanup()
+		require.Empty(t, h.channels)
+	})
+
+	t.Run("only the frames with the last schema are merged", func(t *testing.T) {
+		h := newHistory(10, 0, nil)
+		require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(1)))
+		require.NoError(t, h.Add(1, "stream/a/test", data.NewFrame("test", data.NewField("value", nil, []float64{2}))))
+		require.NoError(t, h.Add(1, "stream/a/test", data.NewFrame("test", data.NewField("value", nil, []float64{3}))))
+
+		frame, ok, err := h.Get(1, "stream/a/test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, data.NewFrame("test", data.NewField("value", nil, []float64{2, 3})), frame)
+	})
+
+	t.Run("the history is persisted", func(t *testing.T) {
+		storage := &fakeHistoryStorage{}
+		h := newHistory(2, time.Minute, storage)
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(float64(i))))
+		}
+		// The frames are queued, only the ones kept by the storage are written.
+		require.Empty(t, storage.saved())
+		h.flush()
+		require.Len(t, storage.saved(), 2)
+
+		// A new history, e.g. after a restart, loads the frames of the storage.
+		restarted := newHistory(2, time.Minute, storage)
+		frame, ok, err := restarted.Get(1, "stream/a/test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		expected, err := data.FrameToJSON(newHistoryTestFrame(2, 3), data.IncludeAll)
+		require.NoError(t, err)
+		actual, err := data.FrameToJSON(frame, data.IncludeAll)
+		require.NoError(t, err)
+		require.JSONEq(t, string(expected), string(actual))
+
+		now = now.Add(2 * time.Minute)
+		restarted.cleanup()
+		require.Empty(t, storage.frames)
+	})
+
+	t.Run("the persisted frames are limited without a max age", func(t *testing.T) {
+		storage := &fakeHistoryStorage{}
+		h := newHistory(2, 0, storage)
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(float64(i))))
+			h.flush()
+		}
+		require.NoError(t, h.Add(1, "stream/a/other", newHistoryTestFrame(10)))
+		h.flush()
+
+		saved := storage.saved()
+		require.Len(t, saved, 3)
+		require.JSONEq(t, string(saved[0].Data), string(mustFrameJSON(t, newHistoryTestFrame(2))))
+		require.JSONEq(t, string(saved[1].Data), string(mustFrameJSON(t, newHistoryTestFrame(3))))
+		require.Equal(t, "stream/a/other", saved[2].Channel)
+	})
+
+	t.Run("the frames are written in batches", func(t *testing.T) {
+		storage := &fakeHistoryStorage{}
+		h := newHistory(10, time.Minute, storage)
+		for i := 0; i < 25; i++ {
+			for j := 1; j <= 10; j++ {
+				require.NoError(t, h.Add(int64(i), "stream/a/test", newHistoryTestFrame(float64(j))))
+			}
+		}
+		h.flush()
+		require.Len(t, storage.saved(), 250)
+		require.Equal(t, 3, storage.batches)
+	})
+
+	t.Run("the queued frames are written when run stops", func(t *testing.T) {
+		storage := &fakeHistoryStorage{}
+		h := newHistory(2, 0, storage)
+		require.NoError(t, h.Add(1, "stream/a/test", newHistoryTestFrame(1)))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, h.Run(ctx), context.Canceled)
+		require.Len(t, storage.saved(), 1)
+	})
+
+	t.Run("run stops with the context", func(t *testing.T) {
+		h := newHistory(2, time.Minute, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, h.Run(ctx), context.Canceled)
+	})
+}
+
+func mustFrameJSON(t *testing.T, frame *data.Frame) []byte {
+	t.Helper()
+	b, err := json.Marshal(frame)
+	require.NoError(t, err)
+	return b
+}
+
+func TestFrameRing(t *testing.T) {
+	r := newFrameRing(3)
+	for i := 1; i <= 4; i++ {
+		r.push(historyEntry{created: time.Unix(int64(i), 0)})
+	}
+	created := func() []int64 {
+		var c []int64
+		for _, e := range r.entries() {
+			c = append(c, e.created.Unix())
+		}
+		return c
+	}
+	require.Equal(t, []int64{2, 3, 4}, created())
+
+	r.dropBefore(time.Unix(4, 0))
+	require.Equal(t, []int64{4}, created())
+
+	r.push(historyEntry{created: time.Unix(5, 0)})
+	require.Equal(t, []int64{4, 5}, created())
+
+	r.dropBefore(time.Unix(6, 0))
+	require.Empty(t, created())
+}
+
+func TestManagedStream_OnSubscribeReplaysHistory(t *testing.T) {
+	var orgID int64 = 1
+	publisher := &testPublisher{orgID: orgID, t: t}
+	s := NewManagedStream("a", publisher.publish, NewMemoryFrameCache(), NewHistory(10, time.Minute, nil), nil)
+	require.NoError(t, s.Push(orgID, "test", newHistoryTestFrame(1)))
+	require.NoError(t, s.Push(orgID, "test", newHistoryTestFrame(2)))
+
+	reply, _, err := s.OnSubscribe(context.Background(), &models.SignedInUser{OrgId: orgID}, models.SubscribeEvent{Path: "test"})
+	require.NoError(t, err)
+	expected, err := data.FrameToJSON(newHistoryTestFrame(1, 2), data.IncludeAll)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(reply.Data))
+}
@@ -3,6 +3,7 @@ package managedstream
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,19 +21,31 @@ var (
 
 // Runner keeps ManagedStream per streamID.
 type Runner struct {
-	mu        sync.RWMutex
-	streams   map[int64]map[string]*ManagedStream
-	publisher models.ChannelPublisher
+	mu         sync.RWMutex
+	streams    map[int64]map[string]*ManagedStream
+	publisher  models.ChannelPublisher
+	frameCache FrameCache
+	history    *History
+	throttler  *Throttler
 }
 
-// NewRunner creates new Runner.
-func NewRunner(publisher models.ChannelPublisher) *Runner {
+// NewRunner creates new Runner. history and throttler are optional.
+func NewRunner(publisher models.ChannelPublisher, frameCache FrameCache, history *History, throttler *Throttler) *Runner {
 	return &Runner{
-		publisher: publisher,
-		streams:   map[int64]map[string]*ManagedStream{},
+		publisher:  publisher,
+		streams:    map[int64]map[string]*ManagedStream{},
+		frameCache: frameCache,
+		history:    history,
+		throttler:  throttler,
 	}
 }
 
+// ListChannels returns info for the UI about the channels of all the managed streams,
+// including the ones pushed to other Grafana servers sharing the frame cache.
+func (r *Runner) ListChannels(orgID int64) ([]util.DynMap, error) {
+	return listChannels(r.frameCache, orgID, live.ScopeStream+"/")
+}
+
 // Streams returns a map of active managed streams (per streamID).
 func (r *Runner) Streams(orgID int64) map[string]*ManagedStream {
 	r.mu.RLock()
@@ -58,7 +71,7 @@ func (r *Runner) GetOrCreateStream(orgID int64, streamID string) (*ManagedStream
 	}
 	s, ok := r.streams[orgID][streamID]
 	if !ok {
-		s = NewManagedStream(streamID, r.publisher)
+		s = NewManagedStream(streamID, r.publisher, r.frameCache, r.history, r.throttler)
 		r.streams[orgID][streamID] = s
 	}
 	return s, nil
@@ -66,40 +79,48 @@ func (r *Runner) GetOrCreateStream(orgID int64, streamID string) (*ManagedStream
 
 // ManagedStream holds the state of a managed stream.
 type ManagedStream struct {
-	mu        sync.RWMutex
-	id        string
-	start     time.Time
-	last      map[int64]map[string]data.FrameJSONCache
-	publisher models.ChannelPublisher
+	id         string
+	start      time.Time
+	publisher  models.ChannelPublisher
+	frameCache FrameCache
+	history    *History
+	throttler  *Throttler
 }
 
-// NewManagedStream creates new ManagedStream.
-func NewManagedStream(id string, publisher models.ChannelPublisher) *ManagedStream {
+// NewManagedStream creates new ManagedStream. history and throttler are optional.
+func NewManagedStream(id string, publisher models.ChannelPublisher, frameCache FrameCache, history *History, throttler *Throttler) *ManagedStream {
 	return &ManagedStream{
-		id:        id,
-		start:     time.Now(),
-		last:      map[int64]map[string]data.FrameJSONCache{},
-		publisher: publisher,
+		id:         id,
+		start:      time.Now(),
+		publisher:  publisher,
+		frameCache: frameCache,
+		history:    history,
+		throttler:  throttler,
 	}
 }
 
 // ListChannels returns info for the UI about this stream.
-func (s *ManagedStream) ListChannels(orgID int64, prefix string) []util.DynMap {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *ManagedStream) ListChannels(orgID int64) ([]util.DynMap, error) {
+	return listChannels(s.frameCache, orgID, live.ScopeStream+"/"+s.id+"/")
+}
 
-	if _, ok := s.last[orgID]; !ok {
-		return []util.DynMap{}
+// listChannels returns info for the UI about the cached channels starting with prefix.
+func listChannels(frameCache FrameCache, orgID int64, prefix string) ([]util.DynMap, error) {
+	channels, err := frameCache.GetActiveChannels(orgID)
+	if err != nil {
+		return nil, err
 	}
-
-	info := make([]util.DynMap, 0, len(s.last[orgID]))
-	for k, v := range s.last[orgID] {
+	info := make([]util.DynMap, 0, len(channels))
+	for k, v := range channels {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
 		ch := util.DynMap{}
-		ch["channel"] = prefix + k
-		ch["data"] = json.RawMessage(v.Bytes(data.IncludeSchemaOnly))
+		ch["channel"] = k
+		ch["data"] = v
 		info = append(info, ch)
 	}
-	return info
+	return info, nil
 }
 
 // Push sends frame to the stream and saves it for later retrieval by subscribers.
@@ -112,40 +133,57 @@ func (s *ManagedStream) Push(orgID int64, path string, frame *data.Frame) error
 		return err
 	}
 
-	s.mu.Lock()
-	if _, ok := s.last[orgID]; !ok {
-		s.last[orgID] = map[string]data.FrameJSONCache{}
+	// The channel this will be posted into.
+	channel := live.Channel{Scope: live.ScopeStream, Namespace: s.id, Path: path}.String()
+
+	isUnchangedSchema, err := s.frameCache.Update(orgID, channel, msg)
+	if err != nil {
+		logger.Error("Error updating managed stream schema", "error", err)
+		return err
+	}
+
+	if s.history != nil {
+		if err := s.history.Add(orgID, channel, frame); err != nil {
+			// The frame is still published.
+			logger.Error("Error adding frame to channel history", "error", err, "channel", channel)
+		}
+	}
+
+	if s.throttler != nil {
+		if opts := s.throttler.options(orgID, channel); opts.Interval > 0 {
+			return s.throttler.push(orgID, channel, frame, opts)
+		}
 	}
-	last, exists := s.last[orgID][path]
-	s.last[orgID][path] = msg
-	s.mu.Unlock()
 
 	include := data.IncludeAll
-	if exists && last.SameSchema(&msg) {
+	if isUnchangedSchema {
 		// When the schema has not changed, just send the data.
 		include = data.IncludeDataOnly
 	}
 	frameJSON := msg.Bytes(include)
 
-	// The channel this will be posted into.
-	channel := live.Channel{Scope: live.ScopeStream, Namespace: s.id, Path: path}.String()
 	logger.Debug("Publish data to channel", "channel", channel, "dataLength", len(frameJSON))
 	return s.publisher(orgID, channel, frameJSON)
 }
 
 // getLastPacket retrieves last packet channel.
-func (s *ManagedStream) getLastPacket(orgId int64, path string) (json.RawMessage, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	_, ok := s.last[orgId]
-	if !ok {
-		return nil, false
+func (s *ManagedStream) getLastPacket(orgId int64, path string) (json.RawMessage, bool, error) {
+	channel := live.Channel{Scope: live.ScopeStream, Namespace: s.id, Path: path}.String()
+	return s.frameCache.GetFrame(orgId, channel)
+}
+
+// getHistoryPacket retrieves a packet with the recent frames of the history of a channel.
+func (s *ManagedStream) getHistoryPacket(orgID int64, path string) (json.RawMessage, bool, error) {
+	channel := live.Channel{Scope: live.ScopeStream, Namespace: s.id, Path: path}.String()
+	frame, ok, err := s.history.Get(orgID, channel)
+	if err != nil || !ok {
+		return nil, false, err
 	}
-	msg, ok := s.last[orgId][path]
-	if ok {
-		return msg.Bytes(data.IncludeAll), ok
+	packet, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, false, err
 	}
-	return nil, ok
+	return packet, true, nil
 }
 
 func (s *ManagedStream) GetHandlerForPath(_ string) (models.ChannelHandler, error) {
@@ -154,7 +192,19 @@ func (s *ManagedStream) GetHandlerForPath(_ string) (models.ChannelHandler, erro
 
 func (s *ManagedStream) OnSubscribe(_ context.Context, u *models.SignedInUser, e models.SubscribeEvent) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
 	reply := models.SubscribeReply{}
-	packet, ok := s.getLastPacket(u.OrgId, e.Path)
+	if s.history != nil {
+		packet, ok, err := s.getHistoryPacket(u.OrgId, e.Path)
+		if err != nil {
+			logger.Error("Error getting channel history, sending the last packet", "error", err, "path", e.Path)
+		} else if ok {
+			reply.Data = packet
+			return reply, backend.SubscribeStreamStatusOK, nil
+		}
+	}
+	packet, ok, err := s.getLastPacket(u.OrgId, e.Path)
+	if err != nil {
+		return models.SubscribeReply{}, 0, err
+	}
 	if ok {
 		reply.Data = packet
 	}
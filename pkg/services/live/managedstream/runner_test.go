@@ -19,21 +19,52 @@ func (p *testPublisher) publish(orgID int64, _ string, _ []byte) error {
 
 func TestNewManagedStream(t *testing.T) {
 	publisher := &testPublisher{orgID: 1, t: t}
-	c := NewManagedStream("a", publisher.publish)
+	c := NewManagedStream("a", publisher.publish, NewMemoryFrameCache(), nil, nil)
 	require.NotNil(t, c)
 }
 
 func TestManagedStream_GetLastPacket(t *testing.T) {
 	var orgID int64 = 1
 	publisher := &testPublisher{orgID: orgID, t: t}
-	c := NewManagedStream("a", publisher.publish)
-	_, ok := c.getLastPacket(orgID, "test")
+	c := NewManagedStream("a", publisher.publish, NewMemoryFrameCache(), nil, nil)
+	_, ok, err := c.getLastPacket(orgID, "test")
+	require.NoError(t, err)
 	require.False(t, ok)
-	err := c.Push(orgID, "test", data.NewFrame("hello"))
+	err = c.Push(orgID, "test", data.NewFrame("hello"))
+	require.NoError(t, err)
+
+	s, ok, err := c.getLastPacket(orgID, "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.JSONEq(t, `{"schema":{"name":"hello","fields":[]},"data":{"values":[]}}`, string(s))
+}
+
+func TestManagedStream_SharedFrameCache(t *testing.T) {
+	var orgID int64 = 1
+	frameCache := NewMemoryFrameCache()
+	a := NewRunner((&testPublisher{orgID: orgID, t: t}).publish, frameCache, nil, nil)
+	b := NewRunner((&testPublisher{orgID: orgID, t: t}).publish, frameCache, nil, nil)
+
+	streamA, err := a.GetOrCreateStream(orgID, "a")
 	require.NoError(t, err)
+	require.NoError(t, streamA.Push(orgID, "test", data.NewFrame("hello")))
 
-	s, ok := c.getLastPacket(orgID, "test")
+	// A stream of another runner sharing the frame cache gets the last packet.
+	streamB, err := b.GetOrCreateStream(orgID, "a")
+	require.NoError(t, err)
+	s, ok, err := streamB.getLastPacket(orgID, "test")
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.JSONEq(t, `{"schema":{"name":"hello","fields":[]},"data":{"values":[]}}`, string(s))
+
+	channels, err := b.ListChannels(orgID)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	require.Equal(t, "stream/a/test", channels[0]["channel"])
+
+	other, err := b.GetOrCreateStream(orgID, "b")
+	require.NoError(t, err)
+	channels, err = other.ListChannels(orgID)
+	require.NoError(t, err)
+	require.Empty(t, channels)
 }
@@ -0,0 +1,344 @@
+package managedstream
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Aggregations of the frames pushed to a throttled channel between two publications.
+const (
+	// AggregationLatest publishes the latest frame, the others are dropped.
+	AggregationLatest = "latest"
+	// AggregationMean publishes a single row with the mean of the numeric fields of the frames,
+	// and the latest value of the other fields.
+	AggregationMean = "mean"
+)
+
+// throttleSweepInterval is the minimum interval between two removals of the idle channels of a Throttler.
+const throttleSweepInterval = time.Minute
+
+// ThrottleOptions configures how the publications of a channel to its subscribers are throttled.
+type ThrottleOptions struct {
+	// Interval is the minimum interval between two publications, 0 disables the throttling.
+	Interval time.Duration
+	// Aggregation is how the frames pushed between two publications are combined.
+	Aggregation string
+}
+
+// ThrottleOptionsGetter returns the throttle options of a channel.
+type ThrottleOptionsGetter func(orgID int64, channel string) (ThrottleOptions, error)
+
+// ValidateAggregation returns an error if the aggregation is not supported.
+func ValidateAggregation(aggregation string) error {
+	switch aggregation {
+	case AggregationLatest, AggregationMean:
+		return nil
+	default:
+		return fmt.Errorf("unsupported aggregation %q", aggregation)
+	}
+}
+
+// Throttler limits the rate at which the frames pushed to the channels of the managed streams are
+// published to their subscribers. A frame pushed at least an interval after the previous publication
+// of its channel is published right away, the frames pushed sooner are combined and published at
+// the end of the interval. The frame cache and the history still get all the frames.
+type Throttler struct {
+	publisher  models.ChannelPublisher
+	getOptions ThrottleOptionsGetter
+	now        func() time.Time
+
+	mu        sync.Mutex
+	channels  map[throttleKey]*throttledChannel
+	lastSweep time.Time
+	// stopped is set when the throttler is stopped, the frames are then published right away.
+	stopped bool
+
+	dropped    prometheus.Counter
+	aggregated prometheus.Counter
+}
+
+// ThrottlerMetrics are the metrics of the throttlers.
+type ThrottlerMetrics struct {
+	dropped    prometheus.Counter
+	aggregated prometheus.Counter
+}
+
+// NewThrottlerMetrics creates the metrics of the throttlers registered with r. A registerer accepts
+// the metrics once, they must be created once and shared by the throttlers using the same registerer.
+func NewThrottlerMetrics(r prometheus.Registerer) *ThrottlerMetrics {
+	return &ThrottlerMetrics{
+		dropped: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana_live",
+			Subsystem: "managed_stream",
+			Name:      "frames_dropped_total",
+			Help:      "The total number of frames pushed to throttled channels which were not published to the subscribers.",
+		}),
+		aggregated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana_live",
+			Subsystem: "managed_stream",
+			Name:      "frames_aggregated_total",
+			Help:      "The total number of frames pushed to throttled channels which were published as part of an aggregate.",
+		}),
+	}
+}
+
+type throttleKey struct {
+	orgID   int64
+	channel string
+}
+
+type throttledChannel struct {
+	lastPublish time.Time
+	// interval is the interval of the channel at its last push.
+	interval time.Duration
+	// pending combines the frames pushed since the last publication, nil when there are none.
+	pending *frameAggregate
+	// timer publishes the pending frames at the end of the interval.
+	timer *time.Timer
+}
+
+// NewThrottler creates new Throttler publishing with publisher, and counting the frames with metrics.
+func NewThrottler(publisher models.ChannelPublisher, getOptions ThrottleOptionsGetter, metrics *ThrottlerMetrics) *Throttler {
+	return &Throttler{
+		publisher:  publisher,
+		getOptions: getOptions,
+		now:        time.Now,
+		channels:   map[throttleKey]*throttledChannel{},
+		dropped:    metrics.dropped,
+		aggregated: metrics.aggregated,
+	}
+}
+
+// options returns the throttle options of a channel. The channel is not throttled if they can not be read.
+func (t *Throttler) options(orgID int64, channel string) ThrottleOptions {
+	opts, err := t.getOptions(orgID, channel)
+	if err != nil {
+		logger.Error("Error getting channel throttle options, the channel is not throttled", "error", err, "channel", channel)
+		return ThrottleOptions{}
+	}
+	return opts
+}
+
+// push publishes the frame, or keeps it until the end of the interval of the channel.
+func (t *Throttler) push(orgID int64, channel string, frame *data.Frame, opts ThrottleOptions) error {
+	key := throttleKey{orgID: orgID, channel: channel}
+	now := t.now()
+
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return t.publish(orgID, channel, frame)
+	}
+	t.sweep(now)
+	ch, ok := t.channels[key]
+	if !ok {
+		ch = &throttledChannel{}
+		t.channels[key] = ch
+	}
+	ch.interval = opts.Interval
+	if ch.timer == nil && now.Sub(ch.lastPublish) >= opts.Interval {
+		ch.lastPublish = now
+		t.mu.Unlock()
+		return t.publish(orgID, channel, frame)
+	}
+	if ch.pending == nil {
+		ch.pending = newFrameAggregate(opts.Aggregation)
+	}
+	t.dropped.Add(float64(ch.pending.add(frame)))
+	if ch.timer == nil {
+		ch.timer = time.AfterFunc(ch.lastPublish.Add(opts.Interval).Sub(now), func() {
+			t.flush(key)
+		})
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Run stops the throttler when the context is done: the pending timers are stopped and their frames
+// are dropped, the frames pushed afterwards are published right away.
+func (t *Throttler) Run(ctx context.Context) error {
+	<-ctx.Done()
+	t.stop()
+	return ctx.Err()
+}
+
+func (t *Throttler) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	for key, ch := range t.channels {
+		if ch.timer != nil {
+			ch.timer.Stop()
+		}
+		if ch.pending != nil {
+			t.dropped.Add(float64(ch.pending.count))
+		}
+		delete(t.channels, key)
+	}
+}
+
+// sweep removes the channels which were not published for a full interval and have no pending frames,
+// the next frame pushed to them would be published right away anyway. t.mu must be held.
+func (t *Throttler) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < throttleSweepInterval {
+		return
+	}
+	for key, ch := range t.channels {
+		if ch.timer == nil && ch.pending == nil && now.Sub(ch.lastPublish) >= ch.interval {
+			delete(t.channels, key)
+		}
+	}
+	t.lastSweep = now
+}
+
+// flush publishes the frames of a channel pushed during the interval.
+func (t *Throttler) flush(key throttleKey) {
+	t.mu.Lock()
+	ch, ok := t.channels[key]
+	if !ok || ch.pending == nil {
+		t.mu.Unlock()
+		return
+	}
+	pending := ch.pending
+	ch.pending = nil
+	ch.timer = nil
+	ch.lastPublish = t.now()
+	t.mu.Unlock()
+
+	if pending.aggregation == AggregationMean {
+		t.aggregated.Add(float64(pending.count))
+	}
+	if err := t.publish(key.orgID, key.channel, pending.frame()); err != nil {
+		logger.Error("Error publishing throttled frames", "error", err, "channel", key.channel)
+	}
+}
+
+// publish sends the frame with its schema, the subscribers may have missed the
+// frames changing the schema since the last publication.
+func (t *Throttler) publish(orgID int64, channel string, frame *data.Frame) error {
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Publish throttled data to channel", "channel", channel, "dataLength", len(frameJSON))
+	return t.publisher(orgID, channel, frameJSON)
+}
+
+// frameAggregate combines the frames pushed to a channel between two publications.
+type frameAggregate struct {
+	aggregation string
+	latest      *data.Frame
+	// count is the number of frames combined in the aggregate.
+	count int
+	// sums and counts are the sum and number of the values of the numeric fields
+	// of the frames, used by the mean aggregation.
+	sums   []float64
+	counts []int
+}
+
+func newFrameAggregate(aggregation string) *frameAggregate {
+	return &frameAggregate{aggregation: aggregation}
+}
+
+// add adds a frame to the aggregate, and returns the number of frames dropped from it.
+func (a *frameAggregate) add(frame *data.Frame) int {
+	dropped := 0
+	if a.aggregation != AggregationMean || a.latest == nil || !sameSchema(a.latest, frame) {
+		// The frames with another schema can not be combined.
+		dropped = a.count
+		a.count = 0
+		a.sums = make([]float64, len(frame.Fields))
+		a.counts = make([]int, len(frame.Fields))
+	}
+	a.latest = frame
+	a.count++
+	if a.aggregation != AggregationMean {
+		return dropped
+	}
+	for i, f := range frame.Fields {
+		if !f.Type().Numeric() {
+			continue
+		}
+		for row := 0; row < f.Len(); row++ {
+			v, err := f.NullableFloatAt(row)
+			if err != nil || v == nil || math.IsNaN(*v) {
+				continue
+			}
+			a.sums[i] += *v
+			a.counts[i]++
+		}
+	}
+	return dropped
+}
+
+// frame returns the frame to publish. The mean aggregation returns a single row with the mean of the
+// numeric fields, rounded for the integer fields, and the last value of the other fields such as the time.
+func (a *frameAggregate) frame() *data.Frame {
+	if a.aggregation != AggregationMean {
+		return a.latest
+	}
+	rows, ok := frameRows(a.latest)
+	if !ok || rows == 0 {
+		return a.latest
+	}
+
+	fields := make([]*data.Field, 0, len(a.latest.Fields))
+	for i, f := range a.latest.Fields {
+		field := data.NewFieldFromFieldType(f.Type(), 1)
+		field.Name = f.Name
+		field.Labels = f.Labels
+		field.Config = f.Config
+		switch {
+		case !f.Type().Numeric():
+			field.Set(0, f.CopyAt(rows-1))
+		case a.counts[i] > 0:
+			field.SetConcrete(0, numericValue(f.Type(), a.sums[i]/float64(a.counts[i])))
+		case !f.Nullable():
+			// only the values of the float fields can all be skipped, as NaN
+			field.SetConcrete(0, numericValue(f.Type(), math.NaN()))
+		}
+		fields = append(fields, field)
+	}
+	frame := data.NewFrame(a.latest.Name, fields...)
+	frame.Meta = a.latest.Meta
+	return frame
+}
+
+// numericValue converts a value to the concrete type of a numeric field, the value is rounded for the integer types.
+func numericValue(fieldType data.FieldType, v float64) interface{} {
+	switch fieldType.NonNullableType() {
+	case data.FieldTypeFloat64:
+		return v
+	case data.FieldTypeFloat32:
+		return float32(v)
+	}
+	v = math.Round(v)
+	switch fieldType.NonNullableType() {
+	case data.FieldTypeInt8:
+		return int8(v)
+	case data.FieldTypeInt16:
+		return int16(v)
+	case data.FieldTypeInt32:
+		return int32(v)
+	case data.FieldTypeInt64:
+		return int64(v)
+	case data.FieldTypeUint8:
+		return uint8(v)
+	case data.FieldTypeUint16:
+		return uint16(v)
+	case data.FieldTypeUint32:
+		return uint32(v)
+	case data.FieldTypeUint64:
+		return uint64(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,239 @@
+package managedstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	mu      sync.Mutex
+	packets []string
+}
+
+func (p *recordingPublisher) publish(_ int64, _ string, b []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.packets = append(p.packets, string(b))
+	return nil
+}
+
+func (p *recordingPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.packets...)
+}
+
+// newTestThrottler returns a Throttler with the same options for all the channels. The tests use
+// an interval long enough for the timers not to fire, and flush the channels explicitly.
+func newTestThrottler(publisher *recordingPublisher, opts ThrottleOptions) *Throttler {
+	return NewThrottler(publisher.publish, func(int64, string) (ThrottleOptions, error) {
+		return opts, nil
+	}, NewThrottlerMetrics(prometheus.NewRegistry()))
+}
+
+func testThrottleFrame(name string, sec int64, values ...float64) *data.Frame {
+	times := make([]time.Time, len(values))
+	for i := range values {
+		times[i] = time.Unix(sec, 0).UTC()
+	}
+	return data.NewFrame(name,
+		data.NewField("time", nil, times),
+		data.NewField("value", nil, values))
+}
+
+func frameJSON(t *testing.T, frame *data.Frame) string {
+	t.Helper()
+	b, err := data.FrameToJSON(frame, data.IncludeAll)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestThrottler_Latest(t *testing.T) {
+	publisher := &recordingPublisher{}
+	opts := ThrottleOptions{Interval: time.Hour, Aggregation: AggregationLatest}
+	th := newTestThrottler(publisher, opts)
+	now := time.Unix(1000, 0)
+	th.now = func() time.Time { return now }
+	key := throttleKey{orgID: 1, channel: "stream/a/test"}
+
+	// The first frame is published right away.
+	require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", 1, 1), opts))
+	require.Len(t, publisher.published(), 1)
+
+	for i := int64(2); i <= 4; i++ {
+		require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", i, float64(i)), opts))
+	}
+	require.Len(t, publisher.published(), 1)
+
+	th.flush(key)
+	packets := publisher.published()
+	require.Len(t, packets, 2)
+	require.JSONEq(t, frameJSON(t, testThrottleFrame("test", 4, 4)), packets[1])
+	require.Equal(t, float64(2), testutil.ToFloat64(th.dropped))
+	require.Equal(t, float64(0), testutil.ToFloat64(th.aggregated))
+
+	// Nothing is published at the end of an interval without frames.
+	th.flush(key)
+	require.Len(t, publisher.published(), 2)
+
+	// A frame pushed an interval after the last publication is published right away.
+	now = now.Add(time.Hour)
+	require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", 5, 5), opts))
+	require.Len(t, publisher.published(), 3)
+}
+
+func TestThrottler_Mean(t *testing.T) {
+	publisher := &recordingPublisher{}
+	opts := ThrottleOptions{Interval: time.Hour, Aggregation: AggregationMean}
+	th := newTestThrottler(publisher, opts)
+	th.now = func() time.Time { return time.Unix(1000, 0) }
+	key := throttleKey{orgID: 1, channel: "stream/a/test"}
+
+	require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", 1, 1), opts))
+
+	t.Run("numeric fields are averaged", func(t *testing.T) {
+		require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", 2, 2, 4), opts))
+		require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", 3, 6), opts))
+		th.flush(key)
+
+		packets := publisher.published()
+		require.Len(t, packets, 2)
+		expected := data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{time.Unix(3, 0).UTC()}),
+			data.NewField("value", nil, []float64{4}))
+		require.JSONEq(t, frameJSON(t, expected), packets[1])
+		require.Equal(t, float64(2), testutil.ToFloat64(th.aggregated))
+	})
+
+	t.Run("frames with another schema are dropped", func(t *testing.T) {
+		require.NoError(t, th.push(1, key.channel, testThrottleFrame("test", 4, 10), opts))
+		require.NoError(t, th.push(1, key.channel, testThrottleFrame("other", 5, 20), opts))
+		th.flush(key)
+
+		packets := publisher.published()
+		require.Len(t, packets, 3)
+		expected := data.NewFrame("other",
+			data.NewField("time", nil, []time.Time{time.Unix(5, 0).UTC()}),
+			data.NewField("value", nil, []float64{20}))
+		require.JSONEq(t, frameJSON(t, expected), packets[2])
+		require.Equal(t, float64(1), testutil.ToFloat64(th.dropped))
+		require.Equal(t, float64(3), testutil.ToFloat64(th.aggregated))
+	})
+}
+
+func TestFrameAggregate_MeanFieldTypes(t *testing.T) {
+	newFrame := func(i int64, u uint8, n *int32, f float32) *data.Frame {
+		return data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{time.Unix(i, 0).UTC()}),
+			data.NewField("int", nil, []int64{i}),
+			data.NewField("uint", nil, []uint8{u}),
+			data.NewField("nullable", nil, []*int32{n}),
+			data.NewField("float", nil, []float32{f}),
+			data.NewField("none", nil, []*float64{nil}))
+	}
+	a := newFrameAggregate(AggregationMean)
+	one, two := int32(1), int32(2)
+	require.Equal(t, 0, a.add(newFrame(1, 10, &one, 0.5)))
+	require.Equal(t, 0, a.add(newFrame(2, 15, nil, 1)))
+	require.Equal(t, 0, a.add(newFrame(4, 20, &two, 1.5)))
+
+	// the means keep the type of the fields, the integers are rounded
+	mean := int32(2)
+	expected := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(4, 0).UTC()}),
+		data.NewField("int", nil, []int64{2}),
+		data.NewField("uint", nil, []uint8{15}),
+		data.NewField("nullable", nil, []*int32{&mean}),
+		data.NewField("float", nil, []float32{1}),
+		data.NewField("none", nil, []*float64{nil}))
+	require.JSONEq(t, frameJSON(t, expected), frameJSON(t, a.frame()))
+}
+
+func TestThrottler_Run(t *testing.T) {
+	publisher := &recordingPublisher{}
+	opts := ThrottleOptions{Interval: time.Hour, Aggregation: AggregationLatest}
+	th := newTestThrottler(publisher, opts)
+
+	require.NoError(t, th.push(1, "stream/a/test", testThrottleFrame("test", 1, 1), opts))
+	require.NoError(t, th.push(1, "stream/a/test", testThrottleFrame("test", 2, 2), opts))
+	timer := th.channels[throttleKey{orgID: 1, channel: "stream/a/test"}].timer
+	require.NotNil(t, timer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, th.Run(ctx), context.Canceled)
+
+	// the pending timers are stopped and their frames dropped
+	require.False(t, timer.Stop())
+	require.Empty(t, th.channels)
+	require.Equal(t, float64(1), testutil.ToFloat64(th.dropped))
+
+	// the frames pushed afterwards are published right away
+	require.NoError(t, th.push(1, "stream/a/test", testThrottleFrame("test", 3, 3), opts))
+	require.Len(t, publisher.published(), 2)
+	require.Empty(t, th.channels)
+}
+
+func TestThrottler_Sweep(t *testing.T) {
+	publisher := &recordingPublisher{}
+	opts := ThrottleOptions{Interval: time.Hour, Aggregation: AggregationLatest}
+	th := newTestThrottler(publisher, opts)
+	now := time.Unix(1000, 0)
+	th.now = func() time.Time { return now }
+
+	require.NoError(t, th.push(1, "stream/a/idle", testThrottleFrame("test", 1, 1), opts))
+	require.NoError(t, th.push(1, "stream/a/pending", testThrottleFrame("test", 1, 1), opts))
+	require.NoError(t, th.push(1, "stream/a/pending", testThrottleFrame("test", 2, 2), opts))
+	require.Len(t, th.channels, 2)
+
+	// The channels published less than an interval ago are kept.
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, th.push(1, "stream/a/other", testThrottleFrame("test", 1, 1), opts))
+	require.Len(t, th.channels, 3)
+
+	// The idle channels are removed, the channels with pending frames are kept.
+	now = now.Add(time.Hour)
+	require.NoError(t, th.push(1, "stream/a/new", testThrottleFrame("test", 1, 1), opts))
+	require.Len(t, th.channels, 2)
+	require.Contains(t, th.channels, throttleKey{orgID: 1, channel: "stream/a/pending"})
+	require.Contains(t, th.channels, throttleKey{orgID: 1, channel: "stream/a/new"})
+	th.channels[throttleKey{orgID: 1, channel: "stream/a/pending"}].timer.Stop()
+}
+
+func TestManagedStream_PushThrottled(t *testing.T) {
+	publisher := &recordingPublisher{}
+	th := NewThrottler(publisher.publish, func(_ int64, channel string) (ThrottleOptions, error) {
+		switch channel {
+		case "stream/a/throttled":
+			return ThrottleOptions{Interval: time.Hour, Aggregation: AggregationLatest}, nil
+		case "stream/a/error":
+			return ThrottleOptions{}, errors.New("no options")
+		default:
+			return ThrottleOptions{}, nil
+		}
+	}, NewThrottlerMetrics(prometheus.NewRegistry()))
+	frameCache := NewMemoryFrameCache()
+	s := NewManagedStream("a", publisher.publish, frameCache, nil, th)
+
+	for _, path := range []string{"throttled", "other", "error"} {
+		for i := int64(1); i <= 3; i++ {
+			require.NoError(t, s.Push(1, path, testThrottleFrame("test", i, float64(i))))
+		}
+	}
+	// The throttled channel publishes its first frame, the others publish all their frames.
+	require.Len(t, publisher.published(), 7)
+
+	// The frame cache gets all the frames of the throttled channel.
+	packet, ok, err := s.getLastPacket(1, "throttled")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.JSONEq(t, frameJSON(t, testThrottleFrame("test", 3, 3)), string(packet))
+}
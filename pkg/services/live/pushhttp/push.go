@@ -12,6 +12,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 	"github.com/grafana/grafana/pkg/setting"
+
+	sdklive "github.com/grafana/grafana-plugin-sdk-go/live"
 )
 
 var (
@@ -56,7 +58,7 @@ func (g *Gateway) Handle(ctx *models.ReqContext) {
 
 	// TODO Grafana 8: decide which formats to use or keep all.
 	urlValues := ctx.Req.URL.Query()
-	frameFormat := pushurl.FrameFormatFromValues(urlValues)
+	convertOptions := pushurl.ConvertOptionsFromValues(urlValues, ctx.Req.Header.Get("Content-Type"))
 
 	body, err := ctx.Req.Body().Bytes()
 	if err != nil {
@@ -68,13 +70,14 @@ func (g *Gateway) Handle(ctx *models.ReqContext) {
 		"protocol", "http",
 		"streamId", streamID,
 		"bodyLength", len(body),
-		"frameFormat", frameFormat,
+		"inputFormat", convertOptions.InputFormat,
+		"frameFormat", convertOptions.FrameFormat,
 	)
 
-	metricFrames, err := g.converter.Convert(body, frameFormat)
+	metricFrames, err := g.converter.Convert(body, convertOptions)
 	if err != nil {
-		logger.Error("Error converting metrics", "error", err, "frameFormat", frameFormat)
-		if errors.Is(err, convert.ErrUnsupportedFrameFormat) {
+		logger.Error("Error converting metrics", "error", err, "inputFormat", convertOptions.InputFormat, "frameFormat", convertOptions.FrameFormat)
+		if errors.Is(err, convert.ErrUnsupportedFrameFormat) || errors.Is(err, convert.ErrUnsupportedInputFormat) {
 			ctx.Resp.WriteHeader(http.StatusBadRequest)
 		} else {
 			ctx.Resp.WriteHeader(http.StatusInternalServerError)
@@ -85,6 +88,22 @@ func (g *Gateway) Handle(ctx *models.ReqContext) {
 	// TODO -- make sure all packets are combined together!
 	// interval = "1s" vs flush_interval = "5s"
 
+	// every channel is authorized before anything is pushed, so that a denied request pushes nothing
+	for _, mf := range metricFrames {
+		channel := sdklive.Channel{Scope: sdklive.ScopeStream, Namespace: streamID, Path: mf.Key()}.String()
+		allowed, err := g.GrafanaLive.ChannelRules.CanPublish(ctx.Req.Context(), ctx.SignedInUser, channel)
+		if err != nil {
+			logger.Error("Error checking channel rules", "error", err, "channel", channel)
+			ctx.Resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			logger.Info("Push denied by channel rule", "user", ctx.SignedInUser.UserId, "channel", channel)
+			ctx.Resp.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
 	for _, mf := range metricFrames {
 		err := stream.Push(ctx.SignedInUser.OrgId, mf.Key(), mf.Frame())
 		if err != nil {
@@ -1,12 +1,20 @@
 package pushurl
 
 import (
+	"mime"
 	"net/url"
 	"strings"
+
+	"github.com/grafana/grafana/pkg/services/live/convert"
 )
 
 const (
-	frameFormatParam = "gf_live_frame_format"
+	frameFormatParam     = "gf_live_frame_format"
+	inputFormatParam     = "gf_live_input_format"
+	jsonNameParam        = "gf_live_json_name"
+	jsonTimeFieldParam   = "gf_live_json_time_field"
+	jsonValueFieldsParam = "gf_live_json_value_fields"
+	jsonLabelFieldsParam = "gf_live_json_label_fields"
 )
 
 // FrameFormatFromValues extracts frame format tip from url values.
@@ -17,3 +25,53 @@ func FrameFormatFromValues(values url.Values) string {
 	}
 	return frameFormat
 }
+
+// InputFormatFromValues extracts input format from url values, or guesses it
+// from the content type of the pushed data when there is none.
+func InputFormatFromValues(values url.Values, contentType string) string {
+	if inputFormat := strings.ToLower(values.Get(inputFormatParam)); inputFormat != "" {
+		return inputFormat
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return convert.InputFormatInflux
+	}
+	switch {
+	case mediaType == "application/json":
+		return convert.InputFormatJSON
+	case mediaType == "text/plain" && params["version"] == "0.0.4":
+		// The content type of the Prometheus text exposition format.
+		return convert.InputFormatPrometheus
+	default:
+		return convert.InputFormatInflux
+	}
+}
+
+// JSONMappingFromValues extracts the mapping of JSON documents from url values.
+func JSONMappingFromValues(values url.Values) convert.JSONMapping {
+	return convert.JSONMapping{
+		Name:        values.Get(jsonNameParam),
+		TimeField:   values.Get(jsonTimeFieldParam),
+		ValueFields: splitList(values.Get(jsonValueFieldsParam)),
+		LabelFields: splitList(values.Get(jsonLabelFieldsParam)),
+	}
+}
+
+// ConvertOptionsFromValues extracts the options of the conversion of the pushed data from url values.
+func ConvertOptionsFromValues(values url.Values, contentType string) convert.Options {
+	return convert.Options{
+		InputFormat: InputFormatFromValues(values, contentType),
+		FrameFormat: FrameFormatFromValues(values),
+		JSONMapping: JSONMappingFromValues(values),
+	}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
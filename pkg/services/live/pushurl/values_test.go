@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,3 +14,28 @@ func TestFrameFormatFromValues(t *testing.T) {
 	values.Set(frameFormatParam, "wide")
 	require.Equal(t, "wide", FrameFormatFromValues(values))
 }
+
+func TestInputFormatFromValues(t *testing.T) {
+	values := url.Values{}
+	require.Equal(t, "influx", InputFormatFromValues(values, ""))
+	require.Equal(t, "influx", InputFormatFromValues(values, "text/plain; charset=utf-8"))
+	require.Equal(t, "json", InputFormatFromValues(values, "application/json; charset=utf-8"))
+	require.Equal(t, "prometheus", InputFormatFromValues(values, "text/plain; version=0.0.4"))
+	values.Set(inputFormatParam, "Frame")
+	require.Equal(t, "frame", InputFormatFromValues(values, "application/json"))
+}
+
+func TestJSONMappingFromValues(t *testing.T) {
+	values := url.Values{}
+	require.Equal(t, convert.JSONMapping{}, JSONMappingFromValues(values))
+	values.Set(jsonNameParam, "sensors")
+	values.Set(jsonTimeFieldParam, "ts")
+	values.Set(jsonValueFieldsParam, "temperature, humidity,")
+	values.Set(jsonLabelFieldsParam, "device.id")
+	require.Equal(t, convert.JSONMapping{
+		Name:        "sensors",
+		TimeField:   "ts",
+		ValueFields: []string{"temperature", "humidity"},
+		LabelFields: []string{"device.id"},
+	}, JSONMappingFromValues(values))
+}
@@ -8,12 +8,14 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/channelrule"
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 
 	"github.com/gorilla/websocket"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
 )
 
 var (
@@ -23,6 +25,7 @@ var (
 // Handler handles WebSocket client connections that push data to Live.
 type Handler struct {
 	managedStreamRunner *managedstream.Runner
+	channelRules        *channelrule.Checker
 	config              Config
 	upgrade             *websocket.Upgrader
 	converter           *convert.Converter
@@ -52,7 +55,7 @@ type Config struct {
 }
 
 // NewHandler creates new Handler.
-func NewHandler(managedStreamRunner *managedstream.Runner, c Config) *Handler {
+func NewHandler(managedStreamRunner *managedstream.Runner, channelRules *channelrule.Checker, c Config) *Handler {
 	if c.CheckOrigin == nil {
 		c.CheckOrigin = sameHostOriginCheck()
 	}
@@ -63,6 +66,7 @@ func NewHandler(managedStreamRunner *managedstream.Runner, c Config) *Handler {
 	}
 	return &Handler{
 		managedStreamRunner: managedStreamRunner,
+		channelRules:        channelRules,
 		config:              c,
 		upgrade:             upgrade,
 		converter:           convert.NewConverter(),
@@ -173,23 +177,38 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 
 		// TODO Grafana 8: decide which formats to use or keep all.
 		urlValues := r.URL.Query()
-		frameFormat := pushurl.FrameFormatFromValues(urlValues)
+		convertOptions := pushurl.ConvertOptionsFromValues(urlValues, r.Header.Get("Content-Type"))
 
 		logger.Debug("Live Push request",
 			"protocol", "http",
 			"streamId", streamID,
 			"bodyLength", len(body),
-			"frameFormat", frameFormat,
+			"inputFormat", convertOptions.InputFormat,
+			"frameFormat", convertOptions.FrameFormat,
 		)
 
-		metricFrames, err := s.converter.Convert(body, frameFormat)
+		metricFrames, err := s.converter.Convert(body, convertOptions)
 		if err != nil {
-			logger.Error("Error converting metrics", "error", err, "frameFormat", frameFormat)
+			logger.Error("Error converting metrics", "error", err, "inputFormat", convertOptions.InputFormat, "frameFormat", convertOptions.FrameFormat)
 			continue
 		}
 
+		// every channel is authorized before anything is pushed, so that a denied message pushes nothing
 		for _, mf := range metricFrames {
-			err := stream.Push(user.OrgId, mf.Key(), mf.Frame())
+			channel := live.Channel{Scope: live.ScopeStream, Namespace: streamID, Path: mf.Key()}.String()
+			allowed, err := s.channelRules.CanPublish(r.Context(), user, channel)
+			if err != nil {
+				logger.Error("Error checking channel rules", "error", err, "channel", channel)
+				return
+			}
+			if !allowed {
+				logger.Info("Push denied by channel rule", "user", user.UserId, "channel", channel)
+				return
+			}
+		}
+
+		for _, mf := range metricFrames {
+			err = stream.Push(user.OrgId, mf.Key(), mf.Frame())
 			if err != nil {
 				return
 			}
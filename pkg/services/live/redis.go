@@ -0,0 +1,57 @@
+package live
+
+import (
+	"fmt"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	redis "gopkg.in/redis.v5"
+)
+
+// redisPrefix is the prefix of the Redis keys of Grafana Live.
+const redisPrefix = "gf_live"
+
+// setupRedisEngine makes the node use Redis as its broker and presence manager, so that
+// publications and presence are shared by all the Grafana servers using the same Redis,
+// and returns a frame cache sharing the last frames of the managed streams the same way with
+// the Redis client it uses. The client must be closed when Grafana Live stops.
+func setupRedisEngine(node *centrifuge.Node, connStr string) (managedstream.FrameCache, *redis.Client, error) {
+	opts, err := remotecache.ParseRedisConnStr(connStr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid [live] ha_engine_connstr: %w", err)
+	}
+
+	redisShard, err := centrifuge.NewRedisShard(node, centrifuge.RedisShardConfig{
+		Address:   opts.Addr,
+		Password:  opts.Password,
+		DB:        opts.DB,
+		UseTLS:    opts.TLSConfig != nil,
+		TLSConfig: opts.TLSConfig,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to Live Redis: %w", err)
+	}
+	shards := []*centrifuge.RedisShard{redisShard}
+
+	broker, err := centrifuge.NewRedisBroker(node, centrifuge.RedisBrokerConfig{
+		Prefix: redisPrefix,
+		Shards: shards,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Live Redis broker: %w", err)
+	}
+	node.SetBroker(broker)
+
+	presenceManager, err := centrifuge.NewRedisPresenceManager(node, centrifuge.RedisPresenceManagerConfig{
+		Prefix: redisPrefix,
+		Shards: shards,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Live Redis presence manager: %w", err)
+	}
+	node.SetPresenceManager(presenceManager)
+
+	client := redis.NewClient(opts)
+	return managedstream.NewRedisFrameCache(client, redisPrefix), client, nil
+}
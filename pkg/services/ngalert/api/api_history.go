@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const defaultStateHistoryLimit = 100
+
+type HistorySrv struct {
+	cfg   *setting.Cfg
+	store store.StateHistoryStore
+	log   log.Logger
+}
+
+func (srv HistorySrv) RouteGetStateHistory(c *models.ReqContext) response.Response {
+	if !srv.cfg.StateHistoryEnabled {
+		return ErrResp(http.StatusNotFound, fmt.Errorf("state history is not enabled"), "")
+	}
+
+	labels, err := parseStateHistoryLabels(c.QueryStrings("labels"))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	limit := c.QueryInt64("limit")
+	if limit <= 0 {
+		limit = defaultStateHistoryLimit
+	}
+
+	q := ngmodels.ListAlertStateHistoryQuery{
+		RuleOrgID: c.SignedInUser.OrgId,
+		RuleUID:   c.Query("ruleUID"),
+		Labels:    labels,
+		Limit:     limit,
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		q.From = time.Unix(0, from*int64(time.Millisecond))
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		q.To = time.Unix(0, to*int64(time.Millisecond))
+	}
+
+	if err := srv.store.ListAlertStateHistory(&q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get state history")
+	}
+
+	result := make(apimodels.GettableStateHistory, 0, len(q.Result))
+	for _, t := range q.Result {
+		result = append(result, apimodels.GettableStateTransition{
+			RuleUID:          t.RuleUID,
+			Labels:           map[string]string(t.Labels),
+			PreviousState:    string(t.PreviousState),
+			State:            string(t.State),
+			EvaluationString: t.EvaluationString,
+			Error:            t.Error,
+			EvaluatedAt:      t.EvaluatedAt,
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// parseStateHistoryLabels parses a list of name=value pairs into a label set.
+func parseStateHistoryLabels(pairs []string) (ngmodels.InstanceLabels, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	labels := make(ngmodels.InstanceLabels, len(pairs))
+	for _, p := range pairs {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label %q: expected name=value", p)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
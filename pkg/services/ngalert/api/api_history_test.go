@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeStateHistoryStore struct {
+	query  *ngmodels.ListAlertStateHistoryQuery
+	result []*ngmodels.AlertStateHistory
+}
+
+func (f *fakeStateHistoryStore) SaveAlertStateHistory(*ngmodels.SaveAlertStateHistoryCommand) error {
+	return nil
+}
+
+func (f *fakeStateHistoryStore) ListAlertStateHistory(query *ngmodels.ListAlertStateHistoryQuery) error {
+	f.query = query
+	query.Result = f.result
+	return nil
+}
+
+func (f *fakeStateHistoryStore) DeleteAlertStateHistory(*ngmodels.DeleteAlertStateHistoryCommand) error {
+	return nil
+}
+
+func newStateHistoryRequest(t *testing.T, url string) *models.ReqContext {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return &models.ReqContext{
+		Context:      &macaron.Context{Req: macaron.Request{Request: req}},
+		SignedInUser: &models.SignedInUser{OrgId: 1},
+	}
+}
+
+func TestRouteGetStateHistory(t *testing.T) {
+	evaluatedAt := time.Unix(1000, 0).UTC()
+	store := &fakeStateHistoryStore{result: []*ngmodels.AlertStateHistory{{
+		RuleOrgID:        1,
+		RuleUID:          "rule-a",
+		Labels:           ngmodels.InstanceLabels{"host": "a"},
+		PreviousState:    ngmodels.InstanceStateNormal,
+		State:            ngmodels.InstanceStateFiring,
+		EvaluationString: "[ var='A' val=1 ]",
+		EvaluatedAt:      evaluatedAt,
+	}}}
+	srv := HistorySrv{cfg: &setting.Cfg{StateHistoryEnabled: true}, store: store, log: log.New("test")}
+
+	t.Run("the transitions are listed with the filters of the query", func(t *testing.T) {
+		res := srv.RouteGetStateHistory(newStateHistoryRequest(t, "/api/v1/history?ruleUID=rule-a&labels=host=a&from=60000&to=120000&limit=10"))
+		require.Equal(t, http.StatusOK, res.Status())
+
+		require.Equal(t, int64(1), store.query.RuleOrgID)
+		require.Equal(t, "rule-a", store.query.RuleUID)
+		require.Equal(t, ngmodels.InstanceLabels{"host": "a"}, store.query.Labels)
+		require.Equal(t, time.Unix(60, 0), store.query.From)
+		require.Equal(t, time.Unix(120, 0), store.query.To)
+		require.Equal(t, int64(10), store.query.Limit)
+
+		var history apimodels.GettableStateHistory
+		require.NoError(t, json.Unmarshal(res.Body(), &history))
+		require.Equal(t, apimodels.GettableStateHistory{{
+			RuleUID:          "rule-a",
+			Labels:           map[string]string{"host": "a"},
+			PreviousState:    "Normal",
+			State:            "Alerting",
+			EvaluationString: "[ var='A' val=1 ]",
+			EvaluatedAt:      evaluatedAt,
+		}}, history)
+	})
+
+	t.Run("the limit defaults to 100", func(t *testing.T) {
+		res := srv.RouteGetStateHistory(newStateHistoryRequest(t, "/api/v1/history"))
+		require.Equal(t, http.StatusOK, res.Status())
+		require.Equal(t, int64(defaultStateHistoryLimit), store.query.Limit)
+		require.True(t, store.query.From.IsZero())
+		require.Empty(t, store.query.Labels)
+	})
+
+	t.Run("invalid labels are rejected", func(t *testing.T) {
+		res := srv.RouteGetStateHistory(newStateHistoryRequest(t, "/api/v1/history?labels=host"))
+		require.Equal(t, http.StatusBadRequest, res.Status())
+	})
+
+	t.Run("the history must be enabled", func(t *testing.T) {
+		disabled := HistorySrv{cfg: &setting.Cfg{}, store: store, log: log.New("test")}
+		res := disabled.RouteGetStateHistory(newStateHistoryRequest(t, "/api/v1/history"))
+		require.Equal(t, http.StatusNotFound, res.Status())
+	})
+}
@@ -46,6 +46,7 @@ func (srv PrometheusSrv) RouteGetAlertStatuses(c *models.ReqContext) response.Re
 			State:       alertState.State.String(),
 			ActiveAt:    &startsAt,
 			Value:       valString,
+			Values:      alertState.Values,
 		})
 	}
 	return response.JSON(http.StatusOK, alertResponse)
@@ -102,11 +103,12 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *models.ReqContext) response.Res
 				queryStr = string(encodedQuery)
 			}
 			alertingRule := apimodels.AlertingRule{
-				State:       "inactive",
-				Name:        rule.Title,
-				Query:       queryStr,
-				Duration:    rule.For.Seconds(),
-				Annotations: rule.Annotations,
+				State:         "inactive",
+				Name:          rule.Title,
+				Query:         queryStr,
+				Duration:      rule.For.Seconds(),
+				KeepFiringFor: rule.KeepFiringFor.Seconds(),
+				Annotations:   rule.Annotations,
 			}
 
 			newRule := apimodels.Rule{
@@ -116,6 +118,12 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *models.ReqContext) response.Res
 				Type:           apiv1.RuleTypeAlerting,
 				LastEvaluation: time.Time{},
 			}
+			if rule.IsRecordingRule() {
+				newRule.Type = apiv1.RuleTypeRecording
+			}
+			if rule.IsPaused {
+				newRule.Health = "paused"
+			}
 
 			for _, alertState := range srv.manager.GetStatesForRuleUID(c.OrgId, rule.UID) {
 				activeAt := alertState.StartsAt
@@ -129,6 +137,11 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *models.ReqContext) response.Res
 					State:       alertState.State.String(),
 					ActiveAt:    &activeAt,
 					Value:       valString, // TODO: set this once it is added to the evaluation results
+					Values:      alertState.Values,
+				}
+				if !alertState.KeptFiringSince.IsZero() {
+					keepFiringSince := alertState.KeptFiringSince
+					alert.KeepFiringSince = &keepFiringSince
 				}
 
 				if alertState.LastEvaluationTime.After(newRule.LastEvaluation) {
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -12,10 +14,13 @@ import (
 
 	coreapi "github.com/grafana/grafana/pkg/api"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/prometheus/common/model"
 )
@@ -25,6 +30,7 @@ type RulerSrv struct {
 	DatasourceCache datasources.CacheService
 	QuotaService    *quota.QuotaService
 	manager         *state.Manager
+	cfg             *setting.Cfg
 	log             log.Logger
 }
 
@@ -239,6 +245,9 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConf
 		if err := validateCondition(cond, c.SignedInUser, c.SkipCache, srv.DatasourceCache); err != nil {
 			return ErrResp(http.StatusBadRequest, err, "failed to validate alert rule %s", r.GrafanaManagedAlert.Title)
 		}
+		if r.GrafanaManagedAlert.Record != "" && srv.cfg.RecordingRulesRemoteWriteURL == "" {
+			return ErrResp(http.StatusBadRequest, errors.New("no remote write endpoint is configured for recording rules"), "failed to validate alert rule %s", r.GrafanaManagedAlert.Title)
+		}
 		alertRuleUIDs = append(alertRuleUIDs, r.GrafanaManagedAlert.UID)
 	}
 
@@ -262,6 +271,167 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConf
 	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule group updated successfully"})
 }
 
+func (srv RulerSrv) RouteGetRuleVersions(c *models.ReqContext) response.Response {
+	rule, namespace, errResp := srv.getRuleWithNamespace(c, c.Params(":RuleUID"), false)
+	if errResp != nil {
+		return errResp
+	}
+
+	q := ngmodels.ListAlertRuleVersionsQuery{
+		OrgID:   c.SignedInUser.OrgId,
+		RuleUID: rule.UID,
+		Limit:   c.QueryInt("limit"),
+		Start:   c.QueryInt("start"),
+	}
+	if err := srv.store.GetAlertRuleVersions(&q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule versions")
+	}
+
+	result := make(apimodels.GettableRuleVersions, 0, len(q.Result))
+	for _, v := range q.Result {
+		result = append(result, toGettableRuleVersion(*v, rule.ID, namespace.Id))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv RulerSrv) RoutePostRuleVersionsDiff(c *models.ReqContext, opts apimodels.RuleVersionsDiffOptions) response.Response {
+	rule, namespace, errResp := srv.getRuleWithNamespace(c, c.Params(":RuleUID"), false)
+	if errResp != nil {
+		return errResp
+	}
+
+	versions := make([]*simplejson.Json, 0, 2)
+	for _, version := range []int64{opts.Base, opts.New} {
+		q := ngmodels.GetAlertRuleVersionQuery{
+			OrgID:   c.SignedInUser.OrgId,
+			RuleUID: rule.UID,
+			Version: version,
+		}
+		if err := srv.store.GetAlertRuleVersion(&q); err != nil {
+			if errors.Is(err, ngmodels.ErrAlertRuleVersionNotFound) {
+				return ErrResp(http.StatusNotFound, err, "version %d", version)
+			}
+			return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule version")
+		}
+
+		b, err := json.Marshal(toGettableRuleVersion(*q.Result, rule.ID, namespace.Id).Rule)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to marshal alert rule version")
+		}
+		data, err := simplejson.NewJson(b)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to marshal alert rule version")
+		}
+		versions = append(versions, data)
+	}
+
+	diffType := dashdiffs.ParseDiffType(opts.DiffType)
+	result, err := dashdiffs.CalculateJSONDiff(versions[0], versions[1], diffType)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "unable to compute diff")
+	}
+
+	if diffType == dashdiffs.DiffDelta {
+		return response.Respond(http.StatusOK, result.Delta).SetHeader("Content-Type", "application/json")
+	}
+	return response.Respond(http.StatusOK, result.Delta).SetHeader("Content-Type", "text/html")
+}
+
+func (srv RulerSrv) RoutePostRestoreRuleVersion(c *models.ReqContext, cmd apimodels.RestoreRuleVersionCommand) response.Response {
+	rule, _, errResp := srv.getRuleWithNamespace(c, c.Params(":RuleUID"), true)
+	if errResp != nil {
+		return errResp
+	}
+
+	restoreCmd := ngmodels.RestoreAlertRuleVersionCommand{
+		OrgID:   c.SignedInUser.OrgId,
+		RuleUID: rule.UID,
+		Version: cmd.Version,
+	}
+	if err := srv.store.RestoreAlertRuleVersion(&restoreCmd); err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleVersionNotFound) {
+			return ErrResp(http.StatusNotFound, err, "failed to restore alert rule")
+		} else if errors.Is(err, ngmodels.ErrAlertRuleFailedValidation) {
+			return ErrResp(http.StatusBadRequest, err, "failed to restore alert rule")
+		} else if errors.Is(err, ngmodels.ErrAlertRuleConflict) {
+			return ErrResp(http.StatusConflict, err, "failed to restore alert rule")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to restore alert rule")
+	}
+
+	srv.manager.RemoveByRuleUID(c.SignedInUser.OrgId, rule.UID)
+
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": fmt.Sprintf("rule restored from version %d", cmd.Version), "version": restoreCmd.Result.Version})
+}
+
+// getRuleWithNamespace returns the alert rule with the given UID and its namespace,
+// checking that the user can edit the namespace if withCanSave is true.
+func (srv RulerSrv) getRuleWithNamespace(c *models.ReqContext, ruleUID string, withCanSave bool) (*ngmodels.AlertRule, *models.Folder, response.Response) {
+	q := ngmodels.GetAlertRuleByUIDQuery{
+		UID:   ruleUID,
+		OrgID: c.SignedInUser.OrgId,
+	}
+	if err := srv.store.GetAlertRuleByUID(&q); err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return nil, nil, ErrResp(http.StatusNotFound, err, "")
+		}
+		return nil, nil, ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	namespace, err := srv.store.GetNamespaceByUID(q.Result.NamespaceUID, c.SignedInUser.OrgId, c.SignedInUser)
+	if err != nil {
+		return nil, nil, toNamespaceErrorResponse(err)
+	}
+
+	if withCanSave {
+		if namespace, err = srv.store.GetNamespaceByTitle(namespace.Title, c.SignedInUser.OrgId, c.SignedInUser, true); err != nil {
+			return nil, nil, toNamespaceErrorResponse(err)
+		}
+	}
+
+	return q.Result, namespace, nil
+}
+
+func toGettableRuleVersion(v ngmodels.AlertRuleVersion, ruleID, namespaceID int64) apimodels.GettableRuleVersion {
+	rule := ngmodels.AlertRule{
+		ID:              ruleID,
+		OrgID:           v.RuleOrgID,
+		Title:           v.Title,
+		Condition:       v.Condition,
+		Data:            v.Data,
+		Updated:         v.Created,
+		IntervalSeconds: v.IntervalSeconds,
+		Version:         v.Version,
+		UID:             v.RuleUID,
+		NamespaceUID:    v.RuleNamespaceUID,
+		RuleGroup:       v.RuleGroup,
+		NoDataState:     v.NoDataState,
+		ExecErrState:    v.ExecErrState,
+		For:             v.For,
+		KeepFiringFor:   v.KeepFiringFor,
+		Annotations:     v.Annotations,
+		Labels:          v.Labels,
+		Record:          v.Record,
+	}
+
+	var message string
+	switch {
+	case v.RestoredFrom > 0:
+		message = fmt.Sprintf("Restored from version %d", v.RestoredFrom)
+	case v.ParentVersion == 0:
+		message = "Initial save"
+	}
+
+	return apimodels.GettableRuleVersion{
+		Version:       v.Version,
+		ParentVersion: v.ParentVersion,
+		RestoredFrom:  v.RestoredFrom,
+		Created:       v.Created,
+		Message:       message,
+		Rule:          toGettableExtendedRuleNode(rule, namespaceID),
+	}
+}
+
 func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64) apimodels.GettableExtendedRuleNode {
 	gettableExtendedRuleNode := apimodels.GettableExtendedRuleNode{
 		GrafanaManagedAlert: &apimodels.GettableGrafanaRule{
@@ -279,12 +449,15 @@ func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64) apimode
 			RuleGroup:       r.RuleGroup,
 			NoDataState:     apimodels.NoDataState(r.NoDataState),
 			ExecErrState:    apimodels.ExecutionErrorState(r.ExecErrState),
+			IsPaused:        r.IsPaused,
+			Record:          r.Record,
 		},
 	}
 	gettableExtendedRuleNode.ApiRuleNode = &apimodels.ApiRuleNode{
-		For:         model.Duration(r.For),
-		Annotations: r.Annotations,
-		Labels:      r.Labels,
+		For:           model.Duration(r.For),
+		KeepFiringFor: model.Duration(r.KeepFiringFor),
+		Annotations:   r.Annotations,
+		Labels:        r.Labels,
 	}
 	return gettableExtendedRuleNode
 }
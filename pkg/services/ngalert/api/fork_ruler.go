@@ -84,6 +84,21 @@ func (r *ForkedRuler) RouteGetRulegGroupConfig(ctx *models.ReqContext) response.
 	}
 }
 
+func (r *ForkedRuler) RouteGetRuleVersions(ctx *models.ReqContext) response.Response {
+	t, err := backendType(ctx, r.DatasourceCache)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+	switch t {
+	case apimodels.GrafanaBackend:
+		return r.GrafanaRuler.RouteGetRuleVersions(ctx)
+	case apimodels.LoTexRulerBackend:
+		return r.LotexRuler.RouteGetRuleVersions(ctx)
+	default:
+		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", t), "")
+	}
+}
+
 func (r *ForkedRuler) RouteGetRulesConfig(ctx *models.ReqContext) response.Response {
 	t, err := backendType(ctx, r.DatasourceCache)
 	if err != nil {
@@ -119,3 +134,33 @@ func (r *ForkedRuler) RoutePostNameRulesConfig(ctx *models.ReqContext, conf apim
 		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", backendType), "")
 	}
 }
+
+func (r *ForkedRuler) RoutePostRestoreRuleVersion(ctx *models.ReqContext, cmd apimodels.RestoreRuleVersionCommand) response.Response {
+	t, err := backendType(ctx, r.DatasourceCache)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+	switch t {
+	case apimodels.GrafanaBackend:
+		return r.GrafanaRuler.RoutePostRestoreRuleVersion(ctx, cmd)
+	case apimodels.LoTexRulerBackend:
+		return r.LotexRuler.RoutePostRestoreRuleVersion(ctx, cmd)
+	default:
+		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", t), "")
+	}
+}
+
+func (r *ForkedRuler) RoutePostRuleVersionsDiff(ctx *models.ReqContext, opts apimodels.RuleVersionsDiffOptions) response.Response {
+	t, err := backendType(ctx, r.DatasourceCache)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+	switch t {
+	case apimodels.GrafanaBackend:
+		return r.GrafanaRuler.RoutePostRuleVersionsDiff(ctx, opts)
+	case apimodels.LoTexRulerBackend:
+		return r.LotexRuler.RoutePostRuleVersionsDiff(ctx, opts)
+	default:
+		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", t), "")
+	}
+}
@@ -93,6 +93,15 @@ func (am *ForkedAMSvc) RouteGetAMAlerts(ctx *models.ReqContext) response.Respons
 	return s.RouteGetAMAlerts(ctx)
 }
 
+func (am *ForkedAMSvc) RouteGetAMStatus(ctx *models.ReqContext) response.Response {
+	s, err := am.getService(ctx)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+
+	return s.RouteGetAMStatus(ctx)
+}
+
 func (am *ForkedAMSvc) RouteGetSilence(ctx *models.ReqContext) response.Response {
 	s, err := am.getService(ctx)
 	if err != nil {
@@ -25,8 +25,11 @@ type RulerApiService interface {
 	RouteDeleteRuleGroupConfig(*models.ReqContext) response.Response
 	RouteGetNamespaceRulesConfig(*models.ReqContext) response.Response
 	RouteGetRulegGroupConfig(*models.ReqContext) response.Response
+	RouteGetRuleVersions(*models.ReqContext) response.Response
 	RouteGetRulesConfig(*models.ReqContext) response.Response
 	RoutePostNameRulesConfig(*models.ReqContext, apimodels.PostableRuleGroupConfig) response.Response
+	RoutePostRestoreRuleVersion(*models.ReqContext, apimodels.RestoreRuleVersionCommand) response.Response
+	RoutePostRuleVersionsDiff(*models.ReqContext, apimodels.RuleVersionsDiffOptions) response.Response
 }
 
 func (api *API) RegisterRulerApiEndpoints(srv RulerApiService, m *metrics.Metrics) {
@@ -67,6 +70,15 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApiService, m *metrics.Metric
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/ruler/{Recipient}/api/v1/rule/{RuleUID}/versions"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/{Recipient}/api/v1/rule/{RuleUID}/versions",
+				srv.RouteGetRuleVersions,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/ruler/{Recipient}/api/v1/rules"),
 			metrics.Instrument(
@@ -86,5 +98,25 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApiService, m *metrics.Metric
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/ruler/{Recipient}/api/v1/rule/{RuleUID}/restore"),
+			binding.Bind(apimodels.RestoreRuleVersionCommand{}),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/{Recipient}/api/v1/rule/{RuleUID}/restore",
+				srv.RoutePostRestoreRuleVersion,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/{Recipient}/api/v1/rule/{RuleUID}/versions/calculate-diff"),
+			binding.Bind(apimodels.RuleVersionsDiffOptions{}),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/{Recipient}/api/v1/rule/{RuleUID}/versions/calculate-diff",
+				srv.RoutePostRuleVersionsDiff,
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
@@ -18,6 +18,7 @@ const (
 	amSilencePath     = "/alertmanager/api/v2/silence/%s"
 	amAlertGroupsPath = "/alertmanager/api/v2/alerts/groups"
 	amAlertsPath      = "/alertmanager/api/v2/alerts"
+	amStatusPath      = "/alertmanager/api/v2/status"
 	amConfigPath      = "/api/v1/alerts"
 )
 
@@ -118,6 +119,20 @@ func (am *LotexAM) RouteGetAMAlerts(ctx *models.ReqContext) response.Response {
 	)
 }
 
+func (am *LotexAM) RouteGetAMStatus(ctx *models.ReqContext) response.Response {
+	return am.withReq(
+		ctx,
+		http.MethodGet,
+		withPath(
+			*ctx.Req.URL,
+			amStatusPath,
+		),
+		nil,
+		jsonExtractor(&apimodels.GettableStatus{}),
+		nil,
+	)
+}
+
 func (am *LotexAM) RouteGetSilence(ctx *models.ReqContext) response.Response {
 	return am.withReq(
 		ctx,
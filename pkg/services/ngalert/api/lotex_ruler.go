@@ -117,6 +117,11 @@ func (r *LotexRuler) RouteGetRulegGroupConfig(ctx *models.ReqContext) response.R
 	)
 }
 
+// RouteGetRuleVersions is not implemented as Cortex and Loki rulers do not keep rule versions.
+func (r *LotexRuler) RouteGetRuleVersions(ctx *models.ReqContext) response.Response {
+	return NotImplementedResp
+}
+
 func (r *LotexRuler) RouteGetRulesConfig(ctx *models.ReqContext) response.Response {
 	legacyRulerPrefix, err := r.getPrefix(ctx)
 	if err != nil {
@@ -149,6 +154,16 @@ func (r *LotexRuler) RoutePostNameRulesConfig(ctx *models.ReqContext, conf apimo
 	return r.withReq(ctx, http.MethodPost, u, bytes.NewBuffer(yml), jsonExtractor(nil), nil)
 }
 
+// RoutePostRestoreRuleVersion is not implemented as Cortex and Loki rulers do not keep rule versions.
+func (r *LotexRuler) RoutePostRestoreRuleVersion(ctx *models.ReqContext, cmd apimodels.RestoreRuleVersionCommand) response.Response {
+	return NotImplementedResp
+}
+
+// RoutePostRuleVersionsDiff is not implemented as Cortex and Loki rulers do not keep rule versions.
+func (r *LotexRuler) RoutePostRuleVersionsDiff(ctx *models.ReqContext, opts apimodels.RuleVersionsDiffOptions) response.Response {
+	return NotImplementedResp
+}
+
 func (r *LotexRuler) getPrefix(ctx *models.ReqContext) (string, error) {
 	ds, err := r.DataProxy.DatasourceCache.GetDatasource(ctx.ParamsInt64("Recipient"), ctx.SignedInUser, ctx.SkipCache)
 	if err != nil {
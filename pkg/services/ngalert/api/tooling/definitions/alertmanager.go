@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/timeinterval"
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -48,6 +50,14 @@ import (
 //       200: GettableAlerts
 //       400: ValidationError
 
+// swagger:route GET /api/alertmanager/{Recipient}/api/v2/status alertmanager RouteGetAMStatus
+//
+// get alertmanager status and configuration
+//
+//     Responses:
+//       200: GettableStatus
+//       400: ValidationError
+
 // swagger:route POST /api/alertmanager/{Recipient}/api/v2/alerts alertmanager RoutePostAMAlerts
 //
 // create alertmanager alerts
@@ -138,6 +148,9 @@ type AlertGroup = amv2.AlertGroup
 // swagger:model
 type Receiver = amv2.Receiver
 
+// swagger:model
+type GettableStatus = amv2.AlertmanagerStatus
+
 // swagger:parameters RouteGetAMAlerts RouteGetAMAlertGroups
 type AlertsParams struct {
 
@@ -183,7 +196,7 @@ type BodyAlertingConfig struct {
 }
 
 // alertmanager routes
-// swagger:parameters RoutePostAlertingConfig RouteGetAlertingConfig RouteDeleteAlertingConfig RouteGetAMAlerts RoutePostAMAlerts RouteGetAMAlertGroups RouteGetSilences RouteCreateSilence RouteGetSilence RouteDeleteSilence RoutePostAlertingConfig
+// swagger:parameters RoutePostAlertingConfig RouteGetAlertingConfig RouteDeleteAlertingConfig RouteGetAMAlerts RoutePostAMAlerts RouteGetAMAlertGroups RouteGetAMStatus RouteGetSilences RouteCreateSilence RouteGetSilence RouteDeleteSilence RoutePostAlertingConfig
 // ruler routes
 // swagger:parameters RouteGetRulesConfig RoutePostNameRulesConfig RouteGetNamespaceRulesConfig RouteDeleteNamespaceRulesConfig RouteGetRulegGroupConfig RouteDeleteRuleGroupConfig
 // prom routes
@@ -465,6 +478,8 @@ type Config struct {
 	Route        *config.Route         `yaml:"route,omitempty" json:"route,omitempty"`
 	InhibitRules []*config.InhibitRule `yaml:"inhibit_rules,omitempty" json:"inhibit_rules,omitempty"`
 	Templates    []string              `yaml:"templates" json:"templates"`
+	// MuteTimeIntervals are the time intervals routes can reference to mute their notifications.
+	MuteTimeIntervals []MuteTimeInterval `yaml:"mute_time_intervals,omitempty" json:"mute_time_intervals,omitempty"`
 }
 
 // Config is the entrypoint for the embedded Alertmanager config with the exception of receivers.
@@ -513,9 +528,103 @@ func (c *Config) UnmarshalJSON(b []byte) error {
 		}
 	}
 
+	return c.ValidateMuteTimeIntervals()
+}
+
+// ValidateMuteTimeIntervals ensures that the mute time intervals are valid and uniquely named,
+// and that the routes only reference mute time intervals that exist.
+func (c *Config) ValidateMuteTimeIntervals() error {
+	names := make(map[string]struct{}, len(c.MuteTimeIntervals))
+	for _, mt := range c.MuteTimeIntervals {
+		if mt.Name == "" {
+			return fmt.Errorf("missing name in mute time interval")
+		}
+		if _, ok := names[mt.Name]; ok {
+			return fmt.Errorf("mute time interval %q is not unique", mt.Name)
+		}
+		names[mt.Name] = struct{}{}
+
+		for _, ti := range mt.TimeIntervals {
+			if _, _, err := ti.Parse(); err != nil {
+				return fmt.Errorf("invalid mute time interval %q: %w", mt.Name, err)
+			}
+		}
+	}
+
+	if c.Route == nil {
+		return nil
+	}
+	if len(c.Route.MuteTimeIntervals) > 0 {
+		return fmt.Errorf("root route must not have any mute time intervals")
+	}
+	return checkMuteTimeIntervals(c.Route, names)
+}
+
+// checkMuteTimeIntervals recursively walks a routing tree and ensures every mute time interval it references exists.
+func checkMuteTimeIntervals(route *config.Route, names map[string]struct{}) error {
+	for _, name := range route.MuteTimeIntervals {
+		if _, ok := names[name]; !ok {
+			return fmt.Errorf("undefined mute time interval %q used in route", name)
+		}
+	}
+	for _, subRoute := range route.Routes {
+		if err := checkMuteTimeIntervals(subRoute, names); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// MuteTimeInterval is a named set of time intervals, e.g. maintenance windows or
+// the hours outside of business hours, during which the routes referencing it don't send notifications.
+type MuteTimeInterval struct {
+	Name          string         `yaml:"name" json:"name"`
+	TimeIntervals []TimeInterval `yaml:"time_intervals" json:"time_intervals"`
+}
+
+// TimeInterval describes an interval of time with the syntax of the Alertmanager.
+// For example, times from "09:00" to "17:00", weekdays "monday:friday", days of month "1:7" or "-1",
+// months "january:march" and years "2021:2022". An empty field matches any time.
+// The time is evaluated in the location, which defaults to UTC.
+type TimeInterval struct {
+	Times       []TimeRange `yaml:"times,omitempty" json:"times,omitempty"`
+	Weekdays    []string    `yaml:"weekdays,omitempty" json:"weekdays,omitempty"`
+	DaysOfMonth []string    `yaml:"days_of_month,omitempty" json:"days_of_month,omitempty"`
+	Months      []string    `yaml:"months,omitempty" json:"months,omitempty"`
+	Years       []string    `yaml:"years,omitempty" json:"years,omitempty"`
+	Location    string      `yaml:"location,omitempty" json:"location,omitempty"`
+}
+
+// TimeRange is a range of the day, the start time is inclusive and the end time is exclusive.
+type TimeRange struct {
+	StartTime string `yaml:"start_time" json:"start_time"`
+	EndTime   string `yaml:"end_time" json:"end_time"`
+}
+
+// Parse returns the Alertmanager time interval and the location it is evaluated in.
+func (ti TimeInterval) Parse() (timeinterval.TimeInterval, *time.Location, error) {
+	var parsed timeinterval.TimeInterval
+
+	loc, err := time.LoadLocation(ti.Location)
+	if err != nil {
+		return parsed, nil, fmt.Errorf("invalid location %q: %w", ti.Location, err)
+	}
+
+	// The Alertmanager parses and validates time intervals in its yaml unmarshaler.
+	// Therefore, we'll redirect to yaml to utilize it.
+	plain := ti
+	plain.Location = ""
+	b, err := yaml.Marshal(plain)
+	if err != nil {
+		return parsed, nil, errors.Wrap(err, "marshaling time interval to yaml for validation")
+	}
+	if err := yaml.Unmarshal(b, &parsed); err != nil {
+		return parsed, nil, err
+	}
+
+	return parsed, loc, nil
+}
+
 type PostableApiAlertingConfig struct {
 	Config `yaml:",inline"`
 
@@ -670,3 +670,84 @@ func Test_Marshaling_Validation(t *testing.T) {
 	expected := []model.LabelName{"alertname"}
 	require.Equal(t, expected, tmp.AlertmanagerConfig.Config.Route.GroupBy)
 }
+
+func Test_MuteTimeIntervals_Validation(t *testing.T) {
+	tc := []struct {
+		desc string
+		cfg  string
+		err  bool
+	}{
+		{
+			desc: "valid mute time intervals referenced by a route",
+			cfg: `{
+				"route": {"receiver": "r", "routes": [{"receiver": "r", "mute_time_intervals": ["weekends", "nights"]}]},
+				"mute_time_intervals": [
+					{"name": "weekends", "time_intervals": [{"weekdays": ["saturday", "sunday"]}]},
+					{"name": "nights", "time_intervals": [{"times": [{"start_time": "00:00", "end_time": "06:00"}], "months": ["january:march"], "days_of_month": ["1:7", "-1"], "location": "Europe/Berlin"}]}
+				]
+			}`,
+		},
+		{
+			desc: "undefined mute time interval",
+			cfg: `{
+				"route": {"receiver": "r", "routes": [{"receiver": "r", "mute_time_intervals": ["weekends"]}]}
+			}`,
+			err: true,
+		},
+		{
+			desc: "mute time interval in the root route",
+			cfg: `{
+				"route": {"receiver": "r", "mute_time_intervals": ["weekends"]},
+				"mute_time_intervals": [{"name": "weekends", "time_intervals": [{"weekdays": ["saturday", "sunday"]}]}]
+			}`,
+			err: true,
+		},
+		{
+			desc: "duplicated mute time interval",
+			cfg: `{
+				"route": {"receiver": "r"},
+				"mute_time_intervals": [
+					{"name": "weekends", "time_intervals": [{"weekdays": ["saturday"]}]},
+					{"name": "weekends", "time_intervals": [{"weekdays": ["sunday"]}]}
+				]
+			}`,
+			err: true,
+		},
+		{
+			desc: "invalid weekday",
+			cfg: `{
+				"route": {"receiver": "r"},
+				"mute_time_intervals": [{"name": "weekends", "time_intervals": [{"weekdays": ["caturday"]}]}]
+			}`,
+			err: true,
+		},
+		{
+			desc: "invalid time range",
+			cfg: `{
+				"route": {"receiver": "r"},
+				"mute_time_intervals": [{"name": "nights", "time_intervals": [{"times": [{"start_time": "06:00", "end_time": "00:00"}]}]}]
+			}`,
+			err: true,
+		},
+		{
+			desc: "invalid location",
+			cfg: `{
+				"route": {"receiver": "r"},
+				"mute_time_intervals": [{"name": "nights", "time_intervals": [{"location": "Middle/Earth"}]}]
+			}`,
+			err: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.desc, func(t *testing.T) {
+			var cfg Config
+			err := json.Unmarshal([]byte(c.cfg), &cfg)
+			if c.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -64,6 +64,40 @@ import (
 //     Responses:
 //       202: Ack
 
+// swagger:route Get /api/ruler/{Recipient}/api/v1/rule/{RuleUID}/versions ruler RouteGetRuleVersions
+//
+// List the versions of a rule, most recent first
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableRuleVersions
+
+// swagger:route POST /api/ruler/{Recipient}/api/v1/rule/{RuleUID}/versions/calculate-diff ruler RoutePostRuleVersionsDiff
+//
+// Compute the diff of two versions of a rule
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//     - text/html
+//
+//     Responses:
+//       200: RuleVersionsDiff
+
+// swagger:route POST /api/ruler/{Recipient}/api/v1/rule/{RuleUID}/restore ruler RoutePostRestoreRuleVersion
+//
+// Restore a rule to one of its versions
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       202: Ack
+
 // swagger:parameters RoutePostNameRulesConfig
 type NamespaceConfig struct {
 	// in:path
@@ -86,6 +120,71 @@ type PathRouleGroupConfig struct {
 	Groupname string
 }
 
+// swagger:parameters RouteGetRuleVersions
+type RuleVersionsParams struct {
+	// in: path
+	RuleUID string
+	// Maximum number of versions to return
+	// in: query
+	// required: false
+	Limit int `json:"limit"`
+	// Number of versions to skip
+	// in: query
+	// required: false
+	Start int `json:"start"`
+}
+
+// swagger:parameters RoutePostRuleVersionsDiff
+type RuleVersionsDiffRequest struct {
+	// in: path
+	RuleUID string
+	// in: body
+	Body RuleVersionsDiffOptions
+}
+
+// swagger:parameters RoutePostRestoreRuleVersion
+type RestoreRuleVersionRequest struct {
+	// in: path
+	RuleUID string
+	// in: body
+	Body RestoreRuleVersionCommand
+}
+
+// swagger:model
+type GettableRuleVersions []GettableRuleVersion
+
+// swagger:model
+type GettableRuleVersion struct {
+	Version       int64     `json:"version"`
+	ParentVersion int64     `json:"parentVersion"`
+	RestoredFrom  int64     `json:"restoredFrom"`
+	Created       time.Time `json:"created"`
+	Message       string    `json:"message"`
+	// Rule is the rule as it was saved in this version.
+	Rule GettableExtendedRuleNode `json:"rule"`
+}
+
+// swagger:model
+type RuleVersionsDiffOptions struct {
+	// required: true
+	Base int64 `json:"base"`
+	// required: true
+	New int64 `json:"new"`
+	// DiffType is one of "basic" (default), "json" or "delta".
+	DiffType string `json:"diffType"`
+}
+
+// RuleVersionsDiff is the HTML rendering of the diff for the basic and json diff types,
+// and the JSON delta for the delta diff type.
+// swagger:model
+type RuleVersionsDiff string
+
+// swagger:model
+type RestoreRuleVersionCommand struct {
+	// required: true
+	Version int64 `json:"version"`
+}
+
 // swagger:model
 type RuleGroupConfigResponse struct {
 	GettableRuleGroupConfig
@@ -187,12 +286,13 @@ func (c *GettableRuleGroupConfig) validate() error {
 }
 
 type ApiRuleNode struct {
-	Record      string            `yaml:"record,omitempty" json:"record,omitempty"`
-	Alert       string            `yaml:"alert,omitempty" json:"alert,omitempty"`
-	Expr        string            `yaml:"expr" json:"expr"`
-	For         model.Duration    `yaml:"for,omitempty" json:"for,omitempty"`
-	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
-	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Record        string            `yaml:"record,omitempty" json:"record,omitempty"`
+	Alert         string            `yaml:"alert,omitempty" json:"alert,omitempty"`
+	Expr          string            `yaml:"expr" json:"expr"`
+	For           model.Duration    `yaml:"for,omitempty" json:"for,omitempty"`
+	KeepFiringFor model.Duration    `yaml:"keep_firing_for,omitempty" json:"keep_firing_for,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations   map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
 }
 
 type RuleType int
@@ -299,6 +399,12 @@ type PostableGrafanaRule struct {
 	UID          string              `json:"uid" yaml:"uid"`
 	NoDataState  NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
+	// IsPaused stops the evaluation of the rule until it is resumed.
+	// When omitted, an existing rule keeps its current value.
+	IsPaused *bool `json:"is_paused,omitempty" yaml:"is_paused,omitempty"`
+	// Record turns the rule into a recording rule: the result of the condition is written
+	// to the configured remote write endpoint as a time series with this metric name.
+	Record string `json:"record,omitempty" yaml:"record,omitempty"`
 }
 
 // swagger:model
@@ -317,4 +423,6 @@ type GettableGrafanaRule struct {
 	RuleGroup       string              `json:"rule_group" yaml:"rule_group"`
 	NoDataState     NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState    ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
+	IsPaused        bool                `json:"is_paused" yaml:"is_paused"`
+	Record          string              `json:"record,omitempty" yaml:"record,omitempty"`
 }
@@ -0,0 +1,62 @@
+package definitions
+
+import (
+	"time"
+)
+
+// swagger:route GET /api/v1/rules/history history RouteGetStateHistory
+//
+// gets the recorded state transitions of alert instances, most recent first
+//
+//     Responses:
+//       200: GettableStateHistory
+//       400: ValidationError
+
+// swagger:parameters RouteGetStateHistory
+type StateHistoryParams struct {
+	// Only return the transitions of the alert instances of the rule with this UID
+	// in: query
+	// required: false
+	RuleUID string `json:"ruleUID"`
+
+	// Only return the transitions of the alert instances with exactly this label set,
+	// given as a list of name=value pairs
+	// in: query
+	// required: false
+	Labels []string `json:"labels"`
+
+	// Only return the transitions evaluated at or after this time, in epoch milliseconds
+	// in: query
+	// required: false
+	From int64 `json:"from"`
+
+	// Only return the transitions evaluated at or before this time, in epoch milliseconds
+	// in: query
+	// required: false
+	To int64 `json:"to"`
+
+	// Maximum number of transitions to return
+	// in: query
+	// required: false
+	// default: 100
+	Limit int64 `json:"limit"`
+}
+
+// swagger:model
+type GettableStateHistory []GettableStateTransition
+
+// swagger:model
+type GettableStateTransition struct {
+	// required: true
+	RuleUID string `json:"ruleUID"`
+	// required: true
+	Labels map[string]string `json:"labels"`
+	// required: true
+	PreviousState string `json:"previousState"`
+	// required: true
+	State            string `json:"state"`
+	EvaluationString string `json:"evaluationString,omitempty"`
+	Error            string `json:"error,omitempty"`
+	// required: true
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
 // swagger:route GET /api/prometheus/{Recipient}/api/v1/rules prometheus RouteGetRuleStatuses
@@ -85,8 +87,9 @@ type AlertingRule struct {
 	// required: true
 	Name string `json:"name,omitempty"`
 	// required: true
-	Query    string  `json:"query,omitempty"`
-	Duration float64 `json:"duration,omitempty"`
+	Query         string  `json:"query,omitempty"`
+	Duration      float64 `json:"duration,omitempty"`
+	KeepFiringFor float64 `json:"keepFiringFor,omitempty"`
 	// required: true
 	Annotations labels `json:"annotations,omitempty"`
 	// required: true
@@ -121,8 +124,12 @@ type Alert struct {
 	// required: true
 	State    string     `json:"state"`
 	ActiveAt *time.Time `json:"activeAt"`
+	// KeepFiringSince is set when the alert is kept firing although its condition is no longer met.
+	KeepFiringSince *time.Time `json:"keepFiringSince,omitempty"`
 	// required: true
 	Value string `json:"value"`
+	// Values are the values of the reduced expressions and queries of the last evaluation keyed by RefID.
+	Values models.InstanceValues `json:"values,omitempty"`
 }
 
 // override the labels type with a map for generation.
@@ -23,6 +23,9 @@ const alertingEvaluationTimeout = 30 * time.Second
 
 type Evaluator struct {
 	Cfg *setting.Cfg
+	// QueryCache, when set, shares the results of the data source queries of the conditions
+	// with a positive QueryCacheTTL.
+	QueryCache *expr.QueryCache
 }
 
 // invalidEvalResultFormatError is an error for invalid format of the alert definition evaluation results.
@@ -69,6 +72,10 @@ type Result struct {
 	// as EvalMatches (from "classic condition"), and in the future from operations
 	// like SSE "math".
 	EvaluationString string
+
+	// Values contains the RefID and value of reduced expressions and queries that have labels
+	// matching the labels of the result, keyed by RefID.
+	Values map[string]NumberValueCapture
 }
 
 // State is an enum of the evaluation State for an alert instance.
@@ -105,6 +112,8 @@ func (s State) String() string {
 type AlertExecCtx struct {
 	OrgID              int64
 	ExpressionsEnabled bool
+	QueryCache         *expr.QueryCache
+	QueryCacheTTL      time.Duration
 
 	Ctx context.Context
 }
@@ -227,8 +236,10 @@ func executeQueriesAndExpressions(ctx AlertExecCtx, data []models.AlertQuery, no
 	}
 
 	exprService := expr.Service{
-		Cfg:         &setting.Cfg{ExpressionsEnabled: ctx.ExpressionsEnabled},
-		DataService: dataService,
+		Cfg:           &setting.Cfg{ExpressionsEnabled: ctx.ExpressionsEnabled},
+		DataService:   dataService,
+		QueryCache:    ctx.QueryCache,
+		QueryCacheTTL: ctx.QueryCacheTTL,
 	}
 	return exprService.TransformData(ctx.Ctx, queryDataReq)
 }
@@ -326,6 +337,7 @@ func evaluateExecutionResult(execResults ExecutionResults, ts time.Time) Results
 			EvaluatedAt:        ts,
 			EvaluationDuration: time.Since(ts),
 			EvaluationString:   extractEvalString(f),
+			Values:             extractValues(f),
 		}
 
 		switch {
@@ -410,7 +422,13 @@ func (e *Evaluator) ConditionEval(condition *models.Condition, now time.Time, da
 	alertCtx, cancelFn := context.WithTimeout(context.Background(), alertingEvaluationTimeout)
 	defer cancelFn()
 
-	alertExecCtx := AlertExecCtx{OrgID: condition.OrgID, Ctx: alertCtx, ExpressionsEnabled: e.Cfg.ExpressionsEnabled}
+	alertExecCtx := AlertExecCtx{
+		OrgID:              condition.OrgID,
+		Ctx:                alertCtx,
+		ExpressionsEnabled: e.Cfg.ExpressionsEnabled,
+		QueryCache:         e.QueryCache,
+		QueryCacheTTL:      condition.QueryCacheTTL,
+	}
 
 	execResult := executeCondition(alertExecCtx, condition, now, dataService)
 
@@ -65,3 +65,22 @@ func extractEvalString(frame *data.Frame) (s string) {
 
 	return ""
 }
+
+// extractValues returns the values captured in the metadata of the frame keyed by RefID.
+// Frames of classic conditions don't have captured values.
+func extractValues(frame *data.Frame) map[string]NumberValueCapture {
+	if frame == nil || frame.Meta == nil || frame.Meta.Custom == nil {
+		return nil
+	}
+
+	caps, ok := frame.Meta.Custom.([]NumberValueCapture)
+	if !ok {
+		return nil
+	}
+
+	values := make(map[string]NumberValueCapture, len(caps))
+	for _, c := range caps {
+		values[c.Var] = c
+	}
+	return values
+}
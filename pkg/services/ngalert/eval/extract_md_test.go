@@ -54,3 +54,23 @@ func newMetaFrame(custom interface{}, val *float64) *data.Frame {
 			Custom: custom,
 		})
 }
+
+func TestExtractValues(t *testing.T) {
+	t.Run("classic conditions have no values", func(t *testing.T) {
+		frame := newMetaFrame([]classic.EvalMatch{
+			{Metric: "Test", Labels: data.Labels{"host": "foo"}, Value: ptr.Float64(32.3)},
+		}, ptr.Float64(1))
+		require.Nil(t, extractValues(frame))
+	})
+
+	t.Run("captured values are keyed by RefID", func(t *testing.T) {
+		frame := newMetaFrame([]NumberValueCapture{
+			{Var: "A", Labels: data.Labels{"host": "foo"}, Value: ptr.Float64(32.3)},
+			{Var: "B", Labels: data.Labels{"host": "foo"}, Value: nil},
+		}, ptr.Float64(1))
+		require.Equal(t, map[string]NumberValueCapture{
+			"A": {Var: "A", Labels: data.Labels{"host": "foo"}, Value: ptr.Float64(32.3)},
+			"B": {Var: "B", Labels: data.Labels{"host": "foo"}, Value: nil},
+		}, extractValues(frame))
+	})
+}
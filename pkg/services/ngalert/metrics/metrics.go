@@ -31,11 +31,15 @@ type Metrics struct {
 	// Registerer is for use by subcomponents which register their own metrics.
 	Registerer           prometheus.Registerer
 	RequestDuration      *prometheus.HistogramVec
-	ActiveConfigurations prometheus.Gauge
+	ActiveConfigurations *prometheus.GaugeVec
 	EvalTotal            *prometheus.CounterVec
 	EvalFailures         *prometheus.CounterVec
 	EvalDuration         *prometheus.SummaryVec
+	EvalQueueDuration    *prometheus.SummaryVec
+	EvalSkipped          *prometheus.CounterVec
 	GroupRules           *prometheus.GaugeVec
+	OwnedRules           prometheus.Gauge
+	StateHistoryDropped  *prometheus.CounterVec
 }
 
 func init() {
@@ -73,12 +77,31 @@ func NewMetrics(r prometheus.Registerer) *Metrics {
 			},
 			[]string{"method", "route", "status_code", "backend"},
 		),
-		ActiveConfigurations: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+		ActiveConfigurations: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: "grafana",
 			Subsystem: "alerting",
 			Name:      "active_configurations",
 			Help:      "The number of active, non default alertmanager configurations for grafana managed alerts",
-		}),
+		}, []string{"org"}),
+		EvalQueueDuration: promauto.With(r).NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:  "grafana",
+				Subsystem:  "alerting",
+				Name:       "rule_evaluation_queue_duration_seconds",
+				Help:       "The time a rule waits between being scheduled and being evaluated.",
+				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+			},
+			[]string{"user"},
+		),
+		EvalSkipped: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "grafana",
+				Subsystem: "alerting",
+				Name:      "rule_evaluations_skipped_total",
+				Help:      "The total number of rule evaluations skipped because the previous evaluation was still running.",
+			},
+			[]string{"user"},
+		),
 		// TODO: once rule groups support multiple rules, consider partitioning
 		// on rule group as well as tenant, similar to loki|cortex.
 		EvalTotal: promauto.With(r).NewCounterVec(
@@ -122,6 +145,23 @@ func NewMetrics(r prometheus.Registerer) *Metrics {
 			},
 			[]string{"user"},
 		),
+		OwnedRules: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "grafana",
+				Subsystem: "alerting",
+				Name:      "scheduler_owned_rules",
+				Help:      "The number of rules evaluated by this instance.",
+			},
+		),
+		StateHistoryDropped: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "grafana",
+				Subsystem: "alerting",
+				Name:      "state_history_dropped_total",
+				Help:      "The total number of alert state transitions not recorded because the queue of the historian was full.",
+			},
+			[]string{"historian"},
+		),
 	}
 }
 
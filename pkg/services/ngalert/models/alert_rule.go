@@ -17,6 +17,10 @@ var (
 	ErrRuleGroupNamespaceNotFound = errors.New("rule group not found under this namespace")
 	// ErrAlertRuleFailedValidation
 	ErrAlertRuleFailedValidation = errors.New("invalid alert rule")
+	// ErrAlertRuleVersionNotFound is an error for an unknown alert rule version.
+	ErrAlertRuleVersionNotFound = errors.New("could not find alert rule version")
+	// ErrAlertRuleConflict is an error for an alert rule with the same title as another rule of the organisation.
+	ErrAlertRuleConflict = errors.New("an alert rule with the same title already exists")
 )
 
 type NoDataState string
@@ -44,6 +48,13 @@ const (
 const (
 	UIDLabel          = "__alert_rule_uid__"
 	NamespaceUIDLabel = "__alert_rule_namespace_uid__"
+
+	// Annotations are actually a set of labels, so technically this is the label name of an annotation.
+	DashboardUIDAnnotation = "__dashboardUid__"
+	PanelIDAnnotation      = "__panelId__"
+
+	// ValuesAnnotation is the annotation of the alerts sent to the Alertmanager carrying the JSON encoded InstanceValues.
+	ValuesAnnotation = "__values__"
 )
 
 // AlertRule is the model for alert rules in unified alerting.
@@ -66,6 +77,13 @@ type AlertRule struct {
 	For         time.Duration
 	Annotations map[string]string
 	Labels      map[string]string
+	// KeepFiringFor is how long the results must be normal before a firing alert is resolved.
+	KeepFiringFor time.Duration
+	// IsPaused is true if the alert rule must not be evaluated until it is resumed.
+	IsPaused bool
+	// Record is the metric name the result of the condition is written to
+	// if the rule is a recording rule. It is empty for alerting rules.
+	Record string
 }
 
 // AlertRuleKey is the alert definition identifier
@@ -83,6 +101,12 @@ func (alertRule *AlertRule) GetKey() AlertRuleKey {
 	return AlertRuleKey{OrgID: alertRule.OrgID, UID: alertRule.UID}
 }
 
+// IsRecordingRule returns true if the rule records the result of its condition
+// as a time series instead of alerting on it.
+func (alertRule *AlertRule) IsRecordingRule() bool {
+	return alertRule.Record != ""
+}
+
 // PreSave sets default values and loads the updated model for each alert query.
 func (alertRule *AlertRule) PreSave(timeNow func() time.Time) error {
 	for i, q := range alertRule.Data {
@@ -116,9 +140,11 @@ type AlertRuleVersion struct {
 	ExecErrState    ExecutionErrorState
 	// ideally this field should have been apimodels.ApiDuration
 	// but this is currently not possible because of circular dependencies
-	For         time.Duration
-	Annotations map[string]string
-	Labels      map[string]string
+	For           time.Duration
+	KeepFiringFor time.Duration
+	Annotations   map[string]string
+	Labels        map[string]string
+	Record        string
 }
 
 // GetAlertRuleByUIDQuery is the query for retrieving/deleting an alert rule by UID and organisation ID.
@@ -129,6 +155,34 @@ type GetAlertRuleByUIDQuery struct {
 	Result *AlertRule
 }
 
+// ListAlertRuleVersionsQuery is the query for listing the versions of an alert rule, most recent first.
+type ListAlertRuleVersionsQuery struct {
+	OrgID   int64
+	RuleUID string
+	Limit   int
+	Start   int
+
+	Result []*AlertRuleVersion
+}
+
+// GetAlertRuleVersionQuery is the query for retrieving a version of an alert rule.
+type GetAlertRuleVersionQuery struct {
+	OrgID   int64
+	RuleUID string
+	Version int64
+
+	Result *AlertRuleVersion
+}
+
+// RestoreAlertRuleVersionCommand is the command for restoring an alert rule to one of its versions.
+type RestoreAlertRuleVersionCommand struct {
+	OrgID   int64
+	RuleUID string
+	Version int64
+
+	Result *AlertRule
+}
+
 // ListAlertRulesQuery is the query for listing alert rules
 type ListAlertRulesQuery struct {
 	OrgID int64
@@ -172,6 +226,10 @@ type Condition struct {
 
 	// Data is an array of data source queries and/or server side expressions.
 	Data []AlertQuery `json:"data"`
+
+	// QueryCacheTTL is for how long the results of the data source queries may be shared with
+	// other conditions running the same queries, 0 disables the sharing.
+	QueryCacheTTL time.Duration `json:"-"`
 }
 
 // IsValid checks the condition's validity.
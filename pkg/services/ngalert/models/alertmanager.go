@@ -12,10 +12,12 @@ type AlertConfiguration struct {
 	ConfigurationVersion      string
 	CreatedAt                 time.Time `xorm:"created"`
 	Default                   bool
+	OrgID                     int64 `xorm:"org_id"`
 }
 
 // GetLatestAlertmanagerConfigurationQuery is the query to get the latest alertmanager configuration.
 type GetLatestAlertmanagerConfigurationQuery struct {
+	OrgID  int64
 	Result *AlertConfiguration
 }
 
@@ -24,6 +26,7 @@ type SaveAlertmanagerConfigurationCmd struct {
 	AlertmanagerConfiguration string
 	ConfigurationVersion      string
 	Default                   bool
+	OrgID                     int64
 }
 
 type DeleteAlertmanagerConfigurationCmd struct {
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 // AlertInstance represents a single alert instance.
@@ -15,6 +18,37 @@ type AlertInstance struct {
 	CurrentStateSince time.Time
 	CurrentStateEnd   time.Time
 	LastEvalTime      time.Time
+	CurrentValues     InstanceValues
+	// KeptFiringSince is the time of the first normal result of a firing alert that is kept firing,
+	// it is zero otherwise.
+	KeptFiringSince time.Time
+}
+
+// InstanceValues are the values of the reduced expressions and queries of the last evaluation
+// of an alert instance, keyed by RefID.
+type InstanceValues map[string]InstanceValue
+
+// InstanceValue is the value of a reduced expression or query with its labels.
+// The value is nil if it is null, NaN or infinite as JSON can't represent the latter.
+type InstanceValue struct {
+	Labels data.Labels `json:"labels,omitempty"`
+	Value  *float64    `json:"value"`
+}
+
+// FromDB loads values stored in the database as JSON into InstanceValues.
+// FromDB is part of the xorm Conversion interface.
+func (iv *InstanceValues) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*iv = nil
+		return nil
+	}
+	return json.Unmarshal(b, iv)
+}
+
+// ToDB serializes InstanceValues as JSON.
+// ToDB is part of the xorm Conversion interface.
+func (iv *InstanceValues) ToDB() ([]byte, error) {
+	return json.Marshal(iv)
 }
 
 // InstanceStateType is an enum for instance states.
@@ -52,6 +86,8 @@ type SaveAlertInstanceCommand struct {
 	LastEvalTime      time.Time
 	CurrentStateSince time.Time
 	CurrentStateEnd   time.Time
+	Values            InstanceValues
+	KeptFiringSince   time.Time
 }
 
 // GetAlertInstanceQuery is the query for retrieving/deleting an alert definition by ID.
@@ -83,6 +119,8 @@ type ListAlertInstancesQueryResult struct {
 	CurrentStateSince time.Time         `json:"currentStateSince"`
 	CurrentStateEnd   time.Time         `json:"currentStateEnd"`
 	LastEvalTime      time.Time         `json:"lastEvalTime"`
+	CurrentValues     InstanceValues    `json:"currentValues,omitempty"`
+	KeptFiringSince   time.Time         `json:"keptFiringSince"`
 }
 
 // ValidateAlertInstance validates that the alert instance contains an alert rule id,
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertStateHistory is a transition of an alert instance from one state to another.
+type AlertStateHistory struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	RuleOrgID        int64  `xorm:"rule_org_id"`
+	RuleUID          string `xorm:"rule_uid"`
+	Labels           InstanceLabels
+	LabelsHash       string
+	PreviousState    InstanceStateType
+	State            InstanceStateType
+	EvaluationString string
+	Error            string
+	EvaluatedAt      time.Time
+}
+
+// SaveAlertStateHistoryCommand is the command for recording state transitions of alert instances.
+type SaveAlertStateHistoryCommand struct {
+	Transitions []AlertStateHistory
+}
+
+// ListAlertStateHistoryQuery is the query for listing the state transitions of alert instances, most recent first.
+type ListAlertStateHistoryQuery struct {
+	RuleOrgID int64
+	// RuleUID, when set, only lists the transitions of the alert instances of that rule.
+	RuleUID string
+	// Labels, when set, only lists the transitions of the alert instances with exactly that label set.
+	Labels InstanceLabels
+	From   time.Time
+	To     time.Time
+	Limit  int64
+
+	Result []*AlertStateHistory
+}
+
+// DeleteAlertStateHistoryCommand is the command for deleting the state transitions recorded before OlderThan.
+type DeleteAlertStateHistoryCommand struct {
+	OlderThan time.Time
+
+	DeletedRows int64
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/benbjohnson/clock"
 
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
@@ -22,6 +23,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb"
@@ -29,14 +31,6 @@ import (
 
 const (
 	maxAttempts int64 = 3
-	// scheduler interval
-	// changing this value is discouraged
-	// because this could cause existing alert definition
-	// with intervals that are not exactly divided by this number
-	// not to be evaluated
-	baseIntervalSeconds = 10
-	// default alert definiiton interval
-	defaultIntervalSeconds int64 = 6 * baseIntervalSeconds
 )
 
 // AlertNG is the service for evaluating the condition of an alert definition.
@@ -49,10 +43,16 @@ type AlertNG struct {
 	DataProxy       *datasourceproxy.DatasourceProxyService `inject:""`
 	QuotaService    *quota.QuotaService                     `inject:""`
 	Metrics         *metrics.Metrics                        `inject:""`
-	Alertmanager    *notifier.Alertmanager
 	Log             log.Logger
 	schedule        schedule.ScheduleService
 	stateManager    *state.Manager
+	// annotationHistorian saves the state transitions as annotations in the background.
+	annotationHistorian *state.AnnotationStateHistorian
+	// stateHistorian saves the state history in the background, it is nil when the state history is disabled.
+	stateHistorian *state.DatabaseStateHistorian
+
+	// Alerting notification services
+	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
 }
 
 func init() {
@@ -62,32 +62,54 @@ func init() {
 // Init initializes the AlertingService.
 func (ng *AlertNG) Init() error {
 	ng.Log = log.New("ngalert")
-	ng.stateManager = state.NewManager(ng.Log, ng.Metrics)
-	baseInterval := baseIntervalSeconds * time.Second
+	baseInterval := store.BaseIntervalSeconds * time.Second
 
 	store := &store.DBstore{
 		BaseInterval:           baseInterval,
-		DefaultIntervalSeconds: defaultIntervalSeconds,
+		DefaultIntervalSeconds: store.DefaultIntervalSeconds,
 		SQLStore:               ng.SQLStore,
 		Logger:                 ng.Log,
 	}
 
+	ng.annotationHistorian = state.NewAnnotationStateHistorian(ng.Log, ng.Metrics)
+	historians := []state.Historian{ng.annotationHistorian}
+	if ng.Cfg.StateHistoryEnabled {
+		ng.stateHistorian = state.NewDatabaseStateHistorian(ng.Log, store, ng.Metrics)
+		historians = append(historians, ng.stateHistorian)
+	}
+	ng.stateManager = state.NewManager(ng.Log, ng.Metrics, state.NewHistorian(historians...))
+
 	var err error
-	ng.Alertmanager, err = notifier.New(ng.Cfg, store, ng.Metrics)
+	ng.MultiOrgAlertmanager, err = notifier.NewMultiOrgAlertmanager(ng.Cfg, store, store, ng.Metrics)
 	if err != nil {
 		return err
 	}
 
+	evaluator := eval.Evaluator{Cfg: ng.Cfg}
+	if ng.Cfg.QueryCacheTTL > 0 {
+		evaluator.QueryCache = expr.NewQueryCache(ng.Metrics.Registerer)
+	}
 	schedCfg := schedule.SchedulerCfg{
 		C:             clock.New(),
 		BaseInterval:  baseInterval,
 		Logger:        ng.Log,
 		MaxAttempts:   maxAttempts,
-		Evaluator:     eval.Evaluator{Cfg: ng.Cfg},
+		Evaluator:     evaluator,
 		InstanceStore: store,
 		RuleStore:     store,
-		Notifier:      ng.Alertmanager,
+		Notifier:      ng.MultiOrgAlertmanager,
 		Metrics:       ng.Metrics,
+
+		EvaluationJitter:                      ng.Cfg.EvaluationJitterEnabled,
+		MaxConcurrentEvaluationsPerDatasource: ng.Cfg.MaxConcurrentEvaluationsPerDatasource,
+		QueryCacheTTL:                         ng.Cfg.QueryCacheTTL,
+	}
+	if ng.Cfg.HADistributeRuleEvaluation && len(ng.Cfg.HAPeers) > 0 {
+		schedCfg.Cluster = ng.MultiOrgAlertmanager
+	}
+	if ng.Cfg.RecordingRulesRemoteWriteURL != "" {
+		schedCfg.RecordingWriter = writer.NewPrometheusWriter(ng.Cfg.RecordingRulesRemoteWriteURL, ng.Cfg.RecordingRulesRemoteWriteUser,
+			ng.Cfg.RecordingRulesRemoteWritePassword, ng.Cfg.RecordingRulesRemoteWriteTimeout, log.New("ngalert.writer"))
 	}
 	ng.schedule = schedule.NewScheduler(schedCfg, ng.DataService)
 
@@ -102,8 +124,10 @@ func (ng *AlertNG) Init() error {
 		InstanceStore:   store,
 		RuleStore:       store,
 		AlertingStore:   store,
-		Alertmanager:    ng.Alertmanager,
 		StateManager:    ng.stateManager,
+		HistoryStore:    store,
+
+		MultiOrgAlertmanager: ng.MultiOrgAlertmanager,
 	}
 	api.RegisterAPIEndpoints(ng.Metrics)
 
@@ -120,8 +144,16 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 		return ng.schedule.Ticker(subCtx, ng.stateManager)
 	})
 	children.Go(func() error {
-		return ng.Alertmanager.Run(subCtx)
+		return ng.MultiOrgAlertmanager.Run(subCtx)
 	})
+	children.Go(func() error {
+		return ng.annotationHistorian.Run(subCtx)
+	})
+	if ng.stateHistorian != nil {
+		children.Go(func() error {
+			return ng.stateHistorian.Run(subCtx)
+		})
+	}
 	return children.Wait()
 }
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/prometheus/alertmanager/silence"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/components/securejsondata"
@@ -77,12 +79,16 @@ const (
 type Alertmanager struct {
 	logger      log.Logger
 	gokitLogger gokit_log.Logger
+	orgID       int64
 
 	Settings *setting.Cfg       `inject:""`
 	SQLStore *sqlstore.SQLStore `inject:""`
 	Store    store.AlertingStore
 	Metrics  *metrics.Metrics `inject:""`
 
+	// peer replicates silences and the notification log to the other Grafana instances of the cluster.
+	peer ClusterPeer
+
 	notificationLog *nflog.Log
 	marker          types.Marker
 	alerts          *mem.Alerts
@@ -104,18 +110,28 @@ type Alertmanager struct {
 
 	reloadConfigMtx sync.RWMutex
 	config          []byte
+
+	startTime time.Time
 }
 
-func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Alertmanager, error) {
+// New creates the Alertmanager of a single organization. Silences, the notification log and
+// templates are kept in a working directory that is specific to that organization.
+func New(orgID int64, cfg *setting.Cfg, store store.AlertingStore, peer ClusterPeer, m *metrics.Metrics) (*Alertmanager, error) {
+	// Every organization registers the same Alertmanager metrics, tell them apart with a label.
+	r := prometheus.WrapRegistererWith(prometheus.Labels{"org": strconv.FormatInt(orgID, 10)}, m.Registerer)
+
 	am := &Alertmanager{
 		Settings:          cfg,
 		stopc:             make(chan struct{}),
-		logger:            log.New("alertmanager"),
-		marker:            types.NewMarker(m.Registerer),
-		stageMetrics:      notify.NewMetrics(m.Registerer),
-		dispatcherMetrics: dispatch.NewDispatcherMetrics(m.Registerer),
+		logger:            log.New("alertmanager", "org", orgID),
+		orgID:             orgID,
+		marker:            types.NewMarker(r),
+		stageMetrics:      notify.NewMetrics(r),
+		dispatcherMetrics: dispatch.NewDispatcherMetrics(r),
 		Store:             store,
+		peer:              peer,
 		Metrics:           m,
+		startTime:         time.Now(),
 	}
 
 	am.gokitLogger = gokit_log.NewLogfmtLogger(logging.NewWrapper(am.logger))
@@ -131,15 +147,20 @@ func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Aler
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize the notification log component of alerting: %w", err)
 	}
+	c := am.peer.AddState(fmt.Sprintf("notificationlog:%d", am.orgID), am.notificationLog, r)
+	am.notificationLog.SetBroadcast(c.Broadcast)
+
 	// Initialize silences
 	am.silences, err = silence.New(silence.Options{
-		Metrics:      m.Registerer,
+		Metrics:      r,
 		SnapshotFile: filepath.Join(am.WorkingDirPath(), "silences"),
 		Retention:    retentionNotificationsAndSilences,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize the silencing component of alerting: %w", err)
 	}
+	c = am.peer.AddState(fmt.Sprintf("silences:%d", am.orgID), am.silences, r)
+	am.silences.SetBroadcast(c.Broadcast)
 
 	am.wg.Add(1)
 	go func() {
@@ -156,24 +177,6 @@ func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Aler
 	return am, nil
 }
 
-func (am *Alertmanager) Run(ctx context.Context) error {
-	// Make sure dispatcher starts. We can tolerate future reload failures.
-	if err := am.SyncAndApplyConfigFromDatabase(); err != nil {
-		am.logger.Error("unable to sync configuration", "err", err)
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			return am.StopAndWait()
-		case <-time.After(pollInterval):
-			if err := am.SyncAndApplyConfigFromDatabase(); err != nil {
-				am.logger.Error("unable to sync configuration", "err", err)
-			}
-		}
-	}
-}
-
 func (am *Alertmanager) StopAndWait() error {
 	if am.dispatcher != nil {
 		am.dispatcher.Stop()
@@ -194,6 +197,10 @@ func (am *Alertmanager) StopAndWait() error {
 // SaveAndApplyConfig saves the configuration the database and applies the configuration to the Alertmanager.
 // It rollbacks the save if we fail to apply the configuration.
 func (am *Alertmanager) SaveAndApplyConfig(cfg *apimodels.PostableUserConfig) error {
+	if err := cfg.AlertmanagerConfig.ValidateMuteTimeIntervals(); err != nil {
+		return fmt.Errorf("invalid mute time intervals: %w", err)
+	}
+
 	rawConfig, err := json.Marshal(&cfg)
 	if err != nil {
 		return fmt.Errorf("failed to serialize to the Alertmanager configuration: %w", err)
@@ -205,6 +212,7 @@ func (am *Alertmanager) SaveAndApplyConfig(cfg *apimodels.PostableUserConfig) er
 	cmd := &ngmodels.SaveAlertmanagerConfigurationCmd{
 		AlertmanagerConfiguration: string(rawConfig),
 		ConfigurationVersion:      fmt.Sprintf("v%d", ngmodels.AlertConfigurationVersion),
+		OrgID:                     am.orgID,
 	}
 
 	err = am.Store.SaveAlertmanagerConfigurationWithCallback(cmd, func() error {
@@ -216,7 +224,7 @@ func (am *Alertmanager) SaveAndApplyConfig(cfg *apimodels.PostableUserConfig) er
 	if err != nil {
 		return err
 	}
-	am.Metrics.ActiveConfigurations.Set(1)
+	am.activeConfigurations().Set(1)
 
 	return nil
 }
@@ -228,7 +236,7 @@ func (am *Alertmanager) SyncAndApplyConfigFromDatabase() error {
 	defer am.reloadConfigMtx.Unlock()
 
 	// First, let's get the configuration we need from the database.
-	q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{}
+	q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: am.orgID}
 	if err := am.Store.GetLatestAlertmanagerConfiguration(q); err != nil {
 		// If there's no configuration in the database, let's use the default configuration.
 		if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
@@ -238,12 +246,13 @@ func (am *Alertmanager) SyncAndApplyConfigFromDatabase() error {
 				AlertmanagerConfiguration: alertmanagerDefaultConfiguration,
 				Default:                   true,
 				ConfigurationVersion:      fmt.Sprintf("v%d", ngmodels.AlertConfigurationVersion),
+				OrgID:                     am.orgID,
 			}
 			if err := am.Store.SaveAlertmanagerConfiguration(savecmd); err != nil {
 				return err
 			}
 
-			q.Result = &ngmodels.AlertConfiguration{AlertmanagerConfiguration: alertmanagerDefaultConfiguration, Default: true}
+			q.Result = &ngmodels.AlertConfiguration{AlertmanagerConfiguration: alertmanagerDefaultConfiguration, Default: true, OrgID: am.orgID}
 		} else {
 			return fmt.Errorf("unable to get Alertmanager configuration from the database: %w", err)
 		}
@@ -259,9 +268,9 @@ func (am *Alertmanager) SyncAndApplyConfigFromDatabase() error {
 	}
 
 	if q.Result.Default {
-		am.Metrics.ActiveConfigurations.Set(0)
+		am.activeConfigurations().Set(0)
 	} else {
-		am.Metrics.ActiveConfigurations.Set(1)
+		am.activeConfigurations().Set(1)
 	}
 
 	return nil
@@ -318,6 +327,10 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	if err != nil {
 		return err
 	}
+	muteTimes, err := buildMuteTimesMap(cfg.AlertmanagerConfig.MuteTimeIntervals)
+	if err != nil {
+		return err
+	}
 	// Now, let's put together our notification pipeline
 	routingStage := make(notify.RoutingStage, len(integrationsMap))
 
@@ -333,13 +346,15 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 
 	inhibitionStage := notify.NewMuteStage(am.inhibitor)
 	silencingStage := notify.NewMuteStage(am.silencer)
+	timeMuteStage := newTimeMuteStage(muteTimes)
+	gossipSettleStage := newGossipSettleStage(am.peer)
 	for name := range integrationsMap {
-		stage := am.createReceiverStage(name, integrationsMap[name], waitFunc, am.notificationLog)
-		routingStage[name] = notify.MultiStage{silencingStage, inhibitionStage, stage}
+		stage := am.createReceiverStage(name, integrationsMap[name], am.waitFunc, am.notificationLog, timeMuteStage)
+		routingStage[name] = notify.MultiStage{gossipSettleStage, silencingStage, inhibitionStage, stage}
 	}
 
 	am.route = dispatch.NewRoute(cfg.AlertmanagerConfig.Route, nil)
-	am.dispatcher = dispatch.NewDispatcher(am.alerts, am.route, routingStage, am.marker, timeoutFunc, am.gokitLogger, am.dispatcherMetrics)
+	am.dispatcher = dispatch.NewDispatcher(am.alerts, am.route, routingStage, am.marker, am.timeoutFunc, am.gokitLogger, am.dispatcherMetrics)
 
 	am.wg.Add(1)
 	go func() {
@@ -357,8 +372,18 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	return nil
 }
 
+// WorkingDirPath returns the directory where the Alertmanager of the organization persists its state.
 func (am *Alertmanager) WorkingDirPath() string {
-	return filepath.Join(am.Settings.DataPath, workingDir)
+	return filepath.Join(am.Settings.DataPath, workingDir, strconv.FormatInt(am.orgID, 10))
+}
+
+// OrgID returns the ID of the organization the Alertmanager belongs to.
+func (am *Alertmanager) OrgID() int64 {
+	return am.orgID
+}
+
+func (am *Alertmanager) activeConfigurations() prometheus.Gauge {
+	return am.Metrics.ActiveConfigurations.WithLabelValues(strconv.FormatInt(am.orgID, 10))
 }
 
 // buildIntegrationsMap builds a map of name to the list of Grafana integration notifiers off of a list of receiver config.
@@ -547,7 +572,8 @@ func (e AlertValidationError) Error() string {
 }
 
 // createReceiverStage creates a pipeline of stages for a receiver.
-func (am *Alertmanager) createReceiverStage(name string, integrations []notify.Integration, wait func() time.Duration, notificationLog notify.NotificationLog) notify.Stage {
+// Notifications of routes that are within one of their mute time intervals are dropped before reaching any integration.
+func (am *Alertmanager) createReceiverStage(name string, integrations []notify.Integration, wait func() time.Duration, notificationLog notify.NotificationLog, timeMuteStage notify.Stage) notify.Stage {
 	var fs notify.FanoutStage
 	for i := range integrations {
 		recv := &nflogpb.Receiver{
@@ -563,17 +589,37 @@ func (am *Alertmanager) createReceiverStage(name string, integrations []notify.I
 
 		fs = append(fs, s)
 	}
-	return fs
+	return notify.MultiStage{timeMuteStage, fs}
 }
 
-func waitFunc() time.Duration {
-	return setting.AlertingNotificationTimeout
+// waitFunc returns how long to wait before sending a notification. In HA, every instance also waits
+// according to its position in the cluster so that the first one gets to notify and replicate the notification log.
+func (am *Alertmanager) waitFunc() time.Duration {
+	return setting.AlertingNotificationTimeout + time.Duration(am.peer.Position())*am.Settings.HAPeerTimeout
 }
 
-func timeoutFunc(d time.Duration) time.Duration {
+func (am *Alertmanager) timeoutFunc(d time.Duration) time.Duration {
 	//TODO: What does MinTimeout means here?
 	if d < notify.MinTimeout {
 		d = notify.MinTimeout
 	}
-	return d + waitFunc()
+	return d + am.waitFunc()
+}
+
+// gossipSettleStage holds the notifications until the gossip mesh has settled, so that the silences
+// and the notification log of the other instances are known before notifying.
+type gossipSettleStage struct {
+	peer ClusterPeer
+}
+
+func newGossipSettleStage(peer ClusterPeer) *gossipSettleStage {
+	return &gossipSettleStage{peer: peer}
+}
+
+// Exec implements the notify.Stage interface.
+func (gss *gossipSettleStage) Exec(ctx context.Context, _ gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if err := gss.peer.WaitReady(ctx); err != nil {
+		return ctx, nil, err
+	}
+	return ctx, alerts, nil
 }
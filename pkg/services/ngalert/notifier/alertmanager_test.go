@@ -47,7 +47,7 @@ func setupAMTest(t *testing.T) *Alertmanager {
 		Logger:                 log.New("alertmanager-test"),
 	}
 
-	am, err := New(cfg, store, m)
+	am, err := New(1, cfg, store, &NilPeer{}, m)
 	require.NoError(t, err)
 	return am
 }
@@ -310,3 +310,58 @@ func TestPutAlert(t *testing.T) {
 		})
 	}
 }
+
+// fakePeer is a ClusterPeer at a given position, which is ready once ready is closed.
+type fakePeer struct {
+	NilPeer
+	position int
+	ready    chan struct{}
+}
+
+func (p *fakePeer) Position() int { return p.position }
+
+func (p *fakePeer) WaitReady(ctx context.Context) error {
+	select {
+	case <-p.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestAlertmanager_WaitFunc(t *testing.T) {
+	am := setupAMTest(t)
+	defer func(timeout time.Duration) { setting.AlertingNotificationTimeout = timeout }(setting.AlertingNotificationTimeout)
+	setting.AlertingNotificationTimeout = 30 * time.Second
+	am.Settings.HAPeerTimeout = 15 * time.Second
+
+	// Without high availability, the notification timeout is kept.
+	require.Equal(t, 30*time.Second, am.waitFunc())
+
+	// In high availability, every instance waits after the ones before it.
+	am.peer = &fakePeer{position: 2}
+	require.Equal(t, time.Minute, am.waitFunc())
+}
+
+func TestGossipSettleStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}}}
+
+	_, res, err := newGossipSettleStage(&NilPeer{}).Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+
+	peer := &fakePeer{ready: make(chan struct{})}
+	stage := newGossipSettleStage(peer)
+
+	// The notifications are held while the mesh settles.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, res, err = stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Empty(t, res)
+
+	close(peer.ready)
+	_, res, err = stage.Exec(context.Background(), gokit_log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+}
@@ -2,6 +2,7 @@ package channels
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"path"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/logging"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
 type ExtendedAlert struct {
@@ -30,6 +32,8 @@ type ExtendedAlert struct {
 	SilenceURL   string      `json:"silenceURL"`
 	DashboardURL string      `json:"dashboardURL"`
 	PanelURL     string      `json:"panelURL"`
+	// Values are the values of the reduced expressions and queries of the alert rule keyed by RefID.
+	Values ngmodels.InstanceValues `json:"values,omitempty"`
 }
 
 type ExtendedAlerts []ExtendedAlert
@@ -96,6 +100,13 @@ func extendAlert(alert template.Alert, externalURL string) (*ExtendedAlert, erro
 		extended.SilenceURL = u.String()
 	}
 
+	// alerts posted to the Alertmanager API can have any annotation, the values are ignored if they are not valid
+	if values, ok := alert.Annotations[ngmodels.ValuesAnnotation]; ok {
+		if err := json.Unmarshal([]byte(values), &extended.Values); err != nil {
+			extended.Values = nil
+		}
+	}
+
 	// remove "private" annotations & labels so they don't show up in the template
 	extended.Annotations = removePrivateItems(extended.Annotations)
 	extended.Labels = removePrivateItems(extended.Labels)
@@ -0,0 +1,369 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/logging"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// MultiOrgAlertmanager manages one Alertmanager per organization.
+// Every organization has its own configuration, silences and notification log.
+type MultiOrgAlertmanager struct {
+	alertmanagersMtx sync.RWMutex
+	alertmanagers    map[int64]*Alertmanager
+	// initialSyncs guards the first load of the configuration of each Alertmanager, which is done
+	// without holding alertmanagersMtx so that it doesn't block the lookups of the other organizations.
+	initialSyncs map[int64]*sync.Once
+
+	settings *setting.Cfg
+	logger   log.Logger
+
+	configStore store.AlertingStore
+	orgStore    store.OrgStore
+
+	// peer is shared by the Alertmanagers of all organizations, each of them gossips its own silences and notification log.
+	peer         ClusterPeer
+	settleCancel context.CancelFunc
+
+	metrics *metrics.Metrics
+}
+
+func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore store.AlertingStore, orgStore store.OrgStore, m *metrics.Metrics) (*MultiOrgAlertmanager, error) {
+	moa := &MultiOrgAlertmanager{
+		settings:      cfg,
+		logger:        log.New("multiorg.alertmanager"),
+		alertmanagers: map[int64]*Alertmanager{},
+		initialSyncs:  map[int64]*sync.Once{},
+		configStore:   configStore,
+		orgStore:      orgStore,
+		peer:          &NilPeer{},
+		settleCancel:  func() {},
+		metrics:       m,
+	}
+
+	moa.migrateLegacyState()
+
+	// High availability is only enabled when peers are configured.
+	if len(cfg.HAPeers) > 0 {
+		l := gokit_log.NewLogfmtLogger(logging.NewWrapper(moa.logger))
+		peer, err := cluster.Create(
+			gokit_log.With(l, "component", "cluster"),
+			m.Registerer,
+			cfg.HAListenAddr,
+			cfg.HAAdvertiseAddr,
+			cfg.HAPeers,
+			true,
+			cfg.HAPushPullInterval,
+			cfg.HAGossipInterval,
+			cluster.DefaultTcpTimeout,
+			cluster.DefaultProbeTimeout,
+			cluster.DefaultProbeInterval,
+			nil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize gossip mesh: %w", err)
+		}
+
+		if err := peer.Join(cluster.DefaultReconnectInterval, cluster.DefaultReconnectTimeout); err != nil {
+			moa.logger.Error("unable to join gossip mesh while initializing cluster for high availability mode", "err", err)
+		}
+
+		// Wait for the number of peers to stabilise before the position of this instance is used to delay notifications.
+		var ctx context.Context
+		ctx, moa.settleCancel = context.WithCancel(context.Background())
+		go peer.Settle(ctx, cluster.DefaultGossipInterval*10)
+		moa.peer = peer
+	}
+
+	return moa, nil
+}
+
+// mainOrgID is the organization created when Grafana first starts.
+const mainOrgID int64 = 1
+
+// legacyStateFiles are the files of the single Alertmanager that ran before every organization got
+// its own Alertmanager, they were kept at the root of the working directory.
+var legacyStateFiles = []string{"silences", "notifications"}
+
+// migrateLegacyState moves the silences and the notification log of the single Alertmanager that ran
+// before every organization got its own Alertmanager to the working directory of the main organization.
+// When the main organization already has its own, the legacy files are orphaned and removed.
+func (moa *MultiOrgAlertmanager) migrateLegacyState() {
+	dir := filepath.Join(moa.settings.DataPath, workingDir, strconv.FormatInt(mainOrgID, 10))
+	for _, name := range legacyStateFiles {
+		legacyPath := filepath.Join(moa.settings.DataPath, workingDir, name)
+		if _, err := os.Stat(legacyPath); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				moa.logger.Error("unable to check the legacy Alertmanager state", "file", legacyPath, "err", err)
+			}
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			moa.logger.Warn("removing the legacy Alertmanager state as the main organization has its own", "file", legacyPath)
+			if err := os.Remove(legacyPath); err != nil {
+				moa.logger.Error("unable to remove the legacy Alertmanager state", "file", legacyPath, "err", err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			moa.logger.Error("unable to create the working directory of the main organization", "dir", dir, "err", err)
+			return
+		}
+		if err := os.Rename(legacyPath, path); err != nil {
+			moa.logger.Error("unable to move the legacy Alertmanager state to the main organization", "file", legacyPath, "err", err)
+			continue
+		}
+		moa.logger.Info("moved the legacy Alertmanager state to the main organization", "file", path, "org", mainOrgID)
+	}
+}
+
+// Run keeps the Alertmanagers in sync with the organizations and their configurations until the context is cancelled.
+func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
+	moa.logger.Info("starting MultiOrg Alertmanager")
+
+	// Make sure dispatchers start. We can tolerate future reload failures.
+	if err := moa.LoadAndSyncAlertmanagersForOrgs(); err != nil {
+		moa.logger.Error("unable to sync Alertmanagers for organizations", "err", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			moa.StopAndWait()
+			return nil
+		case <-time.After(pollInterval):
+			if err := moa.LoadAndSyncAlertmanagersForOrgs(); err != nil {
+				moa.logger.Error("unable to sync Alertmanagers for organizations", "err", err)
+			}
+		}
+	}
+}
+
+// LoadAndSyncAlertmanagersForOrgs fetches the organizations from the database and syncs their Alertmanagers.
+func (moa *MultiOrgAlertmanager) LoadAndSyncAlertmanagersForOrgs() error {
+	orgIDs, err := moa.orgStore.GetOrgs()
+	if err != nil {
+		return err
+	}
+
+	moa.SyncAlertmanagersForOrgs(orgIDs)
+	return nil
+}
+
+// SyncAlertmanagersForOrgs creates the Alertmanagers of the organizations that don't have one yet,
+// applies the latest configuration of each organization and stops the Alertmanagers of the organizations that no longer exist.
+func (moa *MultiOrgAlertmanager) SyncAlertmanagersForOrgs(orgIDs []int64) {
+	orgsFound := make(map[int64]struct{}, len(orgIDs))
+	for _, orgID := range orgIDs {
+		orgsFound[orgID] = struct{}{}
+
+		am, synced, err := moa.alertmanagerFor(orgID)
+		if err != nil {
+			moa.logger.Error("unable to create Alertmanager for org", "org", orgID, "err", err)
+			continue
+		}
+
+		// A new Alertmanager has just loaded its configuration.
+		if !synced {
+			moa.syncAndApplyConfig(orgID, am)
+		}
+	}
+
+	amsToStop := map[int64]*Alertmanager{}
+	moa.alertmanagersMtx.Lock()
+	for orgID, am := range moa.alertmanagers {
+		if _, exists := orgsFound[orgID]; !exists {
+			amsToStop[orgID] = am
+			delete(moa.alertmanagers, orgID)
+			delete(moa.initialSyncs, orgID)
+		}
+	}
+	moa.alertmanagersMtx.Unlock()
+
+	// Stopping an Alertmanager waits for its components to finish, do it without holding the lock.
+	for orgID, am := range amsToStop {
+		moa.logger.Info("stopping Alertmanager", "org", orgID)
+		if err := am.StopAndWait(); err != nil {
+			moa.logger.Error("unable to stop Alertmanager", "org", orgID, "err", err)
+		}
+	}
+}
+
+// AlertmanagerFor returns the Alertmanager of the organization.
+// The Alertmanager is created, and its configuration is loaded from the database, the first time it is requested.
+func (moa *MultiOrgAlertmanager) AlertmanagerFor(orgID int64) (*Alertmanager, error) {
+	am, _, err := moa.alertmanagerFor(orgID)
+	return am, err
+}
+
+// alertmanagerFor returns the Alertmanager of the organization, and whether this call loaded its configuration.
+// The callers requesting a new Alertmanager wait for its configuration to be loaded, without blocking
+// the callers requesting the Alertmanagers of other organizations.
+func (moa *MultiOrgAlertmanager) alertmanagerFor(orgID int64) (*Alertmanager, bool, error) {
+	am, initialSync, err := moa.getOrCreateAlertmanager(orgID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	synced := false
+	initialSync.Do(func() {
+		synced = true
+		moa.syncAndApplyConfig(orgID, am)
+	})
+	return am, synced, nil
+}
+
+func (moa *MultiOrgAlertmanager) getOrCreateAlertmanager(orgID int64) (*Alertmanager, *sync.Once, error) {
+	moa.alertmanagersMtx.RLock()
+	am, ok := moa.alertmanagers[orgID]
+	initialSync := moa.initialSyncs[orgID]
+	moa.alertmanagersMtx.RUnlock()
+	if ok {
+		return am, initialSync, nil
+	}
+
+	moa.alertmanagersMtx.Lock()
+	defer moa.alertmanagersMtx.Unlock()
+
+	// Someone else might have created it while we were waiting for the lock.
+	if am, ok := moa.alertmanagers[orgID]; ok {
+		return am, moa.initialSyncs[orgID], nil
+	}
+
+	am, err := New(orgID, moa.settings, moa.configStore, moa.peer, moa.metrics)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create Alertmanager for org %d: %w", orgID, err)
+	}
+
+	initialSync = &sync.Once{}
+	moa.alertmanagers[orgID] = am
+	moa.initialSyncs[orgID] = initialSync
+	return am, initialSync, nil
+}
+
+func (moa *MultiOrgAlertmanager) syncAndApplyConfig(orgID int64, am *Alertmanager) {
+	// Make sure the dispatcher starts. We can tolerate future reload failures.
+	if err := am.SyncAndApplyConfigFromDatabase(); err != nil {
+		moa.logger.Error("unable to sync configuration", "org", orgID, "err", err)
+	}
+}
+
+// PutAlerts sends the alerts to the Alertmanager of the organization they belong to.
+func (moa *MultiOrgAlertmanager) PutAlerts(orgID int64, alerts apimodels.PostableAlerts) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+
+	return am.PutAlerts(alerts)
+}
+
+// StopAndWait stops the Alertmanagers of all the organizations.
+func (moa *MultiOrgAlertmanager) StopAndWait() {
+	moa.alertmanagersMtx.Lock()
+	defer moa.alertmanagersMtx.Unlock()
+
+	var wg sync.WaitGroup
+	for orgID, am := range moa.alertmanagers {
+		wg.Add(1)
+		go func(orgID int64, am *Alertmanager) {
+			defer wg.Done()
+			if err := am.StopAndWait(); err != nil {
+				moa.logger.Error("unable to stop Alertmanager", "org", orgID, "err", err)
+			}
+		}(orgID, am)
+	}
+	wg.Wait()
+	moa.alertmanagers = map[int64]*Alertmanager{}
+	moa.initialSyncs = map[int64]*sync.Once{}
+
+	if p, ok := moa.peer.(*cluster.Peer); ok {
+		moa.settleCancel()
+		if err := p.Leave(10 * time.Second); err != nil {
+			moa.logger.Warn("unable to leave the gossip mesh", "err", err)
+		}
+		// Leaving the mesh is only possible once, don't try it again on subsequent calls.
+		moa.peer = &NilPeer{}
+	}
+}
+
+// ClusterName returns the name of this instance in the gossip mesh, it is empty when high availability is disabled.
+func (moa *MultiOrgAlertmanager) ClusterName() string {
+	moa.alertmanagersMtx.RLock()
+	defer moa.alertmanagersMtx.RUnlock()
+
+	if p, ok := moa.peer.(*cluster.Peer); ok {
+		return p.Name()
+	}
+	return ""
+}
+
+// ClusterMembers returns the names of the live instances of the gossip mesh, including this one.
+// It returns nil when high availability is disabled.
+func (moa *MultiOrgAlertmanager) ClusterMembers() []string {
+	moa.alertmanagersMtx.RLock()
+	defer moa.alertmanagersMtx.RUnlock()
+
+	p, ok := moa.peer.(*cluster.Peer)
+	if !ok {
+		return nil
+	}
+	peers := p.Peers()
+	members := make([]string, 0, len(peers))
+	for _, n := range peers {
+		members = append(members, n.Name)
+	}
+	return members
+}
+
+// ClusterReady returns true once the members of the gossip mesh have settled, it is always true when high
+// availability is disabled.
+func (moa *MultiOrgAlertmanager) ClusterReady() bool {
+	moa.alertmanagersMtx.RLock()
+	defer moa.alertmanagersMtx.RUnlock()
+
+	if p, ok := moa.peer.(*cluster.Peer); ok {
+		return p.Ready()
+	}
+	return true
+}
+
+// ClusterPeer is the gossip mesh member used to replicate silences and the notification log across Grafana instances.
+type ClusterPeer interface {
+	AddState(string, cluster.State, prometheus.Registerer) cluster.ClusterChannel
+	Position() int
+	WaitReady(context.Context) error
+}
+
+// NilPeer is the ClusterPeer used when high availability is disabled.
+type NilPeer struct{}
+
+func (p *NilPeer) Position() int                   { return 0 }
+func (p *NilPeer) WaitReady(context.Context) error { return nil }
+func (p *NilPeer) AddState(string, cluster.State, prometheus.Registerer) cluster.ClusterChannel {
+	return &NilChannel{}
+}
+
+// NilChannel is the cluster channel of a NilPeer, broadcasts go nowhere.
+type NilChannel struct{}
+
+func (c *NilChannel) Broadcast([]byte) {}
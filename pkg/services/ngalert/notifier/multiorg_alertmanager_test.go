@@ -0,0 +1,256 @@
+package notifier
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeOrgStore struct {
+	orgs []int64
+}
+
+func (f *fakeOrgStore) GetOrgs() ([]int64, error) {
+	return f.orgs, nil
+}
+
+// countingConfigStore counts the configuration loads of each organization.
+type countingConfigStore struct {
+	*store.DBstore
+
+	mtx   sync.Mutex
+	loads map[int64]int
+}
+
+func (s *countingConfigStore) GetLatestAlertmanagerConfiguration(q *ngmodels.GetLatestAlertmanagerConfigurationQuery) error {
+	s.mtx.Lock()
+	s.loads[q.OrgID]++
+	s.mtx.Unlock()
+	return s.DBstore.GetLatestAlertmanagerConfiguration(q)
+}
+
+func (s *countingConfigStore) loadsOf(orgID int64) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.loads[orgID]
+}
+
+func setupMultiOrgAMTest(t *testing.T, orgStore store.OrgStore) (*MultiOrgAlertmanager, *store.DBstore, string) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+	cfg := &setting.Cfg{
+		DataPath: dir,
+	}
+
+	sqlStore := sqlstore.InitTestDB(t)
+	configStore := &store.DBstore{
+		BaseInterval:           10 * time.Second,
+		DefaultIntervalSeconds: 60,
+		SQLStore:               sqlStore,
+		Logger:                 log.New("multiorg-alertmanager-test"),
+	}
+
+	mam, err := NewMultiOrgAlertmanager(cfg, configStore, orgStore, metrics.NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(mam.StopAndWait)
+	return mam, configStore, dir
+}
+
+func TestMultiOrgAlertmanager_SyncAlertmanagersForOrgs(t *testing.T) {
+	orgStore := &fakeOrgStore{orgs: []int64{1, 2, 3}}
+	mam, configStore, dir := setupMultiOrgAMTest(t, orgStore)
+
+	// Ensure that one Alertmanager is created per organization.
+	require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs())
+	require.Len(t, mam.alertmanagers, 3)
+
+	for _, orgID := range orgStore.orgs {
+		am, err := mam.AlertmanagerFor(orgID)
+		require.NoError(t, err)
+		require.Equal(t, orgID, am.OrgID())
+		require.Equal(t, filepath.Join(dir, "alerting", strconv.FormatInt(orgID, 10)), am.WorkingDirPath())
+
+		// Each organization gets its own default configuration.
+		q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID}
+		require.NoError(t, configStore.GetLatestAlertmanagerConfiguration(q))
+		require.True(t, q.Result.Default)
+		require.Equal(t, orgID, q.Result.OrgID)
+	}
+
+	// When an organization is removed, its Alertmanager is stopped and removed.
+	orgStore.orgs = []int64{1, 3}
+	require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs())
+	require.Len(t, mam.alertmanagers, 2)
+	require.NotContains(t, mam.alertmanagers, int64(2))
+}
+
+func TestMultiOrgAlertmanager_MigrateLegacyState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+	alertingDir := filepath.Join(dir, "alerting")
+	mainOrgDir := filepath.Join(alertingDir, "1")
+	require.NoError(t, os.MkdirAll(mainOrgDir, 0750))
+
+	// The silences of the single Alertmanager are moved to the main organization.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(alertingDir, "silences"), []byte("legacy silences"), 0600))
+	// The main organization already has a notification log, the legacy one is removed.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(alertingDir, "notifications"), []byte("legacy notifications"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(mainOrgDir, "notifications"), []byte("notifications"), 0600))
+
+	mam, err := NewMultiOrgAlertmanager(&setting.Cfg{DataPath: dir}, nil, &fakeOrgStore{}, metrics.NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(mam.StopAndWait)
+
+	silences, err := ioutil.ReadFile(filepath.Join(mainOrgDir, "silences"))
+	require.NoError(t, err)
+	require.Equal(t, "legacy silences", string(silences))
+	notifications, err := ioutil.ReadFile(filepath.Join(mainOrgDir, "notifications"))
+	require.NoError(t, err)
+	require.Equal(t, "notifications", string(notifications))
+
+	for _, name := range []string{"silences", "notifications"} {
+		_, err := os.Stat(filepath.Join(alertingDir, name))
+		require.True(t, os.IsNotExist(err), name)
+	}
+}
+
+func TestMultiOrgAlertmanager_AlertmanagerFor(t *testing.T) {
+	mam, configStore, _ := setupMultiOrgAMTest(t, &fakeOrgStore{})
+
+	// The Alertmanager of an organization is created the first time it's requested.
+	am, err := mam.AlertmanagerFor(5)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), am.OrgID())
+	require.NotNil(t, am.config)
+
+	q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: 5}
+	require.NoError(t, configStore.GetLatestAlertmanagerConfiguration(q))
+
+	// Subsequent calls return the same instance.
+	again, err := mam.AlertmanagerFor(5)
+	require.NoError(t, err)
+	require.Same(t, am, again)
+
+	// Other organizations don't see its configuration.
+	q = &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: 6}
+	require.ErrorIs(t, configStore.GetLatestAlertmanagerConfiguration(q), store.ErrNoAlertmanagerConfiguration)
+}
+
+func TestMultiOrgAlertmanager_ConfigurationLoadedOnce(t *testing.T) {
+	orgStore := &fakeOrgStore{orgs: []int64{1}}
+	mam, dbStore, _ := setupMultiOrgAMTest(t, orgStore)
+	configStore := &countingConfigStore{DBstore: dbStore, loads: map[int64]int{}}
+	mam.configStore = configStore
+
+	// Concurrent first requests share the Alertmanager, which loads its configuration once.
+	var wg sync.WaitGroup
+	ams := make([]*Alertmanager, 5)
+	for i := range ams {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			am, err := mam.AlertmanagerFor(1)
+			require.NoError(t, err)
+			require.NotNil(t, am.config)
+			ams[i] = am
+		}(i)
+	}
+	wg.Wait()
+	for _, am := range ams {
+		require.Same(t, ams[0], am)
+	}
+	require.Equal(t, 1, configStore.loadsOf(1))
+
+	// Syncing a new organization loads its configuration once, existing ones are reloaded.
+	orgStore.orgs = []int64{1, 2}
+	require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs())
+	require.Equal(t, 2, configStore.loadsOf(1))
+	require.Equal(t, 1, configStore.loadsOf(2))
+}
+
+func TestMultiOrgAlertmanager_GetStatus(t *testing.T) {
+	mam, _, _ := setupMultiOrgAMTest(t, &fakeOrgStore{})
+
+	// Without peers, high availability is disabled.
+	require.IsType(t, &NilPeer{}, mam.peer)
+
+	am, err := mam.AlertmanagerFor(1)
+	require.NoError(t, err)
+
+	status := am.GetStatus()
+	require.NotNil(t, status.Cluster)
+	require.Equal(t, "disabled", *status.Cluster.Status)
+	require.Empty(t, status.Cluster.Peers)
+	require.NotEmpty(t, *status.Config.Original)
+}
+
+func TestMultiOrgAlertmanager_HighAvailability(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+	cfg := &setting.Cfg{
+		DataPath:           dir,
+		HAListenAddr:       "127.0.0.1:0",
+		HAPeers:            []string{"127.0.0.1:0"},
+		HAPeerTimeout:      15 * time.Second,
+		HAGossipInterval:   cluster.DefaultGossipInterval,
+		HAPushPullInterval: cluster.DefaultPushPullInterval,
+	}
+	configStore := &store.DBstore{
+		BaseInterval:           10 * time.Second,
+		DefaultIntervalSeconds: 60,
+		SQLStore:               sqlstore.InitTestDB(t),
+		Logger:                 log.New("multiorg-alertmanager-test"),
+	}
+
+	mam, err := NewMultiOrgAlertmanager(cfg, configStore, &fakeOrgStore{}, metrics.NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(mam.StopAndWait)
+
+	// With peers, the Alertmanagers share a memberlist peer.
+	peer, ok := mam.peer.(*cluster.Peer)
+	require.True(t, ok)
+	require.NotEmpty(t, mam.ClusterName())
+	require.Contains(t, mam.ClusterMembers(), mam.ClusterName())
+
+	// The peer is ready once the mesh has settled.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	require.NoError(t, peer.WaitReady(ctx))
+	require.True(t, mam.ClusterReady())
+
+	am, err := mam.AlertmanagerFor(1)
+	require.NoError(t, err)
+	status := am.GetStatus()
+	require.Equal(t, "ready", *status.Cluster.Status)
+	require.Equal(t, mam.ClusterName(), status.Cluster.Name)
+	require.Len(t, status.Cluster.Peers, 1)
+
+	// The only member of the mesh notifies first.
+	require.Equal(t, 0, peer.Position())
+	require.Equal(t, setting.AlertingNotificationTimeout, am.waitFunc())
+}
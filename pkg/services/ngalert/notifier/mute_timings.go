@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/alertmanager/types"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// muteTimeInterval is a time interval together with the location it is evaluated in.
+type muteTimeInterval struct {
+	timeinterval.TimeInterval
+	location *time.Location
+}
+
+func (mt muteTimeInterval) ContainsTime(t time.Time) bool {
+	return mt.TimeInterval.ContainsTime(t.In(mt.location))
+}
+
+// buildMuteTimesMap builds a map of name to the list of parsed time intervals off of a list of mute time intervals.
+func buildMuteTimesMap(muteTimeIntervals []apimodels.MuteTimeInterval) (map[string][]muteTimeInterval, error) {
+	muteTimes := make(map[string][]muteTimeInterval, len(muteTimeIntervals))
+	for _, mt := range muteTimeIntervals {
+		intervals := make([]muteTimeInterval, 0, len(mt.TimeIntervals))
+		for _, ti := range mt.TimeIntervals {
+			parsed, loc, err := ti.Parse()
+			if err != nil {
+				return nil, fmt.Errorf("invalid mute time interval %q: %w", mt.Name, err)
+			}
+			intervals = append(intervals, muteTimeInterval{TimeInterval: parsed, location: loc})
+		}
+		muteTimes[mt.Name] = intervals
+	}
+	return muteTimes, nil
+}
+
+// timeMuteStage filters out all the alerts of a route when the current time is within one of the mute time intervals of the route.
+type timeMuteStage struct {
+	muteTimes map[string][]muteTimeInterval
+}
+
+func newTimeMuteStage(muteTimes map[string][]muteTimeInterval) *timeMuteStage {
+	return &timeMuteStage{muteTimes: muteTimes}
+}
+
+// Exec implements the notify.Stage interface.
+func (tms *timeMuteStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	names, ok := notify.MuteTimeIntervalNames(ctx)
+	if !ok {
+		return ctx, alerts, nil
+	}
+	now, ok := notify.Now(ctx)
+	if !ok {
+		return ctx, alerts, errors.New("missing now timestamp")
+	}
+
+	for _, name := range names {
+		intervals, ok := tms.muteTimes[name]
+		if !ok {
+			return ctx, alerts, fmt.Errorf("mute time interval %q doesn't exist in config", name)
+		}
+		for _, mt := range intervals {
+			if mt.ContainsTime(now) {
+				level.Debug(l).Log("msg", "notifications not sent, route is within mute time", "mute_time_interval", name)
+				return ctx, nil, nil
+			}
+		}
+	}
+
+	return ctx, alerts, nil
+}
@@ -0,0 +1,99 @@
+package notifier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+func TestTimeMuteStage(t *testing.T) {
+	muteTimes, err := buildMuteTimesMap([]apimodels.MuteTimeInterval{
+		{
+			Name: "weekends",
+			TimeIntervals: []apimodels.TimeInterval{
+				{Weekdays: []string{"saturday", "sunday"}},
+			},
+		},
+		{
+			Name: "outside-business-hours",
+			TimeIntervals: []apimodels.TimeInterval{
+				{Times: []apimodels.TimeRange{{StartTime: "00:00", EndTime: "09:00"}}, Location: "Europe/Berlin"},
+				{Times: []apimodels.TimeRange{{StartTime: "17:00", EndTime: "24:00"}}, Location: "Europe/Berlin"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	stage := newTimeMuteStage(muteTimes)
+
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}}}
+
+	tc := []struct {
+		name     string
+		now      time.Time
+		routeMTs []string
+		muted    bool
+	}{
+		{
+			name:     "route without mute time intervals is never muted",
+			now:      time.Date(2021, 6, 5, 12, 0, 0, 0, time.UTC), // Saturday
+			routeMTs: nil,
+			muted:    false,
+		},
+		{
+			name:     "route is muted during weekends",
+			now:      time.Date(2021, 6, 5, 12, 0, 0, 0, time.UTC), // Saturday
+			routeMTs: []string{"weekends"},
+			muted:    true,
+		},
+		{
+			name:     "route is not muted during the week",
+			now:      time.Date(2021, 6, 7, 12, 0, 0, 0, time.UTC), // Monday
+			routeMTs: []string{"weekends"},
+			muted:    false,
+		},
+		{
+			name:     "time is evaluated in the location of the interval",
+			now:      time.Date(2021, 6, 7, 15, 30, 0, 0, time.UTC), // 17:30 in Berlin
+			routeMTs: []string{"weekends", "outside-business-hours"},
+			muted:    true,
+		},
+		{
+			name:     "route is not muted during business hours",
+			now:      time.Date(2021, 6, 7, 8, 30, 0, 0, time.UTC), // 10:30 in Berlin
+			routeMTs: []string{"weekends", "outside-business-hours"},
+			muted:    false,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := notify.WithNow(context.Background(), c.now)
+			if c.routeMTs != nil {
+				ctx = notify.WithMuteTimeIntervals(ctx, c.routeMTs)
+			}
+
+			_, res, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+			require.NoError(t, err)
+			if c.muted {
+				require.Empty(t, res)
+			} else {
+				require.Equal(t, alerts, res)
+			}
+		})
+	}
+
+	t.Run("unknown mute time interval is an error", func(t *testing.T) {
+		ctx := notify.WithNow(context.Background(), time.Now())
+		ctx = notify.WithMuteTimeIntervals(ctx, []string{"unknown"})
+		_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cluster"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// GetStatus returns the status of the Alertmanager, including the state of the gossip mesh
+// it uses to replicate silences and the notification log when running in high availability mode.
+func (am *Alertmanager) GetStatus() apimodels.GettableStatus {
+	am.reloadConfigMtx.RLock()
+	config := string(am.config)
+	am.reloadConfigMtx.RUnlock()
+
+	uptime := strfmt.DateTime(am.startTime)
+	return apimodels.GettableStatus{
+		Cluster:     am.clusterStatus(),
+		Config:      &amv2.AlertmanagerConfig{Original: &config},
+		Uptime:      &uptime,
+		VersionInfo: versionInfo(),
+	}
+}
+
+func (am *Alertmanager) clusterStatus() *amv2.ClusterStatus {
+	peer, ok := am.peer.(*cluster.Peer)
+	if !ok {
+		status := amv2.ClusterStatusStatusDisabled
+		return &amv2.ClusterStatus{Status: &status, Peers: []*amv2.PeerStatus{}}
+	}
+
+	status := peer.Status()
+	peers := make([]*amv2.PeerStatus, 0, len(peer.Peers()))
+	for _, n := range peer.Peers() {
+		name, address := n.Name, n.Address()
+		peers = append(peers, &amv2.PeerStatus{Name: &name, Address: &address})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return *peers[i].Name < *peers[j].Name
+	})
+
+	return &amv2.ClusterStatus{
+		Name:   peer.Name(),
+		Status: &status,
+		Peers:  peers,
+	}
+}
+
+func versionInfo() *amv2.VersionInfo {
+	version, revision, branch := setting.BuildVersion, setting.BuildCommit, setting.BuildBranch
+	buildDate := time.Unix(setting.BuildStamp, 0).UTC().Format(time.RFC3339)
+	buildUser, goVersion := "", runtime.Version()
+	return &amv2.VersionInfo{
+		Version:   &version,
+		Revision:  &revision,
+		Branch:    &branch,
+		BuildDate: &buildDate,
+		BuildUser: &buildUser,
+		GoVersion: &goVersion,
+	}
+}
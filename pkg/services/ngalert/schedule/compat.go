@@ -1,12 +1,15 @@
 package schedule
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/go-openapi/strfmt"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/prometheus/alertmanager/api/v2/models"
 
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 )
 
@@ -21,7 +24,7 @@ func FromAlertStateToPostableAlerts(firingStates []*state.State, stateManager *s
 				nL["__value__"] = alertState.Results[0].EvaluationString
 			}
 			alerts.PostableAlerts = append(alerts.PostableAlerts, models.PostableAlert{
-				Annotations: alertState.Annotations,
+				Annotations: alertAnnotations(alertState),
 				StartsAt:    strfmt.DateTime(alertState.StartsAt),
 				EndsAt:      strfmt.DateTime(alertState.EndsAt),
 				Alert: models.Alert{
@@ -35,3 +38,42 @@ func FromAlertStateToPostableAlerts(firingStates []*state.State, stateManager *s
 	stateManager.Put(sentAlerts)
 	return alerts
 }
+
+// FromAlertsStateToStoppedAlert converts the firing states of an alert rule that is no longer evaluated
+// to alerts resolved at ts, so that the Alertmanager does not wait for them to expire.
+func FromAlertsStateToStoppedAlert(firingStates []*state.State, ts time.Time) apimodels.PostableAlerts {
+	alerts := apimodels.PostableAlerts{PostableAlerts: make([]models.PostableAlert, 0, len(firingStates))}
+	for _, alertState := range firingStates {
+		if alertState.State != eval.Alerting {
+			continue
+		}
+		alerts.PostableAlerts = append(alerts.PostableAlerts, models.PostableAlert{
+			Annotations: alertAnnotations(alertState),
+			StartsAt:    strfmt.DateTime(alertState.StartsAt),
+			EndsAt:      strfmt.DateTime(ts),
+			Alert: models.Alert{
+				Labels: models.LabelSet(alertState.Labels.Copy()),
+			},
+		})
+	}
+	return alerts
+}
+
+// alertAnnotations returns the annotations of the state with its values encoded in the ValuesAnnotation annotation,
+// notifiers expose them as the values of the alert.
+func alertAnnotations(alertState *state.State) models.LabelSet {
+	if len(alertState.Values) == 0 {
+		return alertState.Annotations
+	}
+	b, err := json.Marshal(alertState.Values)
+	if err != nil {
+		return alertState.Annotations
+	}
+
+	annotations := make(models.LabelSet, len(alertState.Annotations)+1)
+	for k, v := range alertState.Annotations {
+		annotations[k] = v
+	}
+	annotations[ngModels.ValuesAnnotation] = string(b)
+	return annotations
+}
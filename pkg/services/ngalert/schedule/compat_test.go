@@ -0,0 +1,52 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+func TestAlertAnnotations(t *testing.T) {
+	t.Run("state without values", func(t *testing.T) {
+		s := &state.State{Annotations: map[string]string{"summary": "test"}}
+		require.Equal(t, map[string]string{"summary": "test"}, map[string]string(alertAnnotations(s)))
+	})
+
+	t.Run("values are encoded in an annotation", func(t *testing.T) {
+		value := 1.5
+		s := &state.State{
+			Annotations: map[string]string{"summary": "test"},
+			Values:      models.InstanceValues{"B": {Labels: map[string]string{"host": "a"}, Value: &value}, "C": {}},
+		}
+		require.Equal(t, map[string]string{
+			"summary":               "test",
+			models.ValuesAnnotation: `{"B":{"labels":{"host":"a"},"value":1.5},"C":{"value":null}}`,
+		}, map[string]string(alertAnnotations(s)))
+		// the annotations of the state are not modified
+		require.Equal(t, map[string]string{"summary": "test"}, s.Annotations)
+	})
+}
+
+func TestFromAlertsStateToStoppedAlert(t *testing.T) {
+	startsAt := time.Unix(1000, 0)
+	ts := time.Unix(2000, 0)
+	states := []*state.State{
+		{State: eval.Alerting, Labels: data.Labels{"alertname": "firing"}, StartsAt: startsAt, EndsAt: ts.Add(time.Hour)},
+		{State: eval.Normal, Labels: data.Labels{"alertname": "normal"}, StartsAt: startsAt},
+		{State: eval.Pending, Labels: data.Labels{"alertname": "pending"}, StartsAt: startsAt},
+	}
+
+	alerts := FromAlertsStateToStoppedAlert(states, ts)
+	require.Len(t, alerts.PostableAlerts, 1)
+	alert := alerts.PostableAlerts[0]
+	require.Equal(t, "firing", alert.Labels["alertname"])
+	require.Equal(t, strfmt.DateTime(startsAt), alert.StartsAt)
+	require.Equal(t, strfmt.DateTime(ts), alert.EndsAt)
+}
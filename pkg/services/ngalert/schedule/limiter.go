@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// evalLimiter bounds the number of concurrent evaluations of the alert rules querying the same data source.
+// A nil evalLimiter doesn't limit anything.
+type evalLimiter struct {
+	limit int
+
+	mtx   sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// newEvalLimiter returns a limiter allowing limit concurrent evaluations per data source,
+// it returns nil if limit is not positive.
+func newEvalLimiter(limit int) *evalLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &evalLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire waits for a free slot of every data source queried by the alert rule and returns a function releasing them.
+// It returns an error if the context is done before all slots are acquired.
+func (l *evalLimiter) acquire(ctx context.Context, alertRule *models.AlertRule) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	// the slots are always acquired in the same order to avoid deadlocks between alert rules querying several data sources
+	keys := datasourceKeys(alertRule)
+	acquired := make([]chan struct{}, 0, len(keys))
+	release := func() {
+		for _, s := range acquired {
+			<-s
+		}
+	}
+
+	for _, k := range keys {
+		s := l.slotsFor(k)
+		select {
+		case s <- struct{}{}:
+			acquired = append(acquired, s)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+func (l *evalLimiter) slotsFor(key string) chan struct{} {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	s, ok := l.slots[key]
+	if !ok {
+		s = make(chan struct{}, l.limit)
+		l.slots[key] = s
+	}
+	return s
+}
+
+// datasourceKeys returns the sorted and unique keys of the data sources queried by the alert rule, expressions excluded.
+func datasourceKeys(alertRule *models.AlertRule) []string {
+	seen := make(map[string]struct{}, len(alertRule.Data))
+	keys := make([]string, 0, len(alertRule.Data))
+	for i := range alertRule.Data {
+		q := alertRule.Data[i]
+		if isExpression, err := q.IsExpression(); err != nil || isExpression {
+			continue
+		}
+		k := fmt.Sprintf("%d/%s", alertRule.OrgID, q.DatasourceUID)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestDatasourceKeys(t *testing.T) {
+	rule := &models.AlertRule{
+		OrgID: 1,
+		Data: []models.AlertQuery{
+			{RefID: "A", DatasourceUID: "prom"},
+			{RefID: "B", DatasourceUID: "loki"},
+			{RefID: "C", DatasourceUID: "prom"},
+			{RefID: "D", DatasourceUID: expr.DatasourceUID},
+		},
+	}
+	require.Equal(t, []string{"1/loki", "1/prom"}, datasourceKeys(rule))
+}
+
+func TestEvalLimiter(t *testing.T) {
+	prom := &models.AlertRule{OrgID: 1, Data: []models.AlertQuery{{RefID: "A", DatasourceUID: "prom"}}}
+	loki := &models.AlertRule{OrgID: 1, Data: []models.AlertQuery{{RefID: "A", DatasourceUID: "loki"}}}
+	both := &models.AlertRule{OrgID: 1, Data: []models.AlertQuery{
+		{RefID: "A", DatasourceUID: "prom"},
+		{RefID: "B", DatasourceUID: "loki"},
+	}}
+	onlyExpressions := &models.AlertRule{OrgID: 1, Data: []models.AlertQuery{{RefID: "A", DatasourceUID: expr.DatasourceUID}}}
+
+	t.Run("nil limiter doesn't limit", func(t *testing.T) {
+		l := newEvalLimiter(0)
+		require.Nil(t, l)
+		for i := 0; i < 3; i++ {
+			_, err := l.acquire(context.Background(), prom)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("limits the concurrent evaluations per data source", func(t *testing.T) {
+		l := newEvalLimiter(1)
+		release, err := l.acquire(context.Background(), prom)
+		require.NoError(t, err)
+
+		// another data source is not limited
+		releaseLoki, err := l.acquire(context.Background(), loki)
+		require.NoError(t, err)
+		releaseLoki()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = l.acquire(ctx, prom)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		acquired := make(chan struct{})
+		go func() {
+			r, err := l.acquire(context.Background(), prom)
+			require.NoError(t, err)
+			r()
+			close(acquired)
+		}()
+		release()
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Fatal("slot was not released")
+		}
+	})
+
+	t.Run("releases the acquired slots when the context is done", func(t *testing.T) {
+		l := newEvalLimiter(1)
+		releaseProm, err := l.acquire(context.Background(), prom)
+		require.NoError(t, err)
+
+		// loki is acquired first then the evaluation waits for prom
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = l.acquire(ctx, both)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		releaseLoki, err := l.acquire(context.Background(), loki)
+		require.NoError(t, err)
+		releaseLoki()
+		releaseProm()
+	})
+
+	t.Run("expressions are not limited", func(t *testing.T) {
+		l := newEvalLimiter(1)
+		for i := 0; i < 3; i++ {
+			_, err := l.acquire(context.Background(), onlyExpressions)
+			require.NoError(t, err)
+		}
+	})
+}
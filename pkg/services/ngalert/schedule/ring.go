@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"crypto/sha1" // #nosec
+	"encoding/binary"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ringTokensPerMember is the number of virtual nodes of each member of the ring,
+// the more there are the more evenly the alert rules are distributed.
+const ringTokensPerMember = 128
+
+// hashRing assigns alert rules to the members of a cluster with consistent hashing,
+// so that only the alert rules of a member that leaves or joins the cluster move to another member.
+type hashRing struct {
+	members []string
+	tokens  []uint32
+	owners  map[uint32]string
+}
+
+// newHashRing returns the ring of the given members, which must be sorted.
+func newHashRing(members []string) *hashRing {
+	r := &hashRing{
+		members: members,
+		tokens:  make([]uint32, 0, len(members)*ringTokensPerMember),
+		owners:  make(map[uint32]string, len(members)*ringTokensPerMember),
+	}
+	for _, m := range members {
+		for i := 0; i < ringTokensPerMember; i++ {
+			token := hashString(m + "-" + strconv.Itoa(i))
+			// on the unlikely collision, the token goes to the first member in the sorted order
+			if _, ok := r.owners[token]; ok {
+				continue
+			}
+			r.owners[token] = m
+			r.tokens = append(r.tokens, token)
+		}
+	}
+	sort.Slice(r.tokens, func(i, j int) bool { return r.tokens[i] < r.tokens[j] })
+	return r
+}
+
+// owner returns the member the alert rule is assigned to, or an empty string if the ring has no members.
+func (r *hashRing) owner(key models.AlertRuleKey) string {
+	if len(r.tokens) == 0 {
+		return ""
+	}
+	h := hashRuleKey(key)
+	i := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i] >= h })
+	if i == len(r.tokens) {
+		i = 0
+	}
+	return r.owners[r.tokens[i]]
+}
+
+// equalMembers returns true if the ring has exactly the given sorted members.
+func (r *hashRing) equalMembers(members []string) bool {
+	if len(r.members) != len(members) {
+		return false
+	}
+	for i := range members {
+		if r.members[i] != members[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hashRuleKey(key models.AlertRuleKey) uint32 {
+	return hashString(strconv.FormatInt(key.OrgID, 10) + "/" + key.UID)
+}
+
+// hashString hashes with SHA-1 which, unlike FNV, spreads similar strings such as the tokens of a member
+// evenly over the ring. It is not used for security purposes.
+func hashString(s string) uint32 {
+	h := sha1.Sum([]byte(s)) // #nosec
+	return binary.BigEndian.Uint32(h[:4])
+}
@@ -0,0 +1,52 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestHashRing(t *testing.T) {
+	keys := make([]models.AlertRuleKey, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		keys = append(keys, models.AlertRuleKey{OrgID: int64(i%3 + 1), UID: fmt.Sprintf("rule-%d", i)})
+	}
+
+	t.Run("empty ring has no owner", func(t *testing.T) {
+		require.Equal(t, "", newHashRing(nil).owner(keys[0]))
+	})
+
+	t.Run("alert rules are distributed evenly", func(t *testing.T) {
+		r := newHashRing([]string{"node-1", "node-2", "node-3"})
+		counts := map[string]int{}
+		for _, k := range keys {
+			counts[r.owner(k)]++
+		}
+		require.Len(t, counts, 3)
+		for member, count := range counts {
+			require.InDelta(t, len(keys)/3, count, float64(len(keys))/10, "member %s", member)
+		}
+	})
+
+	t.Run("only the alert rules of a leaving member move", func(t *testing.T) {
+		before := newHashRing([]string{"node-1", "node-2", "node-3"})
+		after := newHashRing([]string{"node-1", "node-3"})
+		for _, k := range keys {
+			if owner := before.owner(k); owner != "node-2" {
+				require.Equal(t, owner, after.owner(k))
+			} else {
+				require.Contains(t, []string{"node-1", "node-3"}, after.owner(k))
+			}
+		}
+	})
+
+	t.Run("equal members", func(t *testing.T) {
+		r := newHashRing([]string{"node-1", "node-2"})
+		require.True(t, r.equalMembers([]string{"node-1", "node-2"}))
+		require.False(t, r.equalMembers([]string{"node-1"}))
+		require.False(t, r.equalMembers([]string{"node-1", "node-3"}))
+	})
+}
@@ -2,7 +2,10 @@ package schedule
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
 	"github.com/grafana/grafana/pkg/tsdb"
 )
 
@@ -36,22 +40,24 @@ type ScheduleService interface {
 	overrideCfg(cfg SchedulerCfg)
 }
 
-func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRuleKey, evalCh <-chan *evalContext, stopCh <-chan struct{}, stateManager *state.Manager) error {
+// ruleRoutine evaluates an alert rule until ruleCtx, which is derived from grafanaCtx, is cancelled to stop it.
+// If restoreStates is true, the states of the alert rule are loaded from the database before its first evaluation.
+func (sch *schedule) ruleRoutine(grafanaCtx, ruleCtx context.Context, key models.AlertRuleKey, evalCh <-chan *evalContext, stateManager *state.Manager, restoreStates bool) error {
 	sch.log.Debug("alert rule routine started", "key", key)
 
-	evalRunning := false
 	var attempt int64
 	var alertRule *models.AlertRule
 	for {
 		select {
 		case ctx := <-evalCh:
-			if evalRunning {
-				continue
+			if restoreStates {
+				// the states are loaded at the first evaluation rather than when the routine starts,
+				// which leaves the previous owner of the alert rule the time to finish saving its last evaluation
+				sch.loadRuleStates(key, stateManager)
+				restoreStates = false
 			}
 
 			evaluate := func(attempt int64) error {
-				start := timeNow()
-
 				// fetch latest alert rule version
 				if alertRule == nil || alertRule.Version < ctx.version {
 					q := models.GetAlertRuleByUIDQuery{OrgID: key.OrgID, UID: key.UID}
@@ -64,12 +70,31 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 					sch.log.Debug("new alert rule version fetched", "title", alertRule.Title, "key", key, "version", alertRule.Version)
 				}
 
-				condition := models.Condition{
-					Condition: alertRule.Condition,
-					OrgID:     alertRule.OrgID,
-					Data:      alertRule.Data,
+				// waiting for the data sources is interrupted when the routine is stopped
+				release, err := sch.evalLimiter.acquire(ruleCtx, alertRule)
+				if err != nil {
+					return err
+				}
+
+				start := timeNow()
+				if attempt == 0 {
+					sch.metrics.EvalQueueDuration.WithLabelValues(fmt.Sprint(alertRule.OrgID)).Observe(start.Sub(ctx.dispatchedAt).Seconds())
+				}
+
+				var results eval.Results
+				if alertRule.IsRecordingRule() {
+					err = sch.recordRule(alertRule, ctx.now)
+				} else {
+					condition := models.Condition{
+						Condition:     alertRule.Condition,
+						OrgID:         alertRule.OrgID,
+						Data:          alertRule.Data,
+						QueryCacheTTL: sch.ruleQueryCacheTTL(alertRule),
+					}
+					results, err = sch.evaluator.ConditionEval(&condition, ctx.now, sch.dataService)
 				}
-				results, err := sch.evaluator.ConditionEval(&condition, ctx.now, sch.dataService)
+				// the data sources are no longer queried, the next alert rules can evaluate
+				release()
 				var (
 					end    = timeNow()
 					tenant = fmt.Sprint(alertRule.OrgID)
@@ -86,11 +111,21 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 					return err
 				}
 
+				if alertRule.IsRecordingRule() {
+					return nil
+				}
+
+				// the results of an alert rule stopped during its evaluation are discarded, so that its states
+				// are neither recreated in the cache nor saved after it is deleted, paused or moved to another instance
+				if ruleCtx.Err() != nil {
+					return nil
+				}
+
 				processedStates := stateManager.ProcessEvalResults(alertRule, results)
 				sch.saveAlertStates(processedStates)
 				alerts := FromAlertStateToPostableAlerts(processedStates, stateManager)
 				sch.log.Debug("sending alerts to notifier", "count", len(alerts.PostableAlerts), "alerts", alerts.PostableAlerts)
-				err = sch.sendAlerts(alerts)
+				err = sch.sendAlerts(key.OrgID, alerts)
 				if err != nil {
 					sch.log.Error("failed to put alerts in the notifier", "count", len(alerts.PostableAlerts), "err", err)
 				}
@@ -98,33 +133,74 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 			}
 
 			func() {
-				evalRunning = true
 				defer func() {
-					evalRunning = false
 					sch.evalApplied(key, ctx.now)
 				}()
 
 				for attempt = 0; attempt < sch.maxAttempts; attempt++ {
 					err := evaluate(attempt)
-					if err == nil {
+					if err == nil || ruleCtx.Err() != nil {
 						break
 					}
 				}
 			}()
-		case <-stopCh:
+		case <-ruleCtx.Done():
+			if err := grafanaCtx.Err(); err != nil {
+				return err
+			}
 			sch.stopApplied(key)
 			sch.log.Debug("stopping alert rule routine", "key", key)
-			// interrupt evaluation if it's running
 			return nil
-		case <-grafanaCtx.Done():
-			return grafanaCtx.Err()
 		}
 	}
 }
 
+// recordRule executes the queries and expressions of a recording rule and writes the result of its condition.
+func (sch *schedule) recordRule(alertRule *models.AlertRule, now time.Time) error {
+	if sch.recordingWriter == nil {
+		return errors.New("no remote write endpoint is configured for recording rules")
+	}
+
+	resp, err := sch.evaluator.QueriesAndExpressionsEval(alertRule.OrgID, alertRule.Data, now, sch.dataService)
+	if err != nil {
+		return err
+	}
+
+	res, ok := resp.Responses[alertRule.Condition]
+	if !ok {
+		return fmt.Errorf("no result for condition %s", alertRule.Condition)
+	}
+	if res.Error != nil {
+		return fmt.Errorf("failed to execute condition %s: %w", alertRule.Condition, res.Error)
+	}
+
+	return sch.recordingWriter.Write(context.Background(), alertRule.Record, now, res.Frames, alertRule.Labels)
+}
+
+// ruleQueryCacheTTL returns for how long the query results of the rule may be shared,
+// which is bounded by the interval of the rule so that it never uses results older than its interval.
+func (sch *schedule) ruleQueryCacheTTL(alertRule *models.AlertRule) time.Duration {
+	ttl := sch.queryCacheTTL
+	if interval := time.Duration(alertRule.IntervalSeconds) * time.Second; interval < ttl {
+		ttl = interval
+	}
+	return ttl
+}
+
+// Cluster is the membership of the Grafana instances that share the evaluation of the alert rules.
+type Cluster interface {
+	// ClusterName returns the name of this instance in the cluster.
+	ClusterName() string
+	// ClusterMembers returns the names of the live instances of the cluster, including this one.
+	ClusterMembers() []string
+	// ClusterReady returns true once the members of the cluster have settled.
+	ClusterReady() bool
+}
+
 // Notifier handles the delivery of alert notifications to the end user
 type Notifier interface {
-	PutAlerts(alerts apimodels.PostableAlerts) error
+	// PutAlerts delivers the alerts to the Alertmanager of the organization.
+	PutAlerts(orgID int64, alerts apimodels.PostableAlerts) error
 }
 
 type schedule struct {
@@ -162,6 +238,21 @@ type schedule struct {
 
 	notifier Notifier
 	metrics  *metrics.Metrics
+
+	// recordingWriter writes the results of recording rules, it is nil if no remote write endpoint is configured.
+	recordingWriter writer.Writer
+
+	// cluster, when set, distributes the evaluation of the alert rules across the instances of the cluster.
+	cluster Cluster
+	// ring assigns the alert rules to the current members of the cluster.
+	ring *hashRing
+
+	// evalJitter spreads the evaluations of the alert rules with the same interval over the ticks of the interval.
+	evalJitter bool
+	// evalLimiter bounds the number of concurrent evaluations per data source.
+	evalLimiter *evalLimiter
+	// queryCacheTTL is the maximum duration the query results of the alert rules are shared.
+	queryCacheTTL time.Duration
 }
 
 // SchedulerCfg is the scheduler configuration.
@@ -177,6 +268,15 @@ type SchedulerCfg struct {
 	InstanceStore   store.InstanceStore
 	Notifier        Notifier
 	Metrics         *metrics.Metrics
+	RecordingWriter writer.Writer
+	Cluster         Cluster
+	// EvaluationJitter spreads the evaluations of the alert rules with the same interval over the ticks of the interval.
+	EvaluationJitter bool
+	// MaxConcurrentEvaluationsPerDatasource bounds the number of concurrent evaluations querying the same data source, 0 is unlimited.
+	MaxConcurrentEvaluationsPerDatasource int
+	// QueryCacheTTL is the maximum duration the query results of the alert rules are shared, 0 disables the sharing.
+	// It requires a query cache in the Evaluator.
+	QueryCacheTTL time.Duration
 }
 
 // NewScheduler returns a new schedule.
@@ -197,6 +297,11 @@ func NewScheduler(cfg SchedulerCfg, dataService *tsdb.Service) *schedule {
 		dataService:     dataService,
 		notifier:        cfg.Notifier,
 		metrics:         cfg.Metrics,
+		recordingWriter: cfg.RecordingWriter,
+		cluster:         cfg.Cluster,
+		evalJitter:      cfg.EvaluationJitter,
+		evalLimiter:     newEvalLimiter(cfg.MaxConcurrentEvaluationsPerDatasource),
+		queryCacheTTL:   cfg.QueryCacheTTL,
 	}
 	return &sch
 }
@@ -245,6 +350,8 @@ func (sch *schedule) Unpause() error {
 
 func (sch *schedule) Ticker(grafanaCtx context.Context, stateManager *state.Manager) error {
 	dispatcherGroup, ctx := errgroup.WithContext(grafanaCtx)
+	// the states of all alert rules are loaded by WarmStateCache before the first tick
+	firstTick := true
 	for {
 		select {
 		case tick := <-sch.heartbeat.C:
@@ -252,6 +359,8 @@ func (sch *schedule) Ticker(grafanaCtx context.Context, stateManager *state.Mana
 			alertRules := sch.fetchAllDetails()
 			sch.log.Debug("alert rules fetched", "count", len(alertRules))
 
+			ring, ringChanged := sch.updateRing()
+
 			// registeredDefinitions is a map used for finding deleted alert rules
 			// initially it is assigned to all known alert rules from the previous cycle
 			// each alert rule found also in this cycle is removed
@@ -263,16 +372,38 @@ func (sch *schedule) Ticker(grafanaCtx context.Context, stateManager *state.Mana
 				ruleInfo alertRuleInfo
 			}
 			readyToRun := make([]readyToRunItem, 0)
+			// pausedRules are the paused alert rules, their routines are stopped like the ones of the deleted alert rules
+			pausedRules := make(map[models.AlertRuleKey]struct{})
+			// disownedRules are the alert rules evaluated by other instances of the cluster since the cluster changed,
+			// their routines are stopped and their states are dropped from the cache of this instance
+			var disownedRules []models.AlertRuleKey
+			ownedRules := 0
 			for _, item := range alertRules {
 				key := item.GetKey()
+				if item.IsPaused {
+					pausedRules[key] = struct{}{}
+					continue
+				}
+
+				if !sch.ownsRule(ring, key) {
+					if ringChanged {
+						disownedRules = append(disownedRules, key)
+					}
+					continue
+				}
+				ownedRules++
+
 				itemVersion := item.Version
 				newRoutine := !sch.registry.exists(key)
-				ruleInfo := sch.registry.getOrCreateInfo(key, itemVersion)
+				ruleInfo := sch.registry.getOrCreateInfo(ctx, key, itemVersion)
 				invalidInterval := item.IntervalSeconds%int64(sch.baseInterval.Seconds()) != 0
 
 				if newRoutine && !invalidInterval {
+					// the alert rule is either new or moved from another instance of the cluster,
+					// it continues from the states saved by the previous owner
+					restoreStates := ring != nil && !firstTick
 					dispatcherGroup.Go(func() error {
-						return sch.ruleRoutine(ctx, key, ruleInfo.evalCh, ruleInfo.stopCh, stateManager)
+						return sch.ruleRoutine(ctx, ruleInfo.ctx, key, ruleInfo.evalCh, stateManager, restoreStates)
 					})
 				}
 
@@ -284,7 +415,7 @@ func (sch *schedule) Ticker(grafanaCtx context.Context, stateManager *state.Mana
 				}
 
 				itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
-				if item.IntervalSeconds != 0 && tickNum%itemFrequency == 0 {
+				if item.IntervalSeconds != 0 && (tickNum-sch.jitterOffset(key, itemFrequency))%itemFrequency == 0 {
 					readyToRun = append(readyToRun, readyToRunItem{key: key, ruleInfo: ruleInfo})
 				}
 
@@ -301,20 +432,34 @@ func (sch *schedule) Ticker(grafanaCtx context.Context, stateManager *state.Mana
 				item := readyToRun[i]
 
 				time.AfterFunc(time.Duration(int64(i)*step), func() {
-					item.ruleInfo.evalCh <- &evalContext{now: tick, version: item.ruleInfo.version}
+					sch.dispatchEval(item.key, item.ruleInfo, tick)
 				})
 			}
 
-			// unregister and stop routines of the deleted alert rules
+			// unregister and stop routines of the deleted and paused alert rules
 			for key := range registeredDefinitions {
 				ruleInfo, err := sch.registry.get(key)
 				if err != nil {
 					sch.log.Error("failed to get alert rule routine information", "err", err)
 					continue
 				}
-				ruleInfo.stopCh <- struct{}{}
+				// the routine stops without blocking the scheduler, even if it is waiting for the data sources
+				ruleInfo.stop()
 				sch.registry.del(key)
+
+				if _, ok := pausedRules[key]; ok {
+					sch.clearPausedRuleStates(key, stateManager)
+				}
+			}
+
+			// the routines of the disowned alert rules are stopped and discard the results of their running evaluation,
+			// their alert instances stay in the database for the instances now evaluating them and
+			// their alerts are not resolved
+			for _, key := range disownedRules {
+				stateManager.RemoveByRuleUID(key.OrgID, key.UID)
 			}
+			sch.metrics.OwnedRules.Set(float64(ownedRules))
+			firstTick = false
 		case <-grafanaCtx.Done():
 			waitErr := dispatcherGroup.Wait()
 
@@ -333,8 +478,103 @@ func (sch *schedule) Ticker(grafanaCtx context.Context, stateManager *state.Mana
 	}
 }
 
-func (sch *schedule) sendAlerts(alerts apimodels.PostableAlerts) error {
-	return sch.notifier.PutAlerts(alerts)
+// dispatchEval sends an evaluation to the routine of an alert rule. The evaluation is skipped if the routine
+// is still evaluating the alert rule and the next evaluation is already waiting.
+func (sch *schedule) dispatchEval(key models.AlertRuleKey, ruleInfo alertRuleInfo, tick time.Time) {
+	select {
+	case ruleInfo.evalCh <- &evalContext{now: tick, version: ruleInfo.version, dispatchedAt: timeNow()}:
+	default:
+		sch.metrics.EvalSkipped.WithLabelValues(fmt.Sprint(key.OrgID)).Inc()
+		sch.log.Warn("skipping evaluation of alert rule, its previous evaluation is still running", "key", key, "now", tick)
+	}
+}
+
+// jitterOffset returns the offset, in ticks, of the evaluations of an alert rule within its interval.
+// The offset is derived from the alert rule key so that it stays the same across ticks and restarts,
+// while the alert rules with the same interval are spread over different ticks.
+func (sch *schedule) jitterOffset(key models.AlertRuleKey, frequency int64) int64 {
+	if !sch.evalJitter || frequency <= 1 {
+		return 0
+	}
+	return int64(uint64(hashRuleKey(key)) % uint64(frequency))
+}
+
+// updateRing rebuilds the hash ring when the members of the cluster change, it returns the ring and whether it changed.
+// The ring is nil when the evaluation of the alert rules is not distributed. Until the members of the cluster
+// have settled, the ring has no members and no alert rule is evaluated, as every instance would otherwise
+// see itself alone and evaluate all the alert rules.
+func (sch *schedule) updateRing() (*hashRing, bool) {
+	if sch.cluster == nil {
+		return nil, false
+	}
+
+	var members []string
+	if sch.cluster.ClusterReady() {
+		members = sch.cluster.ClusterMembers()
+		if len(members) == 0 {
+			// the cluster is not running, this instance evaluates all alert rules
+			members = []string{sch.cluster.ClusterName()}
+		}
+		sort.Strings(members)
+	}
+
+	if sch.ring != nil && sch.ring.equalMembers(members) {
+		return sch.ring, false
+	}
+	if len(members) == 0 {
+		sch.log.Info("waiting for the cluster members to settle before evaluating alert rules")
+	} else {
+		sch.log.Info("cluster members changed, redistributing the evaluation of alert rules", "members", strings.Join(members, ","))
+	}
+	sch.ring = newHashRing(members)
+	return sch.ring, true
+}
+
+// ownsRule returns true if this instance evaluates the alert rule.
+func (sch *schedule) ownsRule(ring *hashRing, key models.AlertRuleKey) bool {
+	return ring == nil || ring.owner(key) == sch.cluster.ClusterName()
+}
+
+// loadRuleStates loads the states of an alert rule from the database into the cache,
+// so that an alert rule that moves from another instance of the cluster keeps its states.
+func (sch *schedule) loadRuleStates(key models.AlertRuleKey, st *state.Manager) {
+	ruleQuery := models.GetAlertRuleByUIDQuery{OrgID: key.OrgID, UID: key.UID}
+	if err := sch.ruleStore.GetAlertRuleByUID(&ruleQuery); err != nil {
+		sch.log.Error("unable to fetch alert rule to load its states", "key", key, "msg", err.Error())
+		return
+	}
+
+	cmd := models.ListAlertInstancesQuery{RuleOrgID: key.OrgID, RuleUID: key.UID}
+	if err := sch.instanceStore.ListAlertInstances(&cmd); err != nil {
+		sch.log.Error("unable to fetch previous state", "key", key, "msg", err.Error())
+		return
+	}
+
+	states := make([]*state.State, 0, len(cmd.Result))
+	for _, entry := range cmd.Result {
+		states = append(states, sch.stateFromInstance(entry, ruleQuery.Result))
+	}
+	st.Put(states)
+}
+
+// clearPausedRuleStates resolves the firing alerts of a paused alert rule and removes its states
+// so that it starts afresh once resumed.
+func (sch *schedule) clearPausedRuleStates(key models.AlertRuleKey, stateManager *state.Manager) {
+	sch.log.Debug("alert rule paused, clearing its states", "key", key)
+	alerts := FromAlertsStateToStoppedAlert(stateManager.GetStatesForRuleUID(key.OrgID, key.UID), sch.clock.Now())
+	if len(alerts.PostableAlerts) > 0 {
+		if err := sch.sendAlerts(key.OrgID, alerts); err != nil {
+			sch.log.Error("failed to resolve the alerts of paused alert rule", "key", key, "count", len(alerts.PostableAlerts), "err", err)
+		}
+	}
+	stateManager.RemoveByRuleUID(key.OrgID, key.UID)
+	if err := sch.ruleStore.DeleteAlertInstancesByRuleUID(key.OrgID, key.UID); err != nil {
+		sch.log.Error("failed to delete alert instances of paused alert rule", "key", key, "err", err)
+	}
+}
+
+func (sch *schedule) sendAlerts(orgID int64, alerts apimodels.PostableAlerts) error {
+	return sch.notifier.PutAlerts(orgID, alerts)
 }
 
 func (sch *schedule) saveAlertStates(states []*state.State) {
@@ -348,6 +588,8 @@ func (sch *schedule) saveAlertStates(states []*state.State) {
 			LastEvalTime:      s.LastEvaluationTime,
 			CurrentStateSince: s.StartsAt,
 			CurrentStateEnd:   s.EndsAt,
+			Values:            s.Values,
+			KeptFiringSince:   s.KeptFiringSince,
 		}
 		err := sch.instanceStore.SaveAlertInstance(&cmd)
 		if err != nil {
@@ -395,29 +637,38 @@ func (sch *schedule) WarmStateCache(st *state.Manager) {
 				continue
 			}
 
-			lbs := map[string]string(entry.Labels)
-			cacheId, err := entry.Labels.StringKey()
-			if err != nil {
-				sch.log.Error("error getting cacheId for entry", "msg", err.Error())
-			}
-			stateForEntry := &state.State{
-				AlertRuleUID:       entry.RuleUID,
-				OrgID:              entry.RuleOrgID,
-				CacheId:            cacheId,
-				Labels:             lbs,
-				State:              translateInstanceState(entry.CurrentState),
-				Results:            []state.Evaluation{},
-				StartsAt:           entry.CurrentStateSince,
-				EndsAt:             entry.CurrentStateEnd,
-				LastEvaluationTime: entry.LastEvalTime,
-				Annotations:        ruleForEntry.Annotations,
+			if ruleForEntry.IsPaused {
+				continue
 			}
-			states = append(states, stateForEntry)
+
+			states = append(states, sch.stateFromInstance(entry, ruleForEntry))
 		}
 	}
 	st.Put(states)
 }
 
+func (sch *schedule) stateFromInstance(entry *models.ListAlertInstancesQueryResult, rule *models.AlertRule) *state.State {
+	lbs := map[string]string(entry.Labels)
+	cacheId, err := entry.Labels.StringKey()
+	if err != nil {
+		sch.log.Error("error getting cacheId for entry", "msg", err.Error())
+	}
+	return &state.State{
+		AlertRuleUID:       entry.RuleUID,
+		OrgID:              entry.RuleOrgID,
+		CacheId:            cacheId,
+		Labels:             lbs,
+		State:              translateInstanceState(entry.CurrentState),
+		Results:            []state.Evaluation{},
+		StartsAt:           entry.CurrentStateSince,
+		EndsAt:             entry.CurrentStateEnd,
+		LastEvaluationTime: entry.LastEvalTime,
+		Annotations:        rule.Annotations,
+		Values:             entry.CurrentValues,
+		KeptFiringSince:    entry.KeptFiringSince,
+	}
+}
+
 func translateInstanceState(state models.InstanceStateType) eval.State {
 	switch {
 	case state == models.InstanceStateFiring:
@@ -435,14 +686,16 @@ type alertRuleRegistry struct {
 }
 
 // getOrCreateInfo returns the channel for the specific alert rule
-// if it does not exists creates one and returns it
-func (r *alertRuleRegistry) getOrCreateInfo(key models.AlertRuleKey, ruleVersion int64) alertRuleInfo {
+// if it does not exists creates one, with a context derived from ctx, and returns it
+func (r *alertRuleRegistry) getOrCreateInfo(ctx context.Context, key models.AlertRuleKey, ruleVersion int64) alertRuleInfo {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	info, ok := r.alertRuleInfo[key]
 	if !ok {
-		r.alertRuleInfo[key] = alertRuleInfo{evalCh: make(chan *evalContext), stopCh: make(chan struct{}), version: ruleVersion}
+		ruleCtx, stop := context.WithCancel(ctx)
+		// one evaluation can wait while the previous one is running, the next ones are skipped
+		r.alertRuleInfo[key] = alertRuleInfo{evalCh: make(chan *evalContext, 1), ctx: ruleCtx, stop: stop, version: ruleVersion}
 		return r.alertRuleInfo[key]
 	}
 	info.version = ruleVersion
@@ -504,12 +757,16 @@ func (r *alertRuleRegistry) keyMap() map[models.AlertRuleKey]struct{} {
 }
 
 type alertRuleInfo struct {
-	evalCh  chan *evalContext
-	stopCh  chan struct{}
+	evalCh chan *evalContext
+	// ctx is the context of the routine of the alert rule, stop cancels it to stop the routine.
+	ctx     context.Context
+	stop    context.CancelFunc
 	version int64
 }
 
 type evalContext struct {
 	now     time.Time
 	version int64
+	// dispatchedAt is when the evaluation was sent to the routine of the alert rule.
+	dispatchedAt time.Time
 }
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests"
@@ -24,6 +26,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -70,6 +73,7 @@ func TestWarmStateCache(t *testing.T) {
 			EndsAt:             evaluationTime.Add(1 * time.Minute),
 			LastEvaluationTime: evaluationTime,
 			Annotations:        map[string]string{"testAnnoKey": "testAnnoValue"},
+			KeptFiringSince:    evaluationTime.Add(-30 * time.Second),
 		},
 	}
 
@@ -93,6 +97,7 @@ func TestWarmStateCache(t *testing.T) {
 		LastEvalTime:      evaluationTime,
 		CurrentStateSince: evaluationTime.Add(-1 * time.Minute),
 		CurrentStateEnd:   evaluationTime.Add(1 * time.Minute),
+		KeptFiringSince:   evaluationTime.Add(-30 * time.Second),
 	}
 	_ = dbstore.SaveAlertInstance(saveCmd2)
 
@@ -108,7 +113,7 @@ func TestWarmStateCache(t *testing.T) {
 		Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
 	}
 	sched := schedule.NewScheduler(schedCfg, nil)
-	st := state.NewManager(schedCfg.Logger, nilMetrics)
+	st := state.NewManager(schedCfg.Logger, nilMetrics, state.NopHistorian{})
 	sched.WarmStateCache(st)
 
 	t.Run("instance cache has expected entries", func(t *testing.T) {
@@ -155,13 +160,8 @@ func TestAlertingTicker(t *testing.T) {
 	}
 	sched := schedule.NewScheduler(schedCfg, nil)
 
-	ctx := context.Background()
-
-	st := state.NewManager(schedCfg.Logger, nilMetrics)
-	go func() {
-		err := sched.Ticker(ctx, st)
-		require.NoError(t, err)
-	}()
+	st := state.NewManager(schedCfg.Logger, nilMetrics, state.NopHistorian{})
+	runTicker(t, sched, st)
 	runtime.Gosched()
 
 	expectedAlertRulesEvaluated := []models.AlertRuleKey{alerts[0].GetKey()}
@@ -223,6 +223,305 @@ func TestAlertingTicker(t *testing.T) {
 	})
 }
 
+func TestAlertingTickerPausedRules(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, 1)
+	t.Cleanup(registry.ClearOverrides)
+
+	// create alert rule with one second interval
+	rule := tests.CreateTestAlertRule(t, dbstore, 1)
+
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	stopAppliedCh := make(chan models.AlertRuleKey, 1)
+
+	mockedClock := clock.NewMock()
+	schedCfg := schedule.SchedulerCfg{
+		C:            mockedClock,
+		BaseInterval: time.Second,
+		EvalAppliedFunc: func(alertDefKey models.AlertRuleKey, now time.Time) {
+			evalAppliedCh <- evalAppliedInfo{alertDefKey: alertDefKey, now: now}
+		},
+		StopAppliedFunc: func(alertDefKey models.AlertRuleKey) {
+			stopAppliedCh <- alertDefKey
+		},
+		MaxAttempts:   1,
+		Evaluator:     eval.Evaluator{Cfg: &setting.Cfg{ExpressionsEnabled: true}},
+		RuleStore:     dbstore,
+		InstanceStore: dbstore,
+		Notifier:      &fakeNotifier{},
+		Logger:        log.New("ngalert schedule test"),
+		Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
+	}
+	sched := schedule.NewScheduler(schedCfg, nil)
+
+	st := state.NewManager(schedCfg.Logger, nilMetrics, state.NopHistorian{})
+	runTicker(t, sched, st)
+	runtime.Gosched()
+
+	t.Run("on 1st tick the alert rule should be evaluated", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+		require.NotEmpty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+
+	rule = tests.UpdateTestAlertRulePaused(t, dbstore, rule, true)
+
+	t.Run("on 2nd tick the paused alert rule should be stopped and its states cleared", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick)
+		assertStopRun(t, stopAppliedCh, rule.GetKey())
+		require.Eventually(t, func() bool {
+			return len(st.GetStatesForRuleUID(rule.OrgID, rule.UID)) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("on 3rd tick the paused alert rule should not be evaluated", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick)
+	})
+
+	rule = tests.UpdateTestAlertRulePaused(t, dbstore, rule, false)
+
+	t.Run("on 4th tick the resumed alert rule should be evaluated", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+	})
+}
+
+func TestAlertingTickerDistributedRules(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, 1)
+	t.Cleanup(registry.ClearOverrides)
+
+	// create alert rule with one second interval
+	rule := tests.CreateTestAlertRule(t, dbstore, 1)
+
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	stopAppliedCh := make(chan models.AlertRuleKey, 1)
+
+	cluster := &fakeCluster{name: "node-1", members: []string{"node-1"}, unsettled: true}
+	mockedClock := clock.NewMock()
+	schedCfg := schedule.SchedulerCfg{
+		C:            mockedClock,
+		BaseInterval: time.Second,
+		EvalAppliedFunc: func(alertDefKey models.AlertRuleKey, now time.Time) {
+			evalAppliedCh <- evalAppliedInfo{alertDefKey: alertDefKey, now: now}
+		},
+		StopAppliedFunc: func(alertDefKey models.AlertRuleKey) {
+			stopAppliedCh <- alertDefKey
+		},
+		MaxAttempts:   1,
+		Evaluator:     eval.Evaluator{Cfg: &setting.Cfg{ExpressionsEnabled: true}},
+		RuleStore:     dbstore,
+		InstanceStore: dbstore,
+		Notifier:      &fakeNotifier{},
+		Cluster:       cluster,
+		Logger:        log.New("ngalert schedule test"),
+		Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
+	}
+	sched := schedule.NewScheduler(schedCfg, nil)
+
+	st := state.NewManager(schedCfg.Logger, nilMetrics, state.NopHistorian{})
+	runTicker(t, sched, st)
+	runtime.Gosched()
+
+	t.Run("on 1st tick the alert rule should not be evaluated before the cluster settles", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick)
+	})
+
+	cluster.setSettled(true)
+
+	t.Run("on 2nd tick the alert rule should be evaluated by the only member", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+		require.NotEmpty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+
+	// the alert rule moves to another member
+	cluster.setMembers("node-2")
+
+	t.Run("on 3rd tick the alert rule should be stopped and its states dropped from the cache only", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick)
+		assertStopRun(t, stopAppliedCh, rule.GetKey())
+		require.Eventually(t, func() bool {
+			return len(st.GetStatesForRuleUID(rule.OrgID, rule.UID)) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		q := models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID}
+		require.NoError(t, dbstore.ListAlertInstances(&q))
+		require.NotEmpty(t, q.Result)
+	})
+
+	// the other member leaves
+	cluster.setMembers("node-1")
+
+	t.Run("on 4th tick the alert rule should be evaluated again with its states", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+		require.NotEmpty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+}
+
+func TestAlertingTickerRuleMovesBetweenMembers(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, 1)
+	t.Cleanup(registry.ClearOverrides)
+
+	// create alert rule with one second interval
+	rule := tests.CreateTestAlertRule(t, dbstore, 1)
+
+	type member struct {
+		cluster       *fakeCluster
+		evalAppliedCh chan evalAppliedInfo
+		stopAppliedCh chan models.AlertRuleKey
+		st            *state.Manager
+	}
+
+	mockedClock := clock.NewMock()
+	members := map[string]*member{}
+	for _, name := range []string{"node-1", "node-2"} {
+		m := &member{
+			cluster:       &fakeCluster{name: name, members: []string{"node-1", "node-2"}},
+			evalAppliedCh: make(chan evalAppliedInfo, 1),
+			stopAppliedCh: make(chan models.AlertRuleKey, 1),
+		}
+		schedCfg := schedule.SchedulerCfg{
+			C:            mockedClock,
+			BaseInterval: time.Second,
+			EvalAppliedFunc: func(alertDefKey models.AlertRuleKey, now time.Time) {
+				m.evalAppliedCh <- evalAppliedInfo{alertDefKey: alertDefKey, now: now}
+			},
+			StopAppliedFunc: func(alertDefKey models.AlertRuleKey) {
+				m.stopAppliedCh <- alertDefKey
+			},
+			MaxAttempts:   1,
+			Evaluator:     eval.Evaluator{Cfg: &setting.Cfg{ExpressionsEnabled: true}},
+			RuleStore:     dbstore,
+			InstanceStore: dbstore,
+			Notifier:      &fakeNotifier{},
+			Cluster:       m.cluster,
+			Logger:        log.New("ngalert schedule test", "member", name),
+			Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
+		}
+		sched := schedule.NewScheduler(schedCfg, nil)
+		m.st = state.NewManager(schedCfg.Logger, nilMetrics, state.NopHistorian{})
+		runTicker(t, sched, m.st)
+		members[name] = m
+	}
+	runtime.Gosched()
+
+	// the alert rule is evaluated by the member it is assigned to
+	var owner, other *member
+	var startsAt time.Time
+	t.Run("on 1st tick the alert rule should be evaluated by a single member", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		select {
+		case info := <-members["node-1"].evalAppliedCh:
+			owner, other = members["node-1"], members["node-2"]
+			require.Equal(t, evalAppliedInfo{alertDefKey: rule.GetKey(), now: tick}, info)
+		case info := <-members["node-2"].evalAppliedCh:
+			owner, other = members["node-2"], members["node-1"]
+			require.Equal(t, evalAppliedInfo{alertDefKey: rule.GetKey(), now: tick}, info)
+		case <-time.After(time.Second):
+			t.Fatal("cycle has expired")
+		}
+		assertEvalRun(t, other.evalAppliedCh, tick)
+
+		states := owner.st.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, eval.Alerting, states[0].State)
+		startsAt = states[0].StartsAt
+		require.Empty(t, other.st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+
+	// the owner of the alert rule leaves the cluster
+	owner.cluster.setMembers(other.cluster.name)
+	other.cluster.setMembers(other.cluster.name)
+
+	t.Run("on 2nd tick the alert rule should move to the other member with its states", func(t *testing.T) {
+		tick := advanceClock(t, mockedClock)
+		assertEvalRun(t, owner.evalAppliedCh, tick)
+		assertStopRun(t, owner.stopAppliedCh, rule.GetKey())
+		assertEvalRun(t, other.evalAppliedCh, tick, rule.GetKey())
+
+		// the alert keeps firing since the first evaluation rather than starting anew
+		states := other.st.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, eval.Alerting, states[0].State)
+		require.True(t, startsAt.Equal(states[0].StartsAt))
+
+		// the previous owner only drops the states from its cache
+		require.Eventually(t, func() bool {
+			return len(owner.st.GetStatesForRuleUID(rule.OrgID, rule.UID)) == 0
+		}, time.Second, 10*time.Millisecond)
+		q := models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID}
+		require.NoError(t, dbstore.ListAlertInstances(&q))
+		require.Len(t, q.Result, 1)
+		require.Equal(t, models.InstanceStateFiring, q.Result[0].CurrentState)
+	})
+}
+
+// runTicker runs the scheduler until the end of the test.
+func runTicker(t *testing.T, sched schedule.ScheduleService, st *state.Manager) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- sched.Ticker(ctx, st)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				require.ErrorIs(t, err, context.Canceled)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("the scheduler did not stop")
+		}
+	})
+}
+
+type fakeCluster struct {
+	mtx       sync.Mutex
+	name      string
+	members   []string
+	unsettled bool
+}
+
+func (c *fakeCluster) ClusterName() string {
+	return c.name
+}
+
+func (c *fakeCluster) ClusterMembers() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]string{}, c.members...)
+}
+
+func (c *fakeCluster) ClusterReady() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return !c.unsettled
+}
+
+func (c *fakeCluster) setSettled(settled bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.unsettled = !settled
+}
+
+func (c *fakeCluster) setMembers(members ...string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.members = members
+}
+
+type fakeNotifier struct{}
+
+func (n *fakeNotifier) PutAlerts(int64, apimodels.PostableAlerts) error {
+	return nil
+}
+
 func assertEvalRun(t *testing.T, ch <-chan evalAppliedInfo, tick time.Time, keys ...models.AlertRuleKey) {
 	timeout := time.After(time.Second)
 
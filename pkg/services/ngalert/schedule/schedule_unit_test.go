@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+func TestJitterOffset(t *testing.T) {
+	keys := make([]models.AlertRuleKey, 0, 100)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, models.AlertRuleKey{OrgID: 1, UID: fmt.Sprintf("rule-%d", i)})
+	}
+
+	t.Run("no offset when the jitter is disabled", func(t *testing.T) {
+		sch := &schedule{evalJitter: false}
+		for _, k := range keys {
+			require.Equal(t, int64(0), sch.jitterOffset(k, 6))
+		}
+	})
+
+	t.Run("no offset for the alert rules evaluated at every tick", func(t *testing.T) {
+		sch := &schedule{evalJitter: true}
+		for _, k := range keys {
+			require.Equal(t, int64(0), sch.jitterOffset(k, 1))
+			require.Equal(t, int64(0), sch.jitterOffset(k, 0))
+		}
+	})
+
+	t.Run("offsets are stable and spread over the interval", func(t *testing.T) {
+		sch := &schedule{evalJitter: true}
+		offsets := map[int64]int{}
+		for _, k := range keys {
+			offset := sch.jitterOffset(k, 6)
+			require.GreaterOrEqual(t, offset, int64(0))
+			require.Less(t, offset, int64(6))
+			require.Equal(t, offset, sch.jitterOffset(k, 6))
+			offsets[offset]++
+		}
+		require.Len(t, offsets, 6)
+	})
+}
+
+type fakeRuleStore struct {
+	store.RuleStore
+	rule *models.AlertRule
+}
+
+func (s *fakeRuleStore) GetAlertRuleByUID(q *models.GetAlertRuleByUIDQuery) error {
+	q.Result = s.rule
+	return nil
+}
+
+func TestRuleRoutineEvaluationQueue(t *testing.T) {
+	// a recording rule without remote write endpoint fails right after waiting for its data source
+	rule := &models.AlertRule{
+		OrgID:   1,
+		UID:     "test",
+		Version: 1,
+		Record:  "test_metric",
+		Data:    []models.AlertQuery{{RefID: "A", DatasourceUID: "prom"}},
+	}
+	key := rule.GetKey()
+
+	mockedClock := clock.NewMock()
+	origTimeNow := timeNow
+	timeNow = mockedClock.Now
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	evalAppliedCh := make(chan time.Time, 10)
+	stopAppliedCh := make(chan models.AlertRuleKey, 1)
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	sch := NewScheduler(SchedulerCfg{
+		C:            mockedClock,
+		BaseInterval: time.Second,
+		EvalAppliedFunc: func(_ models.AlertRuleKey, now time.Time) {
+			evalAppliedCh <- now
+		},
+		StopAppliedFunc: func(alertDefKey models.AlertRuleKey) {
+			stopAppliedCh <- alertDefKey
+		},
+		MaxAttempts:                           1,
+		RuleStore:                             &fakeRuleStore{rule: rule},
+		Logger:                                log.New("ngalert schedule test"),
+		Metrics:                               m,
+		MaxConcurrentEvaluationsPerDatasource: 1,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ruleInfo := sch.registry.getOrCreateInfo(ctx, key, rule.Version)
+	done := make(chan error, 1)
+	go func() {
+		done <- sch.ruleRoutine(ctx, ruleInfo.ctx, key, ruleInfo.evalCh, nil, false)
+	}()
+
+	// another evaluation holds the only slot of the data source
+	release, err := sch.evalLimiter.acquire(ctx, rule)
+	require.NoError(t, err)
+
+	tick := mockedClock.Now()
+	sch.dispatchEval(key, ruleInfo, tick)
+	require.Eventually(t, func() bool {
+		return len(ruleInfo.evalCh) == 0
+	}, time.Second, 10*time.Millisecond, "the routine should wait for the data source")
+
+	t.Run("an evaluation waits while the previous one is running, the next ones are skipped", func(t *testing.T) {
+		sch.dispatchEval(key, ruleInfo, tick.Add(time.Second))
+		sch.dispatchEval(key, ruleInfo, tick.Add(2*time.Second))
+		require.Equal(t, float64(1), testutil.ToFloat64(m.EvalSkipped.WithLabelValues("1")))
+	})
+
+	t.Run("the time spent waiting for the data source is the queue duration", func(t *testing.T) {
+		mockedClock.Add(5 * time.Second)
+		release()
+		require.Equal(t, tick, <-evalAppliedCh)
+		require.Equal(t, tick.Add(time.Second), <-evalAppliedCh)
+
+		var metric dto.Metric
+		require.NoError(t, m.EvalQueueDuration.WithLabelValues("1").(prometheus.Metric).Write(&metric))
+		require.Equal(t, uint64(2), metric.GetSummary().GetSampleCount())
+		require.Equal(t, float64(10), metric.GetSummary().GetSampleSum())
+	})
+
+	t.Run("the slot of the data source is released after the evaluation", func(t *testing.T) {
+		acquireCtx, cancelAcquire := context.WithTimeout(ctx, time.Second)
+		defer cancelAcquire()
+		release, err = sch.evalLimiter.acquire(acquireCtx, rule)
+		require.NoError(t, err)
+	})
+
+	t.Run("a routine waiting for the data source is stopped without blocking", func(t *testing.T) {
+		sch.dispatchEval(key, ruleInfo, tick.Add(3*time.Second))
+		require.Eventually(t, func() bool {
+			return len(ruleInfo.evalCh) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		ruleInfo.stop()
+		select {
+		case stopped := <-stopAppliedCh:
+			require.Equal(t, key, stopped)
+		case <-time.After(time.Second):
+			t.Fatal("the routine should stop")
+		}
+		require.NoError(t, <-done)
+		release()
+	})
+}
@@ -29,12 +29,13 @@ func newCache(logger log.Logger, metrics *metrics.Metrics) *cache {
 	}
 }
 
-func (c *cache) getOrCreate(alertRule *ngModels.AlertRule, result eval.Result) *State {
+// getOrCreate returns the state of the result, ruleLabels are the expanded labels of the alert rule.
+func (c *cache) getOrCreate(alertRule *ngModels.AlertRule, ruleLabels map[string]string, result eval.Result) *State {
 	c.mtxStates.Lock()
 	defer c.mtxStates.Unlock()
 
 	// if duplicate labels exist, alertRule label will take precedence
-	lbs := mergeLabels(alertRule.Labels, result.Instance)
+	lbs := mergeLabels(ruleLabels, result.Instance)
 	lbs[ngModels.UIDLabel] = alertRule.UID
 	lbs[ngModels.NamespaceUIDLabel] = alertRule.NamespaceUID
 	lbs[prometheusModel.AlertNameLabel] = alertRule.Title
@@ -56,11 +57,6 @@ func (c *cache) getOrCreate(alertRule *ngModels.AlertRule, result eval.Result) *
 		return state
 	}
 
-	annotations := map[string]string{}
-	if len(alertRule.Annotations) > 0 {
-		annotations = alertRule.Annotations
-	}
-
 	// If the first result we get is alerting, set StartsAt to EvaluatedAt because we
 	// do not have data for determining StartsAt otherwise
 	newState := &State{
@@ -68,7 +64,6 @@ func (c *cache) getOrCreate(alertRule *ngModels.AlertRule, result eval.Result) *
 		OrgID:              alertRule.OrgID,
 		CacheId:            id,
 		Labels:             lbs,
-		Annotations:        annotations,
 		EvaluationDuration: result.EvaluationDuration,
 	}
 	if result.State == eval.Alerting {
@@ -0,0 +1,283 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// Historian records the state transitions of alert instances.
+type Historian interface {
+	RecordStates(alertRule *ngModels.AlertRule, transitions []StateTransition)
+}
+
+// StateTransition is the change of the state of an alert instance after an evaluation.
+type StateTransition struct {
+	State         *State
+	PreviousState eval.State
+}
+
+// NewHistorian returns a Historian recording the transitions with all the given historians.
+func NewHistorian(historians ...Historian) Historian {
+	return multipleHistorian(historians)
+}
+
+type multipleHistorian []Historian
+
+func (h multipleHistorian) RecordStates(alertRule *ngModels.AlertRule, transitions []StateTransition) {
+	for _, historian := range h {
+		historian.RecordStates(alertRule, transitions)
+	}
+}
+
+// AnnotationStateHistorian records the state transitions of alert instances as annotations
+// of the dashboard panel the alert rule is linked to.
+// The annotations are queued and saved by Run, so that the evaluations don't wait for the database.
+type AnnotationStateHistorian struct {
+	log     log.Logger
+	queue   chan annotationEntry
+	dropped prometheus.Counter
+}
+
+// annotationEntry is an annotation waiting to be saved on the dashboard of the alert rule.
+type annotationEntry struct {
+	ruleUID string
+	dashUID string
+	item    *annotations.Item
+}
+
+func NewAnnotationStateHistorian(logger log.Logger, m *metrics.Metrics) *AnnotationStateHistorian {
+	return &AnnotationStateHistorian{
+		log:     logger,
+		queue:   make(chan annotationEntry, stateHistoryQueueSize),
+		dropped: m.StateHistoryDropped.WithLabelValues("annotation"),
+	}
+}
+
+func (h *AnnotationStateHistorian) RecordStates(alertRule *ngModels.AlertRule, transitions []StateTransition) {
+	dashUID, ok := alertRule.Annotations[ngModels.DashboardUIDAnnotation]
+	if !ok || len(transitions) == 0 {
+		return
+	}
+
+	panelID, err := strconv.ParseInt(alertRule.Annotations[ngModels.PanelIDAnnotation], 10, 64)
+	if err != nil {
+		h.log.Error("error parsing panel ID for alert annotation", "panelId", alertRule.Annotations[ngModels.PanelIDAnnotation], "alertRuleUID", alertRule.UID, "error", err.Error())
+		return
+	}
+
+	// The states keep changing after the evaluation, the annotations are built right away.
+	dropped := 0
+	for _, t := range transitions {
+		entry := annotationEntry{
+			ruleUID: alertRule.UID,
+			dashUID: dashUID,
+			item: &annotations.Item{
+				OrgId:     alertRule.OrgID,
+				PanelId:   panelID,
+				PrevState: t.PreviousState.String(),
+				NewState:  t.State.State.String(),
+				Text:      fmt.Sprintf("%s {%s} - %s", alertRule.Title, t.State.Labels.String(), t.State.State.String()),
+				Epoch:     t.State.LastEvaluationTime.UnixNano() / 1e6,
+			},
+		}
+
+		select {
+		case h.queue <- entry:
+		default:
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		h.dropped.Add(float64(dropped))
+		h.log.Warn("alert annotation queue is full, state transitions are not annotated", "alertRuleUID", alertRule.UID, "dropped", dropped)
+	}
+}
+
+// Run saves the queued annotations until the context is cancelled, then saves the remaining ones.
+func (h *AnnotationStateHistorian) Run(ctx context.Context) error {
+	for {
+		select {
+		case entry := <-h.queue:
+			h.saveAnnotations(h.nextBatch(entry))
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-h.queue:
+					h.saveAnnotations(h.nextBatch(entry))
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// nextBatch returns the entry with the entries that are already queued, up to stateHistoryBatchSize.
+func (h *AnnotationStateHistorian) nextBatch(entry annotationEntry) []annotationEntry {
+	batch := []annotationEntry{entry}
+	for len(batch) < stateHistoryBatchSize {
+		select {
+		case entry := <-h.queue:
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (h *AnnotationStateHistorian) saveAnnotations(batch []annotationEntry) {
+	repo := annotations.GetRepository()
+	if repo == nil {
+		return
+	}
+
+	type dashboardKey struct {
+		orgID int64
+		uid   string
+	}
+	// the dashboards are looked up once per batch, nil when the lookup failed
+	dashboardIDs := make(map[dashboardKey]*int64)
+	for _, entry := range batch {
+		key := dashboardKey{orgID: entry.item.OrgId, uid: entry.dashUID}
+		dashboardID, ok := dashboardIDs[key]
+		if !ok {
+			query := &models.GetDashboardQuery{Uid: entry.dashUID, OrgId: entry.item.OrgId}
+			if err := bus.Dispatch(query); err != nil {
+				h.log.Error("error getting dashboard for alert annotation", "dashboardUID", entry.dashUID, "alertRuleUID", entry.ruleUID, "error", err.Error())
+			} else {
+				dashboardID = &query.Result.Id
+			}
+			dashboardIDs[key] = dashboardID
+		}
+		if dashboardID == nil {
+			continue
+		}
+
+		entry.item.DashboardId = *dashboardID
+		if err := repo.Save(entry.item); err != nil {
+			h.log.Error("error saving alert annotation", "alertRuleUID", entry.ruleUID, "error", err.Error())
+		}
+	}
+}
+
+const (
+	// stateHistoryQueueSize is the number of state transitions waiting to be saved, more are dropped.
+	stateHistoryQueueSize = 10000
+	// stateHistoryBatchSize is the maximum number of state transitions saved together.
+	stateHistoryBatchSize = 100
+	// stateHistoryFlushInterval is the maximum time a state transition waits before it is saved.
+	stateHistoryFlushInterval = time.Second
+)
+
+// DatabaseStateHistorian records the state transitions of alert instances in the database.
+// The transitions are queued and saved in batches by Run, so that the evaluations don't wait for the database.
+type DatabaseStateHistorian struct {
+	log     log.Logger
+	store   StateHistoryStore
+	queue   chan ngModels.AlertStateHistory
+	dropped prometheus.Counter
+}
+
+// StateHistoryStore is the store the DatabaseStateHistorian records the state transitions into.
+type StateHistoryStore interface {
+	SaveAlertStateHistory(cmd *ngModels.SaveAlertStateHistoryCommand) error
+}
+
+func NewDatabaseStateHistorian(logger log.Logger, store StateHistoryStore, m *metrics.Metrics) *DatabaseStateHistorian {
+	return &DatabaseStateHistorian{
+		log:     logger,
+		store:   store,
+		queue:   make(chan ngModels.AlertStateHistory, stateHistoryQueueSize),
+		dropped: m.StateHistoryDropped.WithLabelValues("database"),
+	}
+}
+
+func (h *DatabaseStateHistorian) RecordStates(alertRule *ngModels.AlertRule, transitions []StateTransition) {
+	dropped := 0
+	for _, t := range transitions {
+		entry := ngModels.AlertStateHistory{
+			RuleOrgID:     alertRule.OrgID,
+			RuleUID:       alertRule.UID,
+			Labels:        ngModels.InstanceLabels(t.State.Labels),
+			PreviousState: ngModels.InstanceStateType(t.PreviousState.String()),
+			State:         ngModels.InstanceStateType(t.State.State.String()),
+			EvaluatedAt:   t.State.LastEvaluationTime,
+		}
+		if len(t.State.Results) > 0 {
+			entry.EvaluationString = t.State.Results[len(t.State.Results)-1].EvaluationString
+		}
+		if t.State.Error != nil {
+			entry.Error = t.State.Error.Error()
+		}
+
+		select {
+		case h.queue <- entry:
+		default:
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		h.dropped.Add(float64(dropped))
+		h.log.Warn("alert state history queue is full, state transitions are not recorded", "alertRuleUID", alertRule.UID, "dropped", dropped)
+	}
+}
+
+// Run saves the queued state transitions until the context is cancelled, then saves the remaining ones.
+func (h *DatabaseStateHistorian) Run(ctx context.Context) error {
+	ticker := time.NewTicker(stateHistoryFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ngModels.AlertStateHistory, 0, stateHistoryBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := h.store.SaveAlertStateHistory(&ngModels.SaveAlertStateHistoryCommand{Transitions: batch}); err != nil {
+			h.log.Error("failed to save alert state history", "count", len(batch), "error", err.Error())
+		}
+		batch = make([]ngModels.AlertStateHistory, 0, stateHistoryBatchSize)
+	}
+
+	for {
+		select {
+		case entry := <-h.queue:
+			batch = append(batch, entry)
+			if len(batch) >= stateHistoryBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-h.queue:
+					batch = append(batch, entry)
+					if len(batch) >= stateHistoryBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// NopHistorian doesn't record state transitions.
+type NopHistorian struct{}
+
+func (NopHistorian) RecordStates(*ngModels.AlertRule, []StateTransition) {}
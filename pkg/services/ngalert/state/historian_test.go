@@ -0,0 +1,191 @@
+package state_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	grafanaModels "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+type fakeStateHistoryStore struct {
+	mtx  sync.Mutex
+	cmds []*models.SaveAlertStateHistoryCommand
+}
+
+func (s *fakeStateHistoryStore) SaveAlertStateHistory(cmd *models.SaveAlertStateHistoryCommand) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.cmds = append(s.cmds, cmd)
+	return nil
+}
+
+func (s *fakeStateHistoryStore) saved() []models.AlertStateHistory {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var transitions []models.AlertStateHistory
+	for _, cmd := range s.cmds {
+		transitions = append(transitions, cmd.Transitions...)
+	}
+	return transitions
+}
+
+func TestDatabaseStateHistorian(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2021-03-25")
+	require.NoError(t, err)
+	alertRule := &models.AlertRule{OrgID: 1, UID: "test_alert_rule_uid"}
+
+	store := &fakeStateHistoryStore{}
+	historian := state.NewDatabaseStateHistorian(log.New("test_state_historian"), store, nilMetrics)
+
+	s := &state.State{
+		Labels:             data.Labels{"instance_label": "test"},
+		State:              eval.Alerting,
+		LastEvaluationTime: evaluationTime,
+		Results:            []state.Evaluation{{EvaluationTime: evaluationTime, EvaluationState: eval.Alerting, EvaluationString: "[ var='A' val=1 ]"}},
+	}
+	historian.RecordStates(alertRule, []state.StateTransition{{State: s, PreviousState: eval.Normal}})
+
+	// The transitions are copied when they are recorded, and saved in the background.
+	s.State = eval.Normal
+	require.Empty(t, store.saved())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- historian.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return len(store.saved()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	transition := store.saved()[0]
+	require.Equal(t, "test_alert_rule_uid", transition.RuleUID)
+	require.Equal(t, models.InstanceLabels{"instance_label": "test"}, transition.Labels)
+	require.Equal(t, models.InstanceStateNormal, transition.PreviousState)
+	require.Equal(t, models.InstanceStateFiring, transition.State)
+	require.Equal(t, "[ var='A' val=1 ]", transition.EvaluationString)
+	require.Equal(t, evaluationTime, transition.EvaluatedAt)
+
+	// The transitions recorded before the historian stops are saved in batches.
+	transitions := make([]state.StateTransition, 250)
+	for i := range transitions {
+		transitions[i] = state.StateTransition{State: s, PreviousState: eval.Alerting}
+	}
+	historian.RecordStates(alertRule, transitions)
+	cancel()
+	require.NoError(t, <-done)
+	require.Len(t, store.saved(), 251)
+	for _, cmd := range store.cmds {
+		require.LessOrEqual(t, len(cmd.Transitions), 100)
+	}
+}
+
+type fakeAnnotationRepository struct {
+	annotations.Repository
+
+	mtx   sync.Mutex
+	items []*annotations.Item
+}
+
+func (r *fakeAnnotationRepository) Save(item *annotations.Item) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.items = append(r.items, item)
+	return nil
+}
+
+func (r *fakeAnnotationRepository) saved() []*annotations.Item {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]*annotations.Item{}, r.items...)
+}
+
+func TestAnnotationStateHistorian(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2021-03-25")
+	require.NoError(t, err)
+	alertRule := &models.AlertRule{
+		OrgID: 1,
+		UID:   "test_alert_rule_uid",
+		Title: "test_title",
+		Annotations: map[string]string{
+			models.DashboardUIDAnnotation: "dashboard_uid",
+			models.PanelIDAnnotation:      "2",
+		},
+	}
+
+	repo := &fakeAnnotationRepository{}
+	previousRepo := annotations.GetRepository()
+	annotations.SetRepository(repo)
+	t.Cleanup(func() {
+		annotations.SetRepository(previousRepo)
+	})
+	var lookups int
+	bus.AddHandler("test", func(query *grafanaModels.GetDashboardQuery) error {
+		lookups++
+		query.Result = &grafanaModels.Dashboard{Id: 5, Uid: query.Uid, OrgId: query.OrgId}
+		return nil
+	})
+	t.Cleanup(bus.ClearBusHandlers)
+
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	historian := state.NewAnnotationStateHistorian(log.New("test_state_historian"), m)
+
+	s := &state.State{
+		Labels:             data.Labels{"instance_label": "test"},
+		State:              eval.Alerting,
+		LastEvaluationTime: evaluationTime,
+	}
+	transitions := make([]state.StateTransition, 10)
+	for i := range transitions {
+		transitions[i] = state.StateTransition{State: s, PreviousState: eval.Normal}
+	}
+	historian.RecordStates(alertRule, transitions)
+
+	// The annotations are built when the transitions are recorded, and saved in the background.
+	s.State = eval.Normal
+	require.Empty(t, repo.saved())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- historian.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return len(repo.saved()) == 10
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	item := repo.saved()[0]
+	require.Equal(t, int64(1), item.OrgId)
+	require.Equal(t, int64(5), item.DashboardId)
+	require.Equal(t, int64(2), item.PanelId)
+	require.Equal(t, "Normal", item.PrevState)
+	require.Equal(t, "Alerting", item.NewState)
+	require.Equal(t, "test_title {instance_label=test} - Alerting", item.Text)
+	require.Equal(t, evaluationTime.UnixNano()/1e6, item.Epoch)
+	// The dashboard is looked up once for the annotations saved together.
+	require.Equal(t, 1, lookups)
+
+	// The transitions are dropped when the queue is full.
+	transitions = make([]state.StateTransition, 10005)
+	for i := range transitions {
+		transitions[i] = state.StateTransition{State: s, PreviousState: eval.Alerting}
+	}
+	historian.RecordStates(alertRule, transitions)
+	require.Equal(t, float64(5), testutil.ToFloat64(m.StateHistoryDropped.WithLabelValues("annotation")))
+}
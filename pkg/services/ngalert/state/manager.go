@@ -16,15 +16,17 @@ type Manager struct {
 	ResendDelay time.Duration
 	Log         log.Logger
 	metrics     *metrics.Metrics
+	historian   Historian
 }
 
-func NewManager(logger log.Logger, metrics *metrics.Metrics) *Manager {
+func NewManager(logger log.Logger, metrics *metrics.Metrics, historian Historian) *Manager {
 	manager := &Manager{
 		cache:       newCache(logger, metrics),
 		quit:        make(chan struct{}),
 		ResendDelay: 1 * time.Minute, // TODO: make this configurable
 		Log:         logger,
 		metrics:     metrics,
+		historian:   historian,
 	}
 	go manager.recordMetrics()
 	return manager
@@ -34,8 +36,8 @@ func (st *Manager) Close() {
 	st.quit <- struct{}{}
 }
 
-func (st *Manager) getOrCreate(alertRule *ngModels.AlertRule, result eval.Result) *State {
-	return st.cache.getOrCreate(alertRule, result)
+func (st *Manager) getOrCreate(alertRule *ngModels.AlertRule, ruleLabels map[string]string, result eval.Result) *State {
+	return st.cache.getOrCreate(alertRule, ruleLabels, result)
 }
 
 func (st *Manager) set(entry *State) {
@@ -59,20 +61,43 @@ func (st *Manager) RemoveByRuleUID(orgID int64, ruleUID string) {
 func (st *Manager) ProcessEvalResults(alertRule *ngModels.AlertRule, results eval.Results) []*State {
 	st.Log.Debug("state manager processing evaluation results", "uid", alertRule.UID, "resultCount", len(results))
 	var states []*State
+	var transitions []StateTransition
 	for _, result := range results {
-		s := st.setNextState(alertRule, result)
+		s, previousState := st.setNextState(alertRule, result)
 		states = append(states, s)
+		if s.State != previousState {
+			transitions = append(transitions, StateTransition{State: s, PreviousState: previousState})
+		}
 	}
+	st.historian.RecordStates(alertRule, transitions)
 	st.Log.Debug("returning changed states to scheduler", "count", len(states))
 	return states
 }
 
-//Set the current state based on evaluation results
-func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Result) *State {
-	currentState := st.getOrCreate(alertRule, result)
+//Set the current state based on evaluation results, it also returns the state before the evaluation.
+func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Result) (*State, eval.State) {
+	ruleLabels, err := expandLabels(alertRule, result)
+	currentState := st.getOrCreate(alertRule, ruleLabels, result)
+	previousState := currentState.State
+
+	// annotations are expanded at every evaluation as they can change with the values
+	annotations, annotationsErr := expandAnnotations(alertRule, currentState.Labels, result)
+	currentState.Annotations = annotations
+	if err == nil {
+		err = annotationsErr
+	}
+	if err != nil {
+		st.Log.Error("failed to expand templates of alert rule", "uid", alertRule.UID, "error", err.Error())
+		// the error of the evaluation is kept as it is more relevant
+		if result.State != eval.Error {
+			result.State = eval.Error
+			result.Error = err
+		}
+	}
 
 	currentState.LastEvaluationTime = result.EvaluatedAt
 	currentState.EvaluationDuration = result.EvaluationDuration
+	currentState.Values = newInstanceValues(result.Values)
 	currentState.Results = append(currentState.Results, Evaluation{
 		EvaluationTime:   result.EvaluatedAt,
 		EvaluationState:  result.State,
@@ -83,7 +108,7 @@ func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Resul
 	st.Log.Debug("setting alert state", "uid", alertRule.UID)
 	switch result.State {
 	case eval.Normal:
-		currentState = currentState.resultNormal(result)
+		currentState = currentState.resultNormal(alertRule, result)
 	case eval.Alerting:
 		currentState = currentState.resultAlerting(alertRule, result)
 	case eval.Error:
@@ -94,7 +119,7 @@ func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Resul
 	}
 
 	st.set(currentState)
-	return currentState
+	return currentState, previousState
 }
 
 func (st *Manager) GetAll(orgID int64) []*State {
@@ -777,7 +777,7 @@ func TestProcessEvalResults(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		st := state.NewManager(log.New("test_state_manager"), nilMetrics)
+		st := state.NewManager(log.New("test_state_manager"), nilMetrics, state.NopHistorian{})
 		t.Run(tc.desc, func(t *testing.T) {
 			for _, res := range tc.evalResults {
 				_ = st.ProcessEvalResults(tc.alertRule, res)
@@ -790,3 +790,186 @@ func TestProcessEvalResults(t *testing.T) {
 		})
 	}
 }
+
+type fakeHistorian struct {
+	transitions []state.StateTransition
+}
+
+func (h *fakeHistorian) RecordStates(_ *models.AlertRule, transitions []state.StateTransition) {
+	// The states are owned by the manager's cache, so keep a copy as they were at the transition.
+	for _, t := range transitions {
+		s := *t.State
+		h.transitions = append(h.transitions, state.StateTransition{State: &s, PreviousState: t.PreviousState})
+	}
+}
+
+func TestProcessEvalResults_RecordsStateTransitions(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2021-03-25")
+	require.NoError(t, err)
+
+	alertRule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		NamespaceUID:    "test_namespace_uid",
+		IntervalSeconds: 10,
+	}
+	evalResults := []eval.Results{
+		{eval.Result{Instance: data.Labels{"instance_label": "test"}, State: eval.Normal, EvaluatedAt: evaluationTime}},
+		{eval.Result{Instance: data.Labels{"instance_label": "test"}, State: eval.Alerting, EvaluatedAt: evaluationTime.Add(10 * time.Second)}},
+		{eval.Result{Instance: data.Labels{"instance_label": "test"}, State: eval.Alerting, EvaluatedAt: evaluationTime.Add(20 * time.Second)}},
+		{eval.Result{Instance: data.Labels{"instance_label": "test"}, State: eval.Normal, EvaluatedAt: evaluationTime.Add(30 * time.Second)}},
+	}
+
+	historian := &fakeHistorian{}
+	st := state.NewManager(log.New("test_state_manager"), nilMetrics, historian)
+	for _, res := range evalResults {
+		_ = st.ProcessEvalResults(alertRule, res)
+	}
+
+	require.Len(t, historian.transitions, 2)
+	assert.Equal(t, eval.Normal, historian.transitions[0].PreviousState)
+	assert.Equal(t, eval.Alerting, historian.transitions[0].State.State)
+	assert.Equal(t, evaluationTime.Add(10*time.Second), historian.transitions[0].State.LastEvaluationTime)
+	assert.Equal(t, eval.Alerting, historian.transitions[1].PreviousState)
+	assert.Equal(t, eval.Normal, historian.transitions[1].State.State)
+	assert.Equal(t, evaluationTime.Add(30*time.Second), historian.transitions[1].State.LastEvaluationTime)
+}
+
+func TestProcessEvalResults_KeepFiringFor(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2021-03-25")
+	require.NoError(t, err)
+
+	alertRule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		NamespaceUID:    "test_namespace_uid",
+		IntervalSeconds: 10,
+		KeepFiringFor:   30 * time.Second,
+	}
+
+	steps := []struct {
+		desc                    string
+		result                  eval.State
+		expectedState           eval.State
+		expectedKeptFiringSince time.Duration
+	}{
+		{desc: "normal -> alerting", result: eval.Alerting, expectedState: eval.Alerting},
+		{desc: "alerting is kept firing on the first normal result", result: eval.Normal, expectedState: eval.Alerting, expectedKeptFiringSince: 10 * time.Second},
+		{desc: "alerting is kept firing while normal for less than KeepFiringFor", result: eval.Normal, expectedState: eval.Alerting, expectedKeptFiringSince: 10 * time.Second},
+		{desc: "an alerting result resets the keep firing period", result: eval.Alerting, expectedState: eval.Alerting},
+		{desc: "alerting is kept firing again", result: eval.Normal, expectedState: eval.Alerting, expectedKeptFiringSince: 40 * time.Second},
+		{desc: "alerting is still kept firing", result: eval.Normal, expectedState: eval.Alerting, expectedKeptFiringSince: 40 * time.Second},
+		{desc: "alerting is kept firing up to KeepFiringFor", result: eval.Normal, expectedState: eval.Alerting, expectedKeptFiringSince: 40 * time.Second},
+		{desc: "alerting -> normal after being normal for KeepFiringFor", result: eval.Normal, expectedState: eval.Normal},
+	}
+
+	historian := &fakeHistorian{}
+	st := state.NewManager(log.New("test_state_manager"), nilMetrics, historian)
+	var s *state.State
+	for i, step := range steps {
+		evaluatedAt := evaluationTime.Add(time.Duration(i) * 10 * time.Second)
+		states := st.ProcessEvalResults(alertRule, eval.Results{
+			eval.Result{Instance: data.Labels{"instance_label": "test"}, State: step.result, EvaluatedAt: evaluatedAt},
+		})
+		require.Len(t, states, 1, step.desc)
+		s = states[0]
+
+		require.Equal(t, step.expectedState, s.State, step.desc)
+		if step.expectedKeptFiringSince == 0 {
+			require.True(t, s.KeptFiringSince.IsZero(), step.desc)
+		} else {
+			require.Equal(t, evaluationTime.Add(step.expectedKeptFiringSince), s.KeptFiringSince, step.desc)
+		}
+		if s.State == eval.Alerting {
+			// the alert must not be resolved by the Alertmanager while it is kept firing
+			require.True(t, s.EndsAt.After(evaluatedAt), step.desc)
+		}
+	}
+
+	// the alert started firing only once and was resolved only once
+	require.Len(t, historian.transitions, 2)
+	assert.Equal(t, eval.Alerting, historian.transitions[0].State.State)
+	assert.Equal(t, eval.Normal, historian.transitions[1].State.State)
+	assert.Equal(t, evaluationTime.Add(70*time.Second), s.EndsAt)
+
+	t.Run("pending alerts are not kept firing", func(t *testing.T) {
+		rule := *alertRule
+		rule.UID = "test_alert_rule_uid_pending"
+		rule.For = time.Minute
+
+		st := state.NewManager(log.New("test_state_manager"), nilMetrics, state.NopHistorian{})
+		states := st.ProcessEvalResults(&rule, eval.Results{
+			eval.Result{Instance: data.Labels{"instance_label": "test"}, State: eval.Alerting, EvaluatedAt: evaluationTime},
+		})
+		require.Equal(t, eval.Pending, states[0].State)
+
+		states = st.ProcessEvalResults(&rule, eval.Results{
+			eval.Result{Instance: data.Labels{"instance_label": "test"}, State: eval.Normal, EvaluatedAt: evaluationTime.Add(10 * time.Second)},
+		})
+		require.Equal(t, eval.Normal, states[0].State)
+		require.True(t, states[0].KeptFiringSince.IsZero())
+	})
+}
+
+func TestProcessEvalResults_Templates(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2021-03-25")
+	require.NoError(t, err)
+
+	alertRule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		NamespaceUID:    "test_namespace_uid",
+		IntervalSeconds: 10,
+		ExecErrState:    models.AlertingErrState,
+		Labels:          map[string]string{"severity": `{{ if gt $values.B.Value 90.0 }}critical{{ else }}warning{{ end }}`},
+		Annotations: map[string]string{
+			"summary": `CPU at {{ humanize $values.B.Value }}% on {{ $labels.host }}`,
+		},
+	}
+
+	cpu := 93.12345
+	result := func(value float64, evaluatedAt time.Time) eval.Result {
+		return eval.Result{
+			Instance:    data.Labels{"host": "host-7"},
+			State:       eval.Alerting,
+			EvaluatedAt: evaluatedAt,
+			Values: map[string]eval.NumberValueCapture{
+				"B": {Var: "B", Labels: data.Labels{"host": "host-7"}, Value: &value},
+			},
+		}
+	}
+
+	st := state.NewManager(log.New("test_state_manager"), nilMetrics, state.NopHistorian{})
+	states := st.ProcessEvalResults(alertRule, eval.Results{result(cpu, evaluationTime)})
+	require.Len(t, states, 1)
+	require.NoError(t, states[0].Error)
+	require.Equal(t, "critical", states[0].Labels["severity"])
+	require.Equal(t, "CPU at 93.12% on host-7", states[0].Annotations["summary"])
+	require.Equal(t, models.InstanceValues{"B": {Labels: data.Labels{"host": "host-7"}, Value: &cpu}}, states[0].Values)
+	// the annotations of the alert rule are not modified
+	require.Equal(t, `CPU at {{ humanize $values.B.Value }}% on {{ $labels.host }}`, alertRule.Annotations["summary"])
+
+	t.Run("annotations are expanded at every evaluation", func(t *testing.T) {
+		states := st.ProcessEvalResults(alertRule, eval.Results{result(95, evaluationTime.Add(10*time.Second))})
+		require.Len(t, states, 1)
+		require.Equal(t, "CPU at 95% on host-7", states[0].Annotations["summary"])
+	})
+
+	t.Run("template errors are state errors", func(t *testing.T) {
+		rule := *alertRule
+		rule.UID = "test_alert_rule_uid_error"
+		rule.Annotations = map[string]string{"summary": `{{ humanize $labels.host }}`}
+
+		st := state.NewManager(log.New("test_state_manager"), nilMetrics, state.NopHistorian{})
+		states := st.ProcessEvalResults(&rule, eval.Results{result(cpu, evaluationTime)})
+		require.Len(t, states, 1)
+		require.Error(t, states[0].Error)
+		require.Contains(t, states[0].Error.Error(), "failed to expand template of summary")
+		require.Equal(t, eval.Alerting, states[0].State)
+		require.Equal(t, `{{ humanize $labels.host }}`, states[0].Annotations["summary"])
+		require.Equal(t, eval.Error, states[0].Results[0].EvaluationState)
+	})
+}
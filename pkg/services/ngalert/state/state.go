@@ -1,6 +1,7 @@
 package state
 
 import (
+	"math"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -21,7 +22,10 @@ type State struct {
 	LastSentAt         time.Time
 	Annotations        map[string]string
 	Labels             data.Labels
+	Values             ngModels.InstanceValues
 	Error              error
+	// KeptFiringSince is the time of the first normal result of an alert that is kept firing, see AlertRule.KeepFiringFor.
+	KeptFiringSince time.Time
 }
 
 type Evaluation struct {
@@ -30,7 +34,40 @@ type Evaluation struct {
 	EvaluationString string
 }
 
-func (a *State) resultNormal(result eval.Result) *State {
+// newInstanceValues converts the values of an evaluation result to the values of an alert instance.
+func newInstanceValues(values map[string]eval.NumberValueCapture) ngModels.InstanceValues {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make(ngModels.InstanceValues, len(values))
+	for refID, c := range values {
+		v := ngModels.InstanceValue{Labels: c.Labels}
+		if c.Value != nil && !math.IsNaN(*c.Value) && !math.IsInf(*c.Value, 0) {
+			f := *c.Value
+			v.Value = &f
+		}
+		result[refID] = v
+	}
+	return result
+}
+
+func (a *State) resultNormal(alertRule *ngModels.AlertRule, result eval.Result) *State {
+	if a.State == eval.Alerting && alertRule.KeepFiringFor > 0 {
+		if a.KeptFiringSince.IsZero() {
+			a.KeptFiringSince = result.EvaluatedAt
+		}
+		// the alert keeps firing until the results have been normal for KeepFiringFor
+		if result.EvaluatedAt.Sub(a.KeptFiringSince) < alertRule.KeepFiringFor {
+			if !(alertRule.For > 0) {
+				a.EndsAt = result.EvaluatedAt.Add(time.Duration(alertRule.IntervalSeconds*2) * time.Second)
+			} else {
+				a.EndsAt = result.EvaluatedAt.Add(alertRule.For)
+			}
+			return a
+		}
+	}
+
+	a.KeptFiringSince = time.Time{}
 	if a.State != eval.Normal {
 		a.EndsAt = result.EvaluatedAt
 		a.StartsAt = result.EvaluatedAt
@@ -41,6 +78,7 @@ func (a *State) resultNormal(result eval.Result) *State {
 }
 
 func (a *State) resultAlerting(alertRule *ngModels.AlertRule, result eval.Result) *State {
+	a.KeptFiringSince = time.Time{}
 	switch a.State {
 	case eval.Alerting:
 		if !(alertRule.For > 0) {
@@ -74,6 +112,7 @@ func (a *State) resultAlerting(alertRule *ngModels.AlertRule, result eval.Result
 }
 
 func (a *State) resultError(alertRule *ngModels.AlertRule, result eval.Result) *State {
+	a.KeptFiringSince = time.Time{}
 	a.Error = result.Error
 	if a.StartsAt.IsZero() {
 		a.StartsAt = result.EvaluatedAt
@@ -92,6 +131,7 @@ func (a *State) resultError(alertRule *ngModels.AlertRule, result eval.Result) *
 }
 
 func (a *State) resultNoData(alertRule *ngModels.AlertRule, result eval.Result) *State {
+	a.KeptFiringSince = time.Time{}
 	if a.StartsAt.IsZero() {
 		a.StartsAt = result.EvaluatedAt
 	}
@@ -1,10 +1,13 @@
 package state
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -73,3 +76,21 @@ func TestNeedsSending(t *testing.T) {
 		})
 	}
 }
+
+func TestNewInstanceValues(t *testing.T) {
+	assert.Nil(t, newInstanceValues(nil))
+
+	value, nan, inf := 1.5, math.NaN(), math.Inf(1)
+	values := newInstanceValues(map[string]eval.NumberValueCapture{
+		"A": {Var: "A", Labels: data.Labels{"host": "a"}, Value: &value},
+		"B": {Var: "B", Value: &nan},
+		"C": {Var: "C", Value: &inf},
+		"D": {Var: "D", Value: nil},
+	})
+	assert.Equal(t, ngModels.InstanceValues{
+		"A": {Labels: data.Labels{"host": "a"}, Value: &value},
+		"B": {},
+		"C": {},
+		"D": {},
+	}, values)
+}
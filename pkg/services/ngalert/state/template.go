@@ -0,0 +1,252 @@
+package state
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// templateDefs are prepended to every template so that the data can be accessed as in Prometheus alerting rules.
+const templateDefs = "{{- $labels := .Labels -}}{{- $values := .Values -}}{{- $value := .Value -}}"
+
+// templateData is the data of the templates of annotations and labels.
+type templateData struct {
+	// Labels are the labels of the alert instance.
+	Labels map[string]string
+	// Values are the values of the reduced expressions and queries keyed by RefID.
+	Values map[string]templateCaptureValue
+	// Value is the evaluation string of the result.
+	Value string
+}
+
+// templateCaptureValue is a value of $values, it prints as its value.
+type templateCaptureValue struct {
+	Labels map[string]string
+	Value  float64
+}
+
+func (v templateCaptureValue) String() string {
+	return strconv.FormatFloat(v.Value, 'f', -1, 64)
+}
+
+func newTemplateData(labels map[string]string, result eval.Result) templateData {
+	values := make(map[string]templateCaptureValue, len(result.Values))
+	for refID, c := range result.Values {
+		v := math.NaN()
+		if c.Value != nil {
+			v = *c.Value
+		}
+		values[refID] = templateCaptureValue{Labels: c.Labels, Value: v}
+	}
+	return templateData{
+		Labels: labels,
+		Values: values,
+		Value:  result.EvaluationString,
+	}
+}
+
+// expandLabels expands the templates of the labels of the alert rule, the labels of the result can be accessed with $labels.
+// If a template fails the label keeps its template and the first error is returned.
+func expandLabels(alertRule *ngModels.AlertRule, result eval.Result) (map[string]string, error) {
+	return expandTemplates(alertRule, alertRule.Labels, newTemplateData(result.Instance, result))
+}
+
+// expandAnnotations expands the templates of the annotations of the alert rule, the labels of the alert instance can be accessed with $labels.
+// If a template fails the annotation keeps its template and the first error is returned.
+func expandAnnotations(alertRule *ngModels.AlertRule, labels map[string]string, result eval.Result) (map[string]string, error) {
+	return expandTemplates(alertRule, alertRule.Annotations, newTemplateData(labels, result))
+}
+
+func expandTemplates(alertRule *ngModels.AlertRule, templates map[string]string, data templateData) (map[string]string, error) {
+	keys := make([]string, 0, len(templates))
+	for k := range templates {
+		keys = append(keys, k)
+	}
+	// sorted to always return the error of the same template
+	sort.Strings(keys)
+
+	var firstErr error
+	expanded := make(map[string]string, len(templates))
+	for _, k := range keys {
+		v, err := expandTemplate("__alert_"+alertRule.Title, templates[k], data)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to expand template of %s: %w", k, err)
+			}
+			v = templates[k]
+		}
+		expanded[k] = v
+	}
+	return expanded, firstErr
+}
+
+func expandTemplate(name, text string, data templateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(templateDefs + text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// templateFuncs are the functions of the templates, the humanize functions behave like their Prometheus counterparts.
+var templateFuncs = template.FuncMap{
+	"toUpper": strings.ToUpper,
+	"toLower": strings.ToLower,
+	"title":   strings.Title,
+	"match":   regexp.MatchString,
+	"reReplaceAll": func(pattern, repl, text string) (string, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return re.ReplaceAllString(text, repl), nil
+	},
+	"humanize": func(i interface{}) (string, error) {
+		v, err := toFloat64(i)
+		if err != nil {
+			return "", err
+		}
+		if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprintf("%.4g", v), nil
+		}
+		if math.Abs(v) >= 1 {
+			prefix := ""
+			for _, p := range []string{"k", "M", "G", "T", "P", "E", "Z", "Y"} {
+				if math.Abs(v) < 1000 {
+					break
+				}
+				prefix = p
+				v /= 1000
+			}
+			return fmt.Sprintf("%.4g%s", v, prefix), nil
+		}
+		v, prefix := scaleDown(v)
+		return fmt.Sprintf("%.4g%s", v, prefix), nil
+	},
+	"humanize1024": func(i interface{}) (string, error) {
+		v, err := toFloat64(i)
+		if err != nil {
+			return "", err
+		}
+		if math.Abs(v) <= 1 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprintf("%.4g", v), nil
+		}
+		prefix := ""
+		for _, p := range []string{"ki", "Mi", "Gi", "Ti", "Pi", "Ei", "Zi", "Yi"} {
+			if math.Abs(v) < 1024 {
+				break
+			}
+			prefix = p
+			v /= 1024
+		}
+		return fmt.Sprintf("%.4g%s", v, prefix), nil
+	},
+	"humanizeDuration": func(i interface{}) (string, error) {
+		v, err := toFloat64(i)
+		if err != nil {
+			return "", err
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprintf("%.4g", v), nil
+		}
+		if v == 0 {
+			return fmt.Sprintf("%.4gs", v), nil
+		}
+		if math.Abs(v) >= 1 {
+			sign := ""
+			if v < 0 {
+				sign = "-"
+				v = -v
+			}
+			seconds := int64(v) % 60
+			minutes := (int64(v) / 60) % 60
+			hours := (int64(v) / 60 / 60) % 24
+			days := int64(v) / 60 / 60 / 24
+			// seconds are displayed as an integer from minutes to days
+			if days != 0 {
+				return fmt.Sprintf("%s%dd %dh %dm %ds", sign, days, hours, minutes, seconds), nil
+			}
+			if hours != 0 {
+				return fmt.Sprintf("%s%dh %dm %ds", sign, hours, minutes, seconds), nil
+			}
+			if minutes != 0 {
+				return fmt.Sprintf("%s%dm %ds", sign, minutes, seconds), nil
+			}
+			return fmt.Sprintf("%s%.4gs", sign, v), nil
+		}
+		v, prefix := scaleDown(v)
+		return fmt.Sprintf("%.4g%ss", v, prefix), nil
+	},
+	"humanizePercentage": func(i interface{}) (string, error) {
+		v, err := toFloat64(i)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%.4g%%", v*100), nil
+	},
+	"humanizeTimestamp": func(i interface{}) (string, error) {
+		v, err := toFloat64(i)
+		if err != nil {
+			return "", err
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprintf("%.4g", v), nil
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC().String(), nil
+	},
+}
+
+// scaleDown scales a value smaller than 1 up to the first SI prefix it is at least 1 in.
+func scaleDown(v float64) (float64, string) {
+	prefix := ""
+	for _, p := range []string{"m", "u", "n", "p", "f", "a", "z", "y"} {
+		if math.Abs(v) >= 1 {
+			break
+		}
+		prefix = p
+		v *= 1000
+	}
+	return v, prefix
+}
+
+func toFloat64(i interface{}) (float64, error) {
+	switch v := i.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case templateCaptureValue:
+		return v.Value, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case time.Duration:
+		return v.Seconds(), nil
+	default:
+		return 0, fmt.Errorf("can't convert %T to float", i)
+	}
+}
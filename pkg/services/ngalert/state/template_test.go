@@ -0,0 +1,57 @@
+package state
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	value := 1234.5678
+	result := eval.Result{
+		EvaluationString: "[ var='B' labels={host=a} value=1234.5678 ]",
+		Values: map[string]eval.NumberValueCapture{
+			"B": {Var: "B", Labels: data.Labels{"host": "a"}, Value: &value},
+			"C": {Var: "C", Labels: data.Labels{"host": "a"}, Value: nil},
+		},
+	}
+	templateData := newTemplateData(map[string]string{"host": "a"}, result)
+
+	cases := []struct {
+		desc     string
+		text     string
+		expected string
+		err      bool
+	}{
+		{desc: "text without template", text: "no template", expected: "no template"},
+		{desc: "labels", text: "{{ $labels.host }}", expected: "a"},
+		{desc: "value", text: "{{ $value }}", expected: "[ var='B' labels={host=a} value=1234.5678 ]"},
+		{desc: "values", text: "{{ $values.B }} {{ $values.B.Labels.host }}", expected: "1234.5678 a"},
+		{desc: "null value", text: "{{ $values.C }}", expected: "NaN"},
+		{desc: "humanize", text: "{{ humanize $values.B }} {{ humanize 0.0012 }}", expected: "1.235k 1.2m"},
+		{desc: "humanize1024", text: "{{ humanize1024 2048 }}", expected: "2ki"},
+		{desc: "humanizeDuration", text: "{{ humanizeDuration $values.B.Value }} {{ humanizeDuration 0.5 }}", expected: "20m 34s 500ms"},
+		{desc: "humanizePercentage", text: "{{ humanizePercentage 0.1234 }}", expected: "12.34%"},
+		{desc: "humanizeTimestamp", text: "{{ humanizeTimestamp 1620000000 }}", expected: "2021-05-03 00:00:00 +0000 UTC"},
+		{desc: "string functions", text: `{{ toUpper "a" }}{{ toLower "B" }}{{ title "c" }}{{ reReplaceAll "-[0-9]+" "" "host-7" }}`, expected: "AbChost"},
+		{desc: "invalid template", text: "{{ $labels.host", err: true},
+		{desc: "invalid argument", text: "{{ humanize $labels.host }}", err: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			actual, err := expandTemplate("test", tc.text, templateData)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	require.True(t, math.IsNaN(templateData.Values["C"].Value))
+}
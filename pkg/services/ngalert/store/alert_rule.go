@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/common/model"
+
 	"github.com/grafana/grafana/pkg/services/guardian"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -42,6 +44,9 @@ type RuleStore interface {
 	DeleteRuleGroupAlertRules(orgID int64, namespaceUID string, ruleGroup string) ([]string, error)
 	DeleteAlertInstancesByRuleUID(orgID int64, ruleUID string) error
 	GetAlertRuleByUID(*ngmodels.GetAlertRuleByUIDQuery) error
+	GetAlertRuleVersions(query *ngmodels.ListAlertRuleVersionsQuery) error
+	GetAlertRuleVersion(query *ngmodels.GetAlertRuleVersionQuery) error
+	RestoreAlertRuleVersion(cmd *ngmodels.RestoreAlertRuleVersionCommand) error
 	GetAlertRulesForScheduling(query *ngmodels.ListAlertRulesQuery) error
 	GetOrgAlertRules(query *ngmodels.ListAlertRulesQuery) error
 	GetNamespaceAlertRules(query *ngmodels.ListNamespaceAlertRulesQuery) error
@@ -50,6 +55,7 @@ type RuleStore interface {
 	GetNamespaceByUID(string, int64, *models.SignedInUser) (*models.Folder, error)
 	GetOrgRuleGroups(query *ngmodels.ListOrgRuleGroupsQuery) error
 	UpsertAlertRules([]UpsertRule) error
+	InsertAlertRules([]ngmodels.AlertRule) error
 	UpdateRuleGroup(UpdateRuleGroupCmd) error
 }
 
@@ -209,27 +215,7 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 				}
 				r.New.UID = uid
 
-				if r.New.IntervalSeconds == 0 {
-					r.New.IntervalSeconds = st.DefaultIntervalSeconds
-				}
-
-				r.New.Version = 1
-
-				if r.New.NoDataState == "" {
-					// set default no data state
-					r.New.NoDataState = ngmodels.NoData
-				}
-
-				if r.New.ExecErrState == "" {
-					// set default error state
-					r.New.ExecErrState = ngmodels.AlertingErrState
-				}
-
-				if err := st.validateAlertRule(r.New); err != nil {
-					return err
-				}
-
-				if err := (&r.New).PreSave(TimeNow); err != nil {
+				if err := st.prepareNewAlertRule(&r.New); err != nil {
 					return err
 				}
 
@@ -280,31 +266,55 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 				}
 
 				// no way to update multiple rules at once
-				if _, err := sess.ID(r.Existing.ID).Update(r.New); err != nil {
+				// the record, keep_firing_for and is_paused columns are always updated so that they can be unset
+				if _, err := sess.ID(r.Existing.ID).MustCols("record", "keep_firing_for", "is_paused").Update(r.New); err != nil {
 					return fmt.Errorf("failed to update rule %s: %w", r.New.Title, err)
 				}
 
 				parentVersion = r.Existing.Version
 			}
 
-			ruleVersions = append(ruleVersions, ngmodels.AlertRuleVersion{
-				RuleOrgID:        r.New.OrgID,
-				RuleUID:          r.New.UID,
-				RuleNamespaceUID: r.New.NamespaceUID,
-				RuleGroup:        r.New.RuleGroup,
-				ParentVersion:    parentVersion,
-				Version:          r.New.Version,
-				Created:          r.New.Updated,
-				Condition:        r.New.Condition,
-				Title:            r.New.Title,
-				Data:             r.New.Data,
-				IntervalSeconds:  r.New.IntervalSeconds,
-				NoDataState:      r.New.NoDataState,
-				ExecErrState:     r.New.ExecErrState,
-				For:              r.New.For,
-				Annotations:      r.New.Annotations,
-				Labels:           r.New.Labels,
-			})
+			ruleVersions = append(ruleVersions, newAlertRuleVersion(r.New, parentVersion))
+		}
+
+		if len(newRules) > 0 {
+			if _, err := sess.Insert(&newRules); err != nil {
+				return fmt.Errorf("failed to create new rules: %w", err)
+			}
+		}
+
+		if len(ruleVersions) > 0 {
+			if _, err := sess.Insert(&ruleVersions); err != nil {
+				return fmt.Errorf("failed to create new rule versions: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// InsertAlertRules is a handler for creating alert rules with the UIDs they are given.
+// A UID is generated for the rules without one.
+func (st DBstore) InsertAlertRules(rules []ngmodels.AlertRule) error {
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		newRules := make([]ngmodels.AlertRule, 0, len(rules))
+		ruleVersions := make([]ngmodels.AlertRuleVersion, 0, len(rules))
+		for i := range rules {
+			r := rules[i]
+			if r.UID == "" {
+				uid, err := GenerateNewAlertRuleUID(sess, r.OrgID, r.Title)
+				if err != nil {
+					return fmt.Errorf("failed to generate UID for alert rule %q: %w", r.Title, err)
+				}
+				r.UID = uid
+			}
+
+			if err := st.prepareNewAlertRule(&r); err != nil {
+				return err
+			}
+
+			newRules = append(newRules, r)
+			ruleVersions = append(ruleVersions, newAlertRuleVersion(r, 0))
 		}
 
 		if len(newRules) > 0 {
@@ -323,6 +333,152 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 	})
 }
 
+// prepareNewAlertRule sets the default properties of a new alert rule and validates it.
+func (st DBstore) prepareNewAlertRule(rule *ngmodels.AlertRule) error {
+	if rule.IntervalSeconds == 0 {
+		rule.IntervalSeconds = st.DefaultIntervalSeconds
+	}
+
+	rule.Version = 1
+
+	if rule.NoDataState == "" {
+		// set default no data state
+		rule.NoDataState = ngmodels.NoData
+	}
+
+	if rule.ExecErrState == "" {
+		// set default error state
+		rule.ExecErrState = ngmodels.AlertingErrState
+	}
+
+	if err := st.validateAlertRule(*rule); err != nil {
+		return err
+	}
+
+	return rule.PreSave(TimeNow)
+}
+
+func newAlertRuleVersion(rule ngmodels.AlertRule, parentVersion int64) ngmodels.AlertRuleVersion {
+	return ngmodels.AlertRuleVersion{
+		RuleOrgID:        rule.OrgID,
+		RuleUID:          rule.UID,
+		RuleNamespaceUID: rule.NamespaceUID,
+		RuleGroup:        rule.RuleGroup,
+		ParentVersion:    parentVersion,
+		Version:          rule.Version,
+		Created:          rule.Updated,
+		Condition:        rule.Condition,
+		Title:            rule.Title,
+		Data:             rule.Data,
+		IntervalSeconds:  rule.IntervalSeconds,
+		NoDataState:      rule.NoDataState,
+		ExecErrState:     rule.ExecErrState,
+		For:              rule.For,
+		KeepFiringFor:    rule.KeepFiringFor,
+		Annotations:      rule.Annotations,
+		Labels:           rule.Labels,
+		Record:           rule.Record,
+	}
+}
+
+func getAlertRuleVersion(sess *sqlstore.DBSession, orgID int64, ruleUID string, version int64) (*ngmodels.AlertRuleVersion, error) {
+	ruleVersion := ngmodels.AlertRuleVersion{}
+	has, err := sess.Where("rule_org_id = ? AND rule_uid = ? AND version = ?", orgID, ruleUID, version).Get(&ruleVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ngmodels.ErrAlertRuleVersionNotFound
+	}
+	return &ruleVersion, nil
+}
+
+// GetAlertRuleVersions is a handler for retrieving the versions of an alert rule, most recent first.
+func (st DBstore) GetAlertRuleVersions(query *ngmodels.ListAlertRuleVersionsQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		ruleVersions := make([]*ngmodels.AlertRuleVersion, 0)
+		q := sess.Where("rule_org_id = ? AND rule_uid = ?", query.OrgID, query.RuleUID).Desc("version")
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit, query.Start)
+		}
+		if err := q.Find(&ruleVersions); err != nil {
+			return err
+		}
+
+		query.Result = ruleVersions
+		return nil
+	})
+}
+
+// GetAlertRuleVersion is a handler for retrieving a version of an alert rule.
+// It returns ngmodels.ErrAlertRuleVersionNotFound if no such version is found.
+func (st DBstore) GetAlertRuleVersion(query *ngmodels.GetAlertRuleVersionQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		ruleVersion, err := getAlertRuleVersion(sess, query.OrgID, query.RuleUID, query.Version)
+		if err != nil {
+			return err
+		}
+		query.Result = ruleVersion
+		return nil
+	})
+}
+
+// RestoreAlertRuleVersion is a handler for restoring an alert rule to one of its versions.
+// The restore is saved as a new version of the rule that records the version it was restored from.
+// The rule stays in its current namespace and rule group and keeps the interval of the group.
+// It returns ngmodels.ErrAlertRuleConflict if another rule of the organisation has the restored title.
+func (st DBstore) RestoreAlertRuleVersion(cmd *ngmodels.RestoreAlertRuleVersionCommand) error {
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		existing, err := getAlertRuleByUID(sess, cmd.RuleUID, cmd.OrgID)
+		if err != nil {
+			return err
+		}
+
+		ruleVersion, err := getAlertRuleVersion(sess, cmd.OrgID, cmd.RuleUID, cmd.Version)
+		if err != nil {
+			return err
+		}
+
+		restored := *existing
+		restored.Title = ruleVersion.Title
+		restored.Condition = ruleVersion.Condition
+		restored.Data = ruleVersion.Data
+		restored.NoDataState = ruleVersion.NoDataState
+		restored.ExecErrState = ruleVersion.ExecErrState
+		restored.For = ruleVersion.For
+		restored.KeepFiringFor = ruleVersion.KeepFiringFor
+		restored.Annotations = ruleVersion.Annotations
+		restored.Labels = ruleVersion.Labels
+		restored.Record = ruleVersion.Record
+		restored.Version = existing.Version + 1
+
+		if err := st.validateAlertRule(restored); err != nil {
+			return err
+		}
+
+		if err := (&restored).PreSave(TimeNow); err != nil {
+			return err
+		}
+
+		// all columns are updated so that empty annotations and labels are restored too
+		if _, err := sess.ID(existing.ID).AllCols().Update(&restored); err != nil {
+			if st.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+				return fmt.Errorf("failed to restore rule %s: %w", restored.UID, ngmodels.ErrAlertRuleConflict)
+			}
+			return fmt.Errorf("failed to restore rule %s: %w", restored.UID, err)
+		}
+
+		newVersion := newAlertRuleVersion(restored, existing.Version)
+		newVersion.RestoredFrom = ruleVersion.Version
+		if _, err := sess.Insert(&newVersion); err != nil {
+			return fmt.Errorf("failed to create new rule version: %w", err)
+		}
+
+		cmd.Result = &restored
+		return nil
+	})
+}
+
 // GetOrgAlertRules is a handler for retrieving alert rules of specific organisation.
 func (st DBstore) GetOrgAlertRules(query *ngmodels.ListAlertRulesQuery) error {
 	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
@@ -404,7 +560,7 @@ func (st DBstore) GetNamespaceByUID(UID string, orgID int64, user *models.Signed
 func (st DBstore) GetAlertRulesForScheduling(query *ngmodels.ListAlertRulesQuery) error {
 	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
 		alerts := make([]*ngmodels.AlertRule, 0)
-		q := "SELECT uid, org_id, interval_seconds, version FROM alert_rule"
+		q := "SELECT uid, org_id, interval_seconds, version, is_paused FROM alert_rule"
 		if err := sess.SQL(q).Find(&alerts); err != nil {
 			return err
 		}
@@ -462,6 +618,10 @@ func (st DBstore) validateAlertRule(alertRule ngmodels.AlertRule) error {
 		return fmt.Errorf("%w: no organisation is found", ngmodels.ErrAlertRuleFailedValidation)
 	}
 
+	if alertRule.IsRecordingRule() && !model.IsValidMetricName(model.LabelValue(alertRule.Record)) {
+		return fmt.Errorf("%w: %q is not a valid metric name", ngmodels.ErrAlertRuleFailedValidation, alertRule.Record)
+	}
+
 	return nil
 }
 
@@ -501,10 +661,12 @@ func (st DBstore) UpdateRuleGroup(cmd UpdateRuleGroupCmd) error {
 				RuleGroup:       ruleGroup,
 				NoDataState:     ngmodels.NoDataState(r.GrafanaManagedAlert.NoDataState),
 				ExecErrState:    ngmodels.ExecutionErrorState(r.GrafanaManagedAlert.ExecErrState),
+				Record:          r.GrafanaManagedAlert.Record,
 			}
 
 			if r.ApiRuleNode != nil {
 				new.For = time.Duration(r.ApiRuleNode.For)
+				new.KeepFiringFor = time.Duration(r.ApiRuleNode.KeepFiringFor)
 				new.Annotations = r.ApiRuleNode.Annotations
 				new.Labels = r.ApiRuleNode.Labels
 			}
@@ -513,7 +675,15 @@ func (st DBstore) UpdateRuleGroup(cmd UpdateRuleGroupCmd) error {
 				New: new,
 			}
 
-			if existingGroupRule, ok := existingGroupRulesUIDs[r.GrafanaManagedAlert.UID]; ok {
+			existingGroupRule, ok := existingGroupRulesUIDs[r.GrafanaManagedAlert.UID]
+			// rules are paused or resumed only if requested explicitly
+			if r.GrafanaManagedAlert.IsPaused != nil {
+				upsertRule.New.IsPaused = *r.GrafanaManagedAlert.IsPaused
+			} else if ok {
+				upsertRule.New.IsPaused = existingGroupRule.IsPaused
+			}
+
+			if ok {
 				upsertRule.Existing = &existingGroupRule
 				// remove the rule from existingGroupRulesUIDs
 				delete(existingGroupRulesUIDs, r.GrafanaManagedAlert.UID)
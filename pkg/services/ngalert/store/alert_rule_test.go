@@ -0,0 +1,126 @@
+// +build integration
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRuleVersions(t *testing.T) {
+	mockTimeNow()
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	rule := tests.CreateTestAlertRule(t, dbstore, 60)
+	rule = tests.UpdateTestAlertRuleIntervalSeconds(t, dbstore, rule, 120)
+	require.Equal(t, int64(2), rule.Version)
+
+	t.Run("can list rule versions, most recent first", func(t *testing.T) {
+		q := models.ListAlertRuleVersionsQuery{OrgID: rule.OrgID, RuleUID: rule.UID}
+		require.NoError(t, dbstore.GetAlertRuleVersions(&q))
+		require.Len(t, q.Result, 2)
+		require.Equal(t, int64(2), q.Result[0].Version)
+		require.Equal(t, int64(1), q.Result[0].ParentVersion)
+		require.Equal(t, int64(1), q.Result[1].Version)
+		require.Equal(t, int64(0), q.Result[1].ParentVersion)
+
+		q = models.ListAlertRuleVersionsQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Limit: 1, Start: 1}
+		require.NoError(t, dbstore.GetAlertRuleVersions(&q))
+		require.Len(t, q.Result, 1)
+		require.Equal(t, int64(1), q.Result[0].Version)
+	})
+
+	t.Run("can get a rule version", func(t *testing.T) {
+		q := models.GetAlertRuleVersionQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Version: 1}
+		require.NoError(t, dbstore.GetAlertRuleVersion(&q))
+		require.Equal(t, rule.Title, q.Result.Title)
+		require.Equal(t, int64(60), q.Result.IntervalSeconds)
+
+		q = models.GetAlertRuleVersionQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Version: 10}
+		require.ErrorIs(t, dbstore.GetAlertRuleVersion(&q), models.ErrAlertRuleVersionNotFound)
+	})
+
+	t.Run("can restore a rule version", func(t *testing.T) {
+		cmd := models.RestoreAlertRuleVersionCommand{OrgID: rule.OrgID, RuleUID: rule.UID, Version: 1}
+		require.NoError(t, dbstore.RestoreAlertRuleVersion(&cmd))
+		require.Equal(t, int64(3), cmd.Result.Version)
+		// the interval of the rule group is kept
+		require.Equal(t, int64(120), cmd.Result.IntervalSeconds)
+
+		q := models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID}
+		require.NoError(t, dbstore.GetAlertRuleByUID(&q))
+		require.Equal(t, int64(3), q.Result.Version)
+
+		vq := models.GetAlertRuleVersionQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Version: 3}
+		require.NoError(t, dbstore.GetAlertRuleVersion(&vq))
+		require.Equal(t, int64(2), vq.Result.ParentVersion)
+		require.Equal(t, int64(1), vq.Result.RestoredFrom)
+	})
+
+	t.Run("restoring an unknown version fails", func(t *testing.T) {
+		cmd := models.RestoreAlertRuleVersionCommand{OrgID: rule.OrgID, RuleUID: rule.UID, Version: 10}
+		require.ErrorIs(t, dbstore.RestoreAlertRuleVersion(&cmd), models.ErrAlertRuleVersionNotFound)
+	})
+
+	t.Run("restoring a title used by another rule fails", func(t *testing.T) {
+		q := models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID}
+		require.NoError(t, dbstore.GetAlertRuleByUID(&q))
+		renamed := *q.Result
+		renamed.Title = "a renamed alert rule"
+		require.NoError(t, dbstore.UpsertAlertRules([]store.UpsertRule{{Existing: q.Result, New: renamed}}))
+
+		other := tests.CreateTestAlertRule(t, dbstore, 60)
+		r := *other
+		r.Title = rule.Title
+		require.NoError(t, dbstore.UpsertAlertRules([]store.UpsertRule{{Existing: other, New: r}}))
+
+		cmd := models.RestoreAlertRuleVersionCommand{OrgID: rule.OrgID, RuleUID: rule.UID, Version: 1}
+		require.ErrorIs(t, dbstore.RestoreAlertRuleVersion(&cmd), models.ErrAlertRuleConflict)
+	})
+}
+
+func TestRecordingRules(t *testing.T) {
+	mockTimeNow()
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	rule := tests.CreateTestAlertRule(t, dbstore, 60)
+	require.False(t, rule.IsRecordingRule())
+
+	t.Run("can turn an alerting rule into a recording rule and back", func(t *testing.T) {
+		r := *rule
+		r.Record = "job:requests:rate5m"
+		require.NoError(t, dbstore.UpsertAlertRules([]store.UpsertRule{{Existing: rule, New: r}}))
+
+		q := models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID}
+		require.NoError(t, dbstore.GetAlertRuleByUID(&q))
+		require.True(t, q.Result.IsRecordingRule())
+		require.Equal(t, "job:requests:rate5m", q.Result.Record)
+
+		vq := models.GetAlertRuleVersionQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Version: q.Result.Version}
+		require.NoError(t, dbstore.GetAlertRuleVersion(&vq))
+		require.Equal(t, "job:requests:rate5m", vq.Result.Record)
+
+		recording := q.Result
+		r = *recording
+		r.Record = ""
+		require.NoError(t, dbstore.UpsertAlertRules([]store.UpsertRule{{Existing: recording, New: r}}))
+
+		require.NoError(t, dbstore.GetAlertRuleByUID(&q))
+		require.False(t, q.Result.IsRecordingRule())
+	})
+
+	t.Run("rejects invalid metric names", func(t *testing.T) {
+		r := *rule
+		r.Record = "requests-per-second"
+		err := dbstore.UpsertAlertRules([]store.UpsertRule{{Existing: rule, New: r}})
+		require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+	})
+}
@@ -11,6 +11,17 @@ import (
 // TimeNow makes it possible to test usage of time
 var TimeNow = time.Now
 
+const (
+	// BaseIntervalSeconds is the scheduler interval
+	// changing this value is discouraged
+	// because this could cause existing alert definition
+	// with intervals that are not exactly divided by this number
+	// not to be evaluated
+	BaseIntervalSeconds = 10
+	// DefaultIntervalSeconds is the default alert definition interval
+	DefaultIntervalSeconds int64 = 6 * BaseIntervalSeconds
+)
+
 // AlertDefinitionMaxTitleLength is the maximum length of the alert definition title
 const AlertDefinitionMaxTitleLength = 190
 
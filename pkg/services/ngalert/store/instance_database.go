@@ -99,18 +99,36 @@ func (st DBstore) SaveAlertInstance(cmd *models.SaveAlertInstanceCommand) error
 			CurrentStateSince: cmd.CurrentStateSince,
 			CurrentStateEnd:   cmd.CurrentStateEnd,
 			LastEvalTime:      cmd.LastEvalTime,
+			CurrentValues:     cmd.Values,
+			KeptFiringSince:   cmd.KeptFiringSince,
 		}
 
 		if err := models.ValidateAlertInstance(alertInstance); err != nil {
 			return err
 		}
 
-		params := append(make([]interface{}, 0), alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash, alertInstance.CurrentState, alertInstance.CurrentStateSince.Unix(), alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix())
+		// the values are NULL rather than a JSON null when there are none
+		var valuesJSON interface{}
+		if len(alertInstance.CurrentValues) > 0 {
+			b, err := alertInstance.CurrentValues.ToDB()
+			if err != nil {
+				return err
+			}
+			valuesJSON = string(b)
+		}
+
+		// the time is NULL when the alert is not kept firing
+		var keptFiringSince interface{}
+		if !alertInstance.KeptFiringSince.IsZero() {
+			keptFiringSince = alertInstance.KeptFiringSince.Unix()
+		}
+
+		params := append(make([]interface{}, 0), alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash, alertInstance.CurrentState, alertInstance.CurrentStateSince.Unix(), alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix(), valuesJSON, keptFiringSince)
 
 		upsertSQL := st.SQLStore.Dialect.UpsertSQL(
 			"alert_instance",
 			[]string{"rule_org_id", "rule_uid", "labels_hash"},
-			[]string{"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state", "current_state_since", "current_state_end", "last_eval_time"})
+			[]string{"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state", "current_state_since", "current_state_end", "last_eval_time", "current_values", "kept_firing_since"})
 		_, err = sess.SQL(upsertSQL, params...).Query()
 		if err != nil {
 			return err
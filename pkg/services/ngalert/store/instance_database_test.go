@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
@@ -177,4 +178,84 @@ func TestAlertInstanceOperations(t *testing.T) {
 		require.Equal(t, saveCmdTwo.Labels, listQuery.Result[0].Labels)
 		require.Equal(t, saveCmdTwo.State, listQuery.Result[0].CurrentState)
 	})
+
+	t.Run("can save and read the values of an alert instance", func(t *testing.T) {
+		value := 93.5
+		saveCmd := &models.SaveAlertInstanceCommand{
+			RuleOrgID: alertRule1.OrgID,
+			RuleUID:   alertRule1.UID,
+			State:     models.InstanceStateFiring,
+			Labels:    models.InstanceLabels{"test": "withValues"},
+			Values: models.InstanceValues{
+				"B": {Labels: data.Labels{"test": "withValues"}, Value: &value},
+				"C": {Value: nil},
+			},
+		}
+		err := dbstore.SaveAlertInstance(saveCmd)
+		require.NoError(t, err)
+
+		getCmd := &models.GetAlertInstanceQuery{
+			RuleOrgID: saveCmd.RuleOrgID,
+			RuleUID:   saveCmd.RuleUID,
+			Labels:    saveCmd.Labels,
+		}
+		err = dbstore.GetAlertInstance(getCmd)
+		require.NoError(t, err)
+		require.Equal(t, saveCmd.Values, getCmd.Result.CurrentValues)
+
+		listQuery := &models.ListAlertInstancesQuery{
+			RuleOrgID: saveCmd.RuleOrgID,
+			RuleUID:   saveCmd.RuleUID,
+		}
+		err = dbstore.ListAlertInstances(listQuery)
+		require.NoError(t, err)
+		for _, instance := range listQuery.Result {
+			if instance.Labels["test"] == "withValues" {
+				require.Equal(t, saveCmd.Values, instance.CurrentValues)
+			} else {
+				require.Nil(t, instance.CurrentValues)
+			}
+		}
+	})
+
+	t.Run("can save and read when an alert instance is kept firing", func(t *testing.T) {
+		keptFiringSince := time.Unix(1000, 0)
+		saveCmd := &models.SaveAlertInstanceCommand{
+			RuleOrgID:       alertRule1.OrgID,
+			RuleUID:         alertRule1.UID,
+			State:           models.InstanceStateFiring,
+			Labels:          models.InstanceLabels{"test": "keptFiring"},
+			KeptFiringSince: keptFiringSince,
+		}
+		err := dbstore.SaveAlertInstance(saveCmd)
+		require.NoError(t, err)
+
+		listQuery := &models.ListAlertInstancesQuery{
+			RuleOrgID: saveCmd.RuleOrgID,
+			RuleUID:   saveCmd.RuleUID,
+		}
+		err = dbstore.ListAlertInstances(listQuery)
+		require.NoError(t, err)
+		for _, instance := range listQuery.Result {
+			if instance.Labels["test"] == "keptFiring" {
+				require.True(t, keptFiringSince.Equal(instance.KeptFiringSince))
+			} else {
+				require.True(t, instance.KeptFiringSince.IsZero())
+			}
+		}
+
+		// the time is cleared once the alert is no longer kept firing
+		saveCmd.KeptFiringSince = time.Time{}
+		err = dbstore.SaveAlertInstance(saveCmd)
+		require.NoError(t, err)
+
+		getCmd := &models.GetAlertInstanceQuery{
+			RuleOrgID: saveCmd.RuleOrgID,
+			RuleUID:   saveCmd.RuleUID,
+			Labels:    saveCmd.Labels,
+		}
+		err = dbstore.GetAlertInstance(getCmd)
+		require.NoError(t, err)
+		require.True(t, getCmd.Result.KeptFiringSince.IsZero())
+	})
 }
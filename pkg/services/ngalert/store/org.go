@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// OrgStore is the database interface used for listing the organizations known to Grafana.
+type OrgStore interface {
+	GetOrgs() ([]int64, error)
+}
+
+// GetOrgs returns the IDs of all the organizations.
+func (st DBstore) GetOrgs() ([]int64, error) {
+	orgs := make([]int64, 0)
+	err := st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		q := "SELECT id FROM org"
+		if err := sess.SQL(q).Find(&orgs); err != nil {
+			return err
+		}
+		return nil
+	})
+	return orgs, err
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// StateHistoryStore is the database interface used for recording and querying the state transitions of alert instances.
+type StateHistoryStore interface {
+	SaveAlertStateHistory(cmd *models.SaveAlertStateHistoryCommand) error
+	ListAlertStateHistory(query *models.ListAlertStateHistoryQuery) error
+	DeleteAlertStateHistory(cmd *models.DeleteAlertStateHistoryCommand) error
+}
+
+// SaveAlertStateHistory is a handler for recording state transitions of alert instances.
+func (st DBstore) SaveAlertStateHistory(cmd *models.SaveAlertStateHistoryCommand) error {
+	if len(cmd.Transitions) == 0 {
+		return nil
+	}
+
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		for _, t := range cmd.Transitions {
+			labelTupleJSON, labelsHash, err := t.Labels.StringAndHash()
+			if err != nil {
+				return err
+			}
+
+			insertSQL := `INSERT INTO alert_state_history
+				(rule_org_id, rule_uid, labels, labels_hash, previous_state, state, evaluation_string, error, evaluated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			if _, err := sess.Exec(insertSQL, t.RuleOrgID, t.RuleUID, labelTupleJSON, labelsHash, t.PreviousState, t.State, t.EvaluationString, t.Error, t.EvaluatedAt.Unix()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListAlertStateHistory is a handler for retrieving the state transitions of alert instances
+// within specific organisation based on various filters.
+func (st DBstore) ListAlertStateHistory(query *models.ListAlertStateHistoryQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		transitions := make([]*models.AlertStateHistory, 0)
+
+		s := strings.Builder{}
+		params := make([]interface{}, 0)
+
+		addToQuery := func(stmt string, p ...interface{}) {
+			s.WriteString(stmt)
+			params = append(params, p...)
+		}
+
+		addToQuery("SELECT * FROM alert_state_history WHERE rule_org_id = ?", query.RuleOrgID)
+
+		if query.RuleUID != "" {
+			addToQuery(" AND rule_uid = ?", query.RuleUID)
+		}
+
+		if len(query.Labels) > 0 {
+			_, hash, err := query.Labels.StringAndHash()
+			if err != nil {
+				return err
+			}
+			addToQuery(" AND labels_hash = ?", hash)
+		}
+
+		if !query.From.IsZero() {
+			addToQuery(" AND evaluated_at >= ?", query.From.Unix())
+		}
+
+		if !query.To.IsZero() {
+			addToQuery(" AND evaluated_at <= ?", query.To.Unix())
+		}
+
+		addToQuery(" ORDER BY evaluated_at DESC, id DESC")
+
+		if query.Limit > 0 {
+			addToQuery(st.SQLStore.Dialect.Limit(query.Limit))
+		}
+
+		if err := sess.SQL(s.String(), params...).Find(&transitions); err != nil {
+			return err
+		}
+
+		query.Result = transitions
+		return nil
+	})
+}
+
+// DeleteAlertStateHistory is a handler for deleting the state transitions recorded before a given time.
+func (st DBstore) DeleteAlertStateHistory(cmd *models.DeleteAlertStateHistoryCommand) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE evaluated_at < ?", cmd.OlderThan.Unix())
+		if err != nil {
+			return err
+		}
+
+		cmd.DeletedRows, err = res.RowsAffected()
+		return err
+	})
+}
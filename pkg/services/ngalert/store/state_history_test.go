@@ -0,0 +1,80 @@
+// +build integration
+
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertStateHistory(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	start := time.Unix(1000, 0)
+	transition := func(ruleUID string, labels models.InstanceLabels, previous, state models.InstanceStateType, evaluatedAt time.Time) models.AlertStateHistory {
+		return models.AlertStateHistory{
+			RuleOrgID:     1,
+			RuleUID:       ruleUID,
+			Labels:        labels,
+			PreviousState: previous,
+			State:         state,
+			EvaluatedAt:   evaluatedAt,
+		}
+	}
+
+	err := dbstore.SaveAlertStateHistory(&models.SaveAlertStateHistoryCommand{Transitions: []models.AlertStateHistory{
+		transition("rule-a", models.InstanceLabels{"host": "a"}, models.InstanceStateNormal, models.InstanceStateFiring, start),
+		transition("rule-a", models.InstanceLabels{"host": "b"}, models.InstanceStateNormal, models.InstanceStateFiring, start.Add(time.Minute)),
+		transition("rule-a", models.InstanceLabels{"host": "a"}, models.InstanceStateFiring, models.InstanceStateNormal, start.Add(2*time.Minute)),
+		transition("rule-b", models.InstanceLabels{"host": "a"}, models.InstanceStateNormal, models.InstanceStatePending, start.Add(3*time.Minute)),
+	}})
+	require.NoError(t, err)
+	err = dbstore.SaveAlertStateHistory(&models.SaveAlertStateHistoryCommand{Transitions: []models.AlertStateHistory{{
+		RuleOrgID: 2, RuleUID: "rule-a", Labels: models.InstanceLabels{"host": "a"},
+		PreviousState: models.InstanceStateNormal, State: models.InstanceStateFiring, EvaluatedAt: start,
+	}}})
+	require.NoError(t, err)
+
+	list := func(q models.ListAlertStateHistoryQuery) []*models.AlertStateHistory {
+		t.Helper()
+		require.NoError(t, dbstore.ListAlertStateHistory(&q))
+		return q.Result
+	}
+
+	t.Run("transitions of the organization are listed most recent first", func(t *testing.T) {
+		res := list(models.ListAlertStateHistoryQuery{RuleOrgID: 1})
+		require.Len(t, res, 4)
+		require.Equal(t, "rule-b", res[0].RuleUID)
+		require.Equal(t, models.InstanceStatePending, res[0].State)
+		require.Equal(t, start.Add(3*time.Minute).Unix(), res[0].EvaluatedAt.Unix())
+		require.Equal(t, start.Unix(), res[3].EvaluatedAt.Unix())
+	})
+
+	t.Run("transitions can be filtered", func(t *testing.T) {
+		require.Len(t, list(models.ListAlertStateHistoryQuery{RuleOrgID: 1, RuleUID: "rule-a"}), 3)
+
+		res := list(models.ListAlertStateHistoryQuery{RuleOrgID: 1, RuleUID: "rule-a", Labels: models.InstanceLabels{"host": "a"}})
+		require.Len(t, res, 2)
+		require.Equal(t, models.InstanceStateNormal, res[0].State)
+		require.Equal(t, models.InstanceStateFiring, res[1].State)
+
+		require.Len(t, list(models.ListAlertStateHistoryQuery{RuleOrgID: 1, From: start.Add(time.Minute), To: start.Add(2 * time.Minute)}), 2)
+		require.Len(t, list(models.ListAlertStateHistoryQuery{RuleOrgID: 1, Limit: 1}), 1)
+	})
+
+	t.Run("old transitions are deleted", func(t *testing.T) {
+		cmd := models.DeleteAlertStateHistoryCommand{OlderThan: start.Add(90 * time.Second)}
+		require.NoError(t, dbstore.DeleteAlertStateHistory(&cmd))
+		require.Equal(t, int64(3), cmd.DeletedRows)
+
+		require.Len(t, list(models.ListAlertStateHistoryQuery{RuleOrgID: 1}), 2)
+		require.Empty(t, list(models.ListAlertStateHistoryQuery{RuleOrgID: 2}))
+	})
+}
@@ -158,3 +158,37 @@ func UpdateTestAlertRuleIntervalSeconds(t *testing.T, dbstore *store.DBstore, ex
 	t.Logf("alert definition: %v with interval: %d created", rule.GetKey(), rule.IntervalSeconds)
 	return rule
 }
+
+// UpdateTestAlertRulePaused pauses or resumes a dummy alert definition to be used by the tests.
+func UpdateTestAlertRulePaused(t *testing.T, dbstore *store.DBstore, existingRule *models.AlertRule, isPaused bool) *models.AlertRule {
+	cmd := store.UpdateRuleGroupCmd{
+		OrgID:        1,
+		NamespaceUID: "namespace",
+		RuleGroupConfig: apimodels.PostableRuleGroupConfig{
+			Name:     existingRule.RuleGroup,
+			Interval: model.Duration(time.Duration(existingRule.IntervalSeconds) * time.Second),
+			Rules: []apimodels.PostableExtendedRuleNode{
+				{
+					GrafanaManagedAlert: &apimodels.PostableGrafanaRule{
+						UID:      existingRule.UID,
+						IsPaused: &isPaused,
+					},
+				},
+			},
+		},
+	}
+
+	err := dbstore.UpdateRuleGroup(cmd)
+	require.NoError(t, err)
+
+	q := models.GetAlertRuleByUIDQuery{
+		OrgID: 1,
+		UID:   existingRule.UID,
+	}
+	err = dbstore.GetAlertRuleByUID(&q)
+	require.NoError(t, err)
+	require.Equal(t, isPaused, q.Result.IsPaused)
+
+	t.Logf("alert definition: %v paused: %t", q.Result.GetKey(), q.Result.IsPaused)
+	return q.Result
+}
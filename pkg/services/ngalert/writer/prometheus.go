@@ -0,0 +1,153 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	remoteWriteVersion = "0.1.0"
+	// maxErrorBodyLength is the maximum number of bytes of the response body added to the error of a failed write.
+	maxErrorBodyLength = 512
+)
+
+// Writer writes the results of recording rules as time series.
+type Writer interface {
+	// Write writes a sample at time t for every numeric series of the frames.
+	// The series are named name and get the extra labels in addition to their own.
+	Write(ctx context.Context, name string, t time.Time, frames data.Frames, extraLabels map[string]string) error
+}
+
+// PrometheusWriter writes time series to an endpoint implementing the Prometheus remote write protocol.
+type PrometheusWriter struct {
+	url      string
+	user     string
+	password string
+	client   *http.Client
+	log      log.Logger
+}
+
+func NewPrometheusWriter(url, user, password string, timeout time.Duration, logger log.Logger) *PrometheusWriter {
+	return &PrometheusWriter{
+		url:      url,
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: timeout},
+		log:      logger,
+	}
+}
+
+func (w *PrometheusWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, extraLabels map[string]string) error {
+	series, err := framesToTimeSeries(name, t, frames, extraLabels)
+	if err != nil {
+		return err
+	}
+	if len(series) == 0 {
+		w.log.Debug("no series to write", "name", name)
+		return nil
+	}
+
+	writeReq := &prompb.WriteRequest{Timeseries: series}
+	b, err := writeReq.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal write request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(snappy.Encode(nil, b)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	if w.user != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write series %s: %w", name, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			w.log.Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return fmt.Errorf("failed to write series %s: unexpected status code %d: %s", name, resp.StatusCode, string(body))
+	}
+
+	w.log.Debug("series written", "name", name, "count", len(series))
+	return nil
+}
+
+// framesToTimeSeries converts every numeric field of the frames to a time series with a single sample at time t.
+// The value of the sample is the last non-null value of the field, fields without any value are skipped.
+// The extra labels take precedence over the labels of the fields.
+func framesToTimeSeries(name string, t time.Time, frames data.Frames, extraLabels map[string]string) ([]prompb.TimeSeries, error) {
+	timestamp := t.UnixNano() / int64(time.Millisecond)
+	series := make([]prompb.TimeSeries, 0, len(frames))
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+
+			var value *float64
+			for i := field.Len() - 1; i >= 0; i-- {
+				v, err := field.NullableFloatAt(i)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read value of field %s: %w", field.Name, err)
+				}
+				if v != nil {
+					value = v
+					break
+				}
+			}
+			if value == nil {
+				continue
+			}
+
+			series = append(series, prompb.TimeSeries{
+				Labels:  toPrometheusLabels(name, field.Labels, extraLabels),
+				Samples: []prompb.Sample{{Value: *value, Timestamp: timestamp}},
+			})
+		}
+	}
+	return series, nil
+}
+
+// toPrometheusLabels merges the labels sorted by name, as required by the remote write protocol.
+func toPrometheusLabels(name string, labels data.Labels, extraLabels map[string]string) []prompb.Label {
+	merged := make(map[string]string, len(labels)+len(extraLabels)+1)
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range extraLabels {
+		merged[k] = v
+	}
+	merged["__name__"] = name
+
+	result := make([]prompb.Label, 0, len(merged))
+	for k, v := range merged {
+		result = append(result, prompb.Label{Name: k, Value: v})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,117 @@
+package writer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// remoteWriteReceiver is a stand-in for a Prometheus remote write endpoint that keeps the received requests.
+type remoteWriteReceiver struct {
+	t        *testing.T
+	status   int
+	requests []*prompb.WriteRequest
+	headers  []http.Header
+}
+
+func (rw *remoteWriteReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	compressed, err := ioutil.ReadAll(r.Body)
+	require.NoError(rw.t, err)
+	b, err := snappy.Decode(nil, compressed)
+	require.NoError(rw.t, err)
+	req := &prompb.WriteRequest{}
+	require.NoError(rw.t, req.Unmarshal(b))
+
+	rw.requests = append(rw.requests, req)
+	rw.headers = append(rw.headers, r.Header.Clone())
+	w.WriteHeader(rw.status)
+}
+
+func TestPrometheusWriter(t *testing.T) {
+	now := time.Unix(1620000000, 0)
+	ms := now.UnixNano() / int64(time.Millisecond)
+
+	numberFrame := data.NewFrame("",
+		data.NewField("", data.Labels{"instance": "a", "team": "x"}, []*float64{float64Ptr(2)}),
+	)
+	seriesFrame := data.NewFrame("",
+		data.NewField("time", nil, []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute), now}),
+		data.NewField("", data.Labels{"instance": "b"}, []*float64{float64Ptr(3), float64Ptr(4), nil}),
+	)
+	emptyFrame := data.NewFrame("",
+		data.NewField("", data.Labels{"instance": "c"}, []*float64{nil}),
+	)
+
+	t.Run("writes the last value of every series", func(t *testing.T) {
+		receiver := &remoteWriteReceiver{t: t, status: http.StatusNoContent}
+		srv := httptest.NewServer(receiver)
+		t.Cleanup(srv.Close)
+
+		w := NewPrometheusWriter(srv.URL, "user", "password", time.Second, log.New("test"))
+		err := w.Write(context.Background(), "job:requests:rate5m", now, data.Frames{numberFrame, seriesFrame, emptyFrame}, map[string]string{"team": "y"})
+		require.NoError(t, err)
+
+		require.Len(t, receiver.requests, 1)
+		require.Equal(t, []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "job:requests:rate5m"},
+					{Name: "instance", Value: "a"},
+					{Name: "team", Value: "y"},
+				},
+				Samples: []prompb.Sample{{Value: 2, Timestamp: ms}},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "job:requests:rate5m"},
+					{Name: "instance", Value: "b"},
+					{Name: "team", Value: "y"},
+				},
+				Samples: []prompb.Sample{{Value: 4, Timestamp: ms}},
+			},
+		}, receiver.requests[0].Timeseries)
+
+		headers := receiver.headers[0]
+		require.Equal(t, "snappy", headers.Get("Content-Encoding"))
+		require.Equal(t, "application/x-protobuf", headers.Get("Content-Type"))
+		require.Equal(t, "0.1.0", headers.Get("X-Prometheus-Remote-Write-Version"))
+		require.Equal(t, "Basic dXNlcjpwYXNzd29yZA==", headers.Get("Authorization"))
+	})
+
+	t.Run("skips the request without series", func(t *testing.T) {
+		receiver := &remoteWriteReceiver{t: t, status: http.StatusNoContent}
+		srv := httptest.NewServer(receiver)
+		t.Cleanup(srv.Close)
+
+		w := NewPrometheusWriter(srv.URL, "", "", time.Second, log.New("test"))
+		require.NoError(t, w.Write(context.Background(), "empty", now, data.Frames{emptyFrame}, nil))
+		require.Empty(t, receiver.requests)
+	})
+
+	t.Run("returns an error when the write is rejected", func(t *testing.T) {
+		receiver := &remoteWriteReceiver{t: t, status: http.StatusBadRequest}
+		srv := httptest.NewServer(receiver)
+		t.Cleanup(srv.Close)
+
+		w := NewPrometheusWriter(srv.URL, "", "", time.Second, log.New("test"))
+		err := w.Write(context.Background(), "rejected", now, data.Frames{numberFrame}, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "400")
+		require.Len(t, receiver.requests, 1)
+		require.Empty(t, receiver.headers[0].Get("Authorization"))
+	})
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
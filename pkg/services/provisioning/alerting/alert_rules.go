@@ -0,0 +1,204 @@
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// RuleStore is the part of the alert rule store used to provision the alert rules.
+type RuleStore interface {
+	GetAlertRuleByUID(*ngmodels.GetAlertRuleByUIDQuery) error
+	GetRuleGroupAlertRules(*ngmodels.ListRuleGroupAlertRulesQuery) error
+	InsertAlertRules([]ngmodels.AlertRule) error
+	UpsertAlertRules([]store.UpsertRule) error
+	DeleteAlertRuleByUID(orgID int64, ruleUID string) error
+}
+
+// Provision alert rules
+func Provision(configDirectory string, ruleStore RuleStore) error {
+	rp := newRuleProvisioner(log.New("provisioning.alerting"), ruleStore)
+	return rp.applyChanges(configDirectory)
+}
+
+// RuleProvisioner is responsible for provisioning alert rules
+type RuleProvisioner struct {
+	log         log.Logger
+	cfgProvider *configReader
+	ruleStore   RuleStore
+}
+
+func newRuleProvisioner(log log.Logger, ruleStore RuleStore) RuleProvisioner {
+	return RuleProvisioner{
+		log:         log,
+		cfgProvider: &configReader{log: log},
+		ruleStore:   ruleStore,
+	}
+}
+
+func (rp *RuleProvisioner) apply(cfg *rulesAsConfig) error {
+	for _, rule := range cfg.DeleteRules {
+		rp.log.Info("Deleting alert rule", "uid", rule.UID, "orgId", rule.OrgID)
+		if err := rp.ruleStore.DeleteAlertRuleByUID(rule.OrgID, rule.UID); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range cfg.Groups {
+		if err := rp.applyGroup(group); err != nil {
+			return fmt.Errorf("failed to provision rule group %q: %w", group.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyGroup creates the missing rules of the group with the UIDs of the configuration, updates
+// the rules that changed and deletes the rules of the group missing from the configuration.
+// The unchanged rules are left alone, so that their version only changes with the configuration.
+func (rp *RuleProvisioner) applyGroup(group *ruleGroupFromConfig) error {
+	rp.log.Debug("Provisioning rule group from configuration", "name", group.Name, "folderUid", group.FolderUID, "orgId", group.OrgID)
+	q := &ngmodels.ListRuleGroupAlertRulesQuery{
+		OrgID:        group.OrgID,
+		NamespaceUID: group.FolderUID,
+		RuleGroup:    group.Name,
+	}
+	if err := rp.ruleStore.GetRuleGroupAlertRules(q); err != nil {
+		return err
+	}
+	existingRules := make(map[string]*ngmodels.AlertRule, len(q.Result))
+	for _, r := range q.Result {
+		existingRules[r.UID] = r
+	}
+
+	var newRules []ngmodels.AlertRule
+	var updatedRules []store.UpsertRule
+	for _, rule := range group.alertRules() {
+		existing, ok := existingRules[rule.UID]
+		if !ok {
+			// the UIDs are unique in the organization, the rules don't move between groups
+			query := &ngmodels.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID}
+			err := rp.ruleStore.GetAlertRuleByUID(query)
+			if err == nil {
+				return fmt.Errorf("alert rule %q already belongs to rule group %q of folder %q", rule.UID, query.Result.RuleGroup, query.Result.NamespaceUID)
+			}
+			if !errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+				return err
+			}
+			newRules = append(newRules, rule)
+			continue
+		}
+		delete(existingRules, rule.UID)
+
+		changed, err := ruleChanged(existing, rule)
+		if err != nil {
+			return fmt.Errorf("invalid alert rule %q: %w", rule.UID, err)
+		}
+		if changed {
+			updatedRules = append(updatedRules, store.UpsertRule{Existing: existing, New: rule})
+		}
+	}
+
+	if len(newRules) > 0 {
+		rp.log.Info("Creating alert rules", "group", group.Name, "count", len(newRules))
+		if err := rp.ruleStore.InsertAlertRules(newRules); err != nil {
+			return err
+		}
+	}
+	if len(updatedRules) > 0 {
+		rp.log.Info("Updating alert rules", "group", group.Name, "count", len(updatedRules))
+		if err := rp.ruleStore.UpsertAlertRules(updatedRules); err != nil {
+			return err
+		}
+	}
+
+	// the rules of the group missing from the configuration are deleted
+	for uid := range existingRules {
+		rp.log.Info("Deleting alert rule missing from its group", "uid", uid, "group", group.Name)
+		if err := rp.ruleStore.DeleteAlertRuleByUID(group.OrgID, uid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ruleChanged returns whether updating the existing rule with the provisioned one would change it.
+// The properties the update keeps when they are not set, are unchanged when they are not provisioned.
+func ruleChanged(existing *ngmodels.AlertRule, rule ngmodels.AlertRule) (bool, error) {
+	if existing.Title != rule.Title ||
+		existing.Condition != rule.Condition ||
+		existing.NoDataState != rule.NoDataState ||
+		existing.ExecErrState != rule.ExecErrState ||
+		existing.KeepFiringFor != rule.KeepFiringFor ||
+		existing.IsPaused != rule.IsPaused ||
+		existing.Record != rule.Record {
+		return true, nil
+	}
+	if rule.IntervalSeconds != 0 && existing.IntervalSeconds != rule.IntervalSeconds {
+		return true, nil
+	}
+	if rule.For != 0 && existing.For != rule.For {
+		return true, nil
+	}
+	if len(rule.Annotations) > 0 && !reflect.DeepEqual(existing.Annotations, rule.Annotations) {
+		return true, nil
+	}
+	if len(rule.Labels) > 0 && !reflect.DeepEqual(existing.Labels, rule.Labels) {
+		return true, nil
+	}
+	if len(rule.Data) == 0 {
+		return false, nil
+	}
+	return queriesChanged(existing.Data, rule.Data)
+}
+
+// queriesChanged returns whether the provisioned queries differ from the stored ones. The provisioned
+// queries are completed like the stored ones were before they are compared.
+func queriesChanged(existing []ngmodels.AlertQuery, queries []ngmodels.AlertQuery) (bool, error) {
+	if len(existing) != len(queries) {
+		return true, nil
+	}
+	for i := range queries {
+		q := queries[i]
+		if err := q.PreSave(); err != nil {
+			return false, err
+		}
+		e := existing[i]
+		if e.RefID != q.RefID || e.QueryType != q.QueryType || e.DatasourceUID != q.DatasourceUID || e.RelativeTimeRange != q.RelativeTimeRange {
+			return true, nil
+		}
+
+		var existingModel, model interface{}
+		if err := json.Unmarshal(e.Model, &existingModel); err != nil {
+			return true, nil
+		}
+		if err := json.Unmarshal(q.Model, &model); err != nil {
+			return false, err
+		}
+		if !reflect.DeepEqual(existingModel, model) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (rp *RuleProvisioner) applyChanges(configPath string) error {
+	configs, err := rp.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := rp.apply(cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
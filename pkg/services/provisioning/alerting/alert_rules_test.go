@@ -0,0 +1,82 @@
+package alerting_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+	"github.com/grafana/grafana/pkg/services/provisioning/alerting"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	updatedRules      = "./testdata/test-configs/updated-rules"
+)
+
+func TestProvisionRules(t *testing.T) {
+	require.NoError(t, os.Setenv("TEST_VAR", "cpu"))
+	t.Cleanup(func() { _ = os.Unsetenv("TEST_VAR") })
+
+	dbstore := tests.SetupTestEnv(t, store.BaseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	getRule := func(t *testing.T, uid string) *models.AlertRule {
+		t.Helper()
+		q := models.GetAlertRuleByUIDQuery{OrgID: 1, UID: uid}
+		require.NoError(t, dbstore.GetAlertRuleByUID(&q))
+		return q.Result
+	}
+	versions := func(t *testing.T, uid string) int {
+		t.Helper()
+		q := models.ListAlertRuleVersionsQuery{OrgID: 1, RuleUID: uid}
+		require.NoError(t, dbstore.GetAlertRuleVersions(&q))
+		return len(q.Result)
+	}
+
+	t.Run("new rules are created with the UIDs of the configuration", func(t *testing.T) {
+		require.NoError(t, alerting.Provision(correctProperties, dbstore))
+
+		rule := getRule(t, "rule1")
+		require.Equal(t, "High CPU", rule.Title)
+		require.Equal(t, "folder1", rule.NamespaceUID)
+		require.Equal(t, "cpu-group", rule.RuleGroup)
+		require.Equal(t, int64(60), rule.IntervalSeconds)
+		require.Equal(t, int64(1), rule.Version)
+		require.True(t, rule.IsPaused)
+		require.Equal(t, map[string]string{"team": "ops"}, rule.Labels)
+		require.Len(t, rule.Data, 2)
+
+		rule = getRule(t, "rule2")
+		require.Equal(t, "Low disk space", rule.Title)
+		require.False(t, rule.IsPaused)
+		require.Equal(t, models.NoData, rule.NoDataState)
+		require.Equal(t, models.AlertingErrState, rule.ExecErrState)
+	})
+
+	t.Run("unchanged rules are not updated", func(t *testing.T) {
+		require.NoError(t, alerting.Provision(correctProperties, dbstore))
+
+		for _, uid := range []string{"rule1", "rule2"} {
+			require.Equal(t, int64(1), getRule(t, uid).Version)
+			require.Equal(t, 1, versions(t, uid))
+		}
+	})
+
+	t.Run("changed rules are updated and the rules missing from their group are deleted", func(t *testing.T) {
+		require.NoError(t, alerting.Provision(updatedRules, dbstore))
+
+		rule := getRule(t, "rule1")
+		require.Equal(t, "Very high CPU", rule.Title)
+		require.Equal(t, int64(2), rule.Version)
+		require.False(t, rule.IsPaused)
+		require.Equal(t, 2, versions(t, "rule1"))
+
+		q := models.GetAlertRuleByUIDQuery{OrgID: 1, UID: "rule2"}
+		require.ErrorIs(t, dbstore.GetAlertRuleByUID(&q), models.ErrAlertRuleNotFound)
+	})
+}
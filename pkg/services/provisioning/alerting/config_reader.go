@@ -0,0 +1,150 @@
+package alerting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*rulesAsConfig, error) {
+	var rules []*rulesAsConfig
+	cr.log.Debug("Looking for alert rule provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read alert rule provisioning files from directory", "path", path, "error", err)
+		return rules, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing alert rules provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseRulesConfig(path, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %q: %w", file.Name(), err)
+			}
+
+			if cfg != nil {
+				rules = append(rules, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating alert rules")
+	if err := validateRequiredField(rules); err != nil {
+		return nil, err
+	}
+
+	if err := checkOrgID(rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (cr *configReader) parseRulesConfig(path string, file os.FileInfo) (*rulesAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *rulesAsConfigV0
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToRulesFromConfig()
+}
+
+func checkOrgID(rules []*rulesAsConfig) error {
+	for i := range rules {
+		for _, group := range rules[i].Groups {
+			if group.OrgID < 1 {
+				group.OrgID = 1
+			} else if err := utils.CheckOrgExists(group.OrgID); err != nil {
+				return fmt.Errorf("failed to provision %q rule group: %w", group.Name, err)
+			}
+		}
+
+		for _, rule := range rules[i].DeleteRules {
+			if rule.OrgID < 1 {
+				rule.OrgID = 1
+			}
+		}
+	}
+	return nil
+}
+
+func validateRequiredField(rules []*rulesAsConfig) error {
+	for i := range rules {
+		var errStrings []string
+		for index, group := range rules[i].Groups {
+			if group.Name == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added rule group item %d in configuration doesn't contain required field name", index+1),
+				)
+			}
+
+			if group.FolderUID == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added rule group item %d in configuration doesn't contain required field folder_uid", index+1),
+				)
+			}
+
+			for ruleIndex, rule := range group.Rules {
+				if rule.UID == "" {
+					errStrings = append(
+						errStrings,
+						fmt.Sprintf("Added alert rule item %d of rule group item %d in configuration doesn't contain required field uid", ruleIndex+1, index+1),
+					)
+				}
+
+				if rule.Title == "" {
+					errStrings = append(
+						errStrings,
+						fmt.Sprintf("Added alert rule item %d of rule group item %d in configuration doesn't contain required field title", ruleIndex+1, index+1),
+					)
+				}
+
+				if rule.Condition == "" {
+					errStrings = append(
+						errStrings,
+						fmt.Sprintf("Added alert rule item %d of rule group item %d in configuration doesn't contain required field condition", ruleIndex+1, index+1),
+					)
+				}
+			}
+		}
+
+		for index, rule := range rules[i].DeleteRules {
+			if rule.UID == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Deleted alert rule item %d in configuration doesn't contain required field uid", index+1),
+				)
+			}
+		}
+
+		if len(errStrings) != 0 {
+			return fmt.Errorf(strings.Join(errStrings, "\n"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package alerting
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	noRequiredFields  = "./testdata/test-configs/no-required-fields"
+	invalidDuration   = "./testdata/test-configs/invalid-duration"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestRulesAsConfig(t *testing.T) {
+	cfgProvider := &configReader{log: log.New("test logger")}
+
+	t.Run("can read correct properties", func(t *testing.T) {
+		require.NoError(t, os.Setenv("TEST_VAR", "cpu"))
+		t.Cleanup(func() { _ = os.Unsetenv("TEST_VAR") })
+
+		cfg, err := cfgProvider.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		groups := cfg[0].Groups
+		require.Len(t, groups, 1)
+		group := groups[0]
+		require.Equal(t, "cpu-group", group.Name)
+		require.Equal(t, "folder1", group.FolderUID)
+		require.Equal(t, int64(1), group.OrgID)
+		require.Equal(t, time.Minute, group.Interval)
+		require.Len(t, group.Rules, 2)
+
+		rule := group.Rules[0]
+		require.Equal(t, "rule1", rule.UID)
+		require.Equal(t, "High CPU", rule.Title)
+		require.Equal(t, "B", rule.Condition)
+		require.Equal(t, 5*time.Minute, rule.For)
+		require.True(t, rule.IsPaused)
+		require.Equal(t, map[string]string{"team": "ops"}, rule.Labels)
+		require.Equal(t, map[string]string{"summary": "The CPU usage is high"}, rule.Annotations)
+		require.Len(t, rule.Data, 2)
+		require.Equal(t, "A", rule.Data[0].RefID)
+		require.Equal(t, "datasource1", rule.Data[0].DatasourceUID)
+		require.Equal(t, models.Duration(10*time.Minute), rule.Data[0].RelativeTimeRange.From)
+		require.JSONEq(t, `{"expr":"cpu_usage"}`, string(rule.Data[0].Model))
+		require.JSONEq(t, `{"type":"math","expression":"$A > 90"}`, string(rule.Data[1].Model))
+
+		require.False(t, group.Rules[1].IsPaused)
+
+		deleteRules := cfg[0].DeleteRules
+		require.Len(t, deleteRules, 2)
+		require.Equal(t, "rule3", deleteRules[0].UID)
+		require.Equal(t, int64(1), deleteRules[0].OrgID)
+		require.Equal(t, "rule4", deleteRules[1].UID)
+		require.Equal(t, int64(1), deleteRules[1].OrgID)
+	})
+
+	t.Run("missing required fields are rejected", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(noRequiredFields)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Added rule group item 1 in configuration doesn't contain required field name")
+		require.Contains(t, err.Error(), "Added rule group item 1 in configuration doesn't contain required field folder_uid")
+		require.Contains(t, err.Error(), "Added alert rule item 1 of rule group item 1 in configuration doesn't contain required field uid")
+		require.Contains(t, err.Error(), "Added alert rule item 1 of rule group item 1 in configuration doesn't contain required field title")
+		require.Contains(t, err.Error(), "Deleted alert rule item 1 in configuration doesn't contain required field uid")
+	})
+
+	t.Run("invalid durations are rejected", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(invalidDuration)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `invalid interval of rule group "group"`)
+	})
+
+	t.Run("a missing folder is skipped", func(t *testing.T) {
+		cfg, err := cfgProvider.readConfig(emptyFolder)
+		require.NoError(t, err)
+		require.Empty(t, cfg)
+	})
+}
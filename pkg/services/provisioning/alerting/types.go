@@ -0,0 +1,201 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// rulesAsConfig is normalized data object for alert rules config data. Any config version should be mappable
+// to this type.
+type rulesAsConfig struct {
+	Groups      []*ruleGroupFromConfig
+	DeleteRules []*deleteRuleConfig
+}
+
+type ruleGroupFromConfig struct {
+	OrgID     int64
+	FolderUID string
+	Name      string
+	Interval  time.Duration
+	Rules     []*ruleFromConfig
+}
+
+type ruleFromConfig struct {
+	UID           string
+	Title         string
+	Condition     string
+	Data          []ngmodels.AlertQuery
+	NoDataState   string
+	ExecErrState  string
+	For           time.Duration
+	KeepFiringFor time.Duration
+	Annotations   map[string]string
+	Labels        map[string]string
+	IsPaused      bool
+}
+
+type deleteRuleConfig struct {
+	OrgID int64
+	UID   string
+}
+
+// rulesAsConfigV0 is mapping for zero version configs. This is mapped to its normalised version.
+type rulesAsConfigV0 struct {
+	Groups      []*ruleGroupFromConfigV0 `json:"groups" yaml:"groups"`
+	DeleteRules []*deleteRuleConfigV0    `json:"delete_rules" yaml:"delete_rules"`
+}
+
+type ruleGroupFromConfigV0 struct {
+	OrgID     values.Int64Value   `json:"org_id" yaml:"org_id"`
+	FolderUID values.StringValue  `json:"folder_uid" yaml:"folder_uid"`
+	Name      values.StringValue  `json:"name" yaml:"name"`
+	Interval  values.StringValue  `json:"interval" yaml:"interval"`
+	Rules     []*ruleFromConfigV0 `json:"rules" yaml:"rules"`
+}
+
+type ruleFromConfigV0 struct {
+	UID           values.StringValue    `json:"uid" yaml:"uid"`
+	Title         values.StringValue    `json:"title" yaml:"title"`
+	Condition     values.StringValue    `json:"condition" yaml:"condition"`
+	Data          []values.JSONValue    `json:"data" yaml:"data"`
+	NoDataState   values.StringValue    `json:"no_data_state" yaml:"no_data_state"`
+	ExecErrState  values.StringValue    `json:"exec_err_state" yaml:"exec_err_state"`
+	For           values.StringValue    `json:"for" yaml:"for"`
+	KeepFiringFor values.StringValue    `json:"keep_firing_for" yaml:"keep_firing_for"`
+	Annotations   values.StringMapValue `json:"annotations" yaml:"annotations"`
+	Labels        values.StringMapValue `json:"labels" yaml:"labels"`
+	IsPaused      values.BoolValue      `json:"is_paused" yaml:"is_paused"`
+}
+
+type deleteRuleConfigV0 struct {
+	OrgID values.Int64Value  `json:"org_id" yaml:"org_id"`
+	UID   values.StringValue `json:"uid" yaml:"uid"`
+}
+
+// mapToRulesFromConfig maps config syntax to normalized rulesAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *rulesAsConfigV0) mapToRulesFromConfig() (*rulesAsConfig, error) {
+	r := &rulesAsConfig{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, group := range cfg.Groups {
+		interval, err := parseDuration(group.Interval.Value())
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval of rule group %q: %w", group.Name.Value(), err)
+		}
+
+		g := &ruleGroupFromConfig{
+			OrgID:     group.OrgID.Value(),
+			FolderUID: group.FolderUID.Value(),
+			Name:      group.Name.Value(),
+			Interval:  interval,
+		}
+		for _, rule := range group.Rules {
+			rr, err := rule.mapToRuleFromConfig()
+			if err != nil {
+				return nil, fmt.Errorf("invalid alert rule %q: %w", rule.Title.Value(), err)
+			}
+			g.Rules = append(g.Rules, rr)
+		}
+		r.Groups = append(r.Groups, g)
+	}
+
+	for _, rule := range cfg.DeleteRules {
+		r.DeleteRules = append(r.DeleteRules, &deleteRuleConfig{
+			OrgID: rule.OrgID.Value(),
+			UID:   rule.UID.Value(),
+		})
+	}
+
+	return r, nil
+}
+
+func (rule *ruleFromConfigV0) mapToRuleFromConfig() (*ruleFromConfig, error) {
+	forDuration, err := parseDuration(rule.For.Value())
+	if err != nil {
+		return nil, fmt.Errorf("invalid for: %w", err)
+	}
+	keepFiringFor, err := parseDuration(rule.KeepFiringFor.Value())
+	if err != nil {
+		return nil, fmt.Errorf("invalid keep_firing_for: %w", err)
+	}
+
+	// the queries are decoded like the ones of the ruler API
+	queries := make([]ngmodels.AlertQuery, 0, len(rule.Data))
+	for _, query := range rule.Data {
+		b, err := json.Marshal(query.Value())
+		if err != nil {
+			return nil, err
+		}
+		var q ngmodels.AlertQuery
+		if err := json.Unmarshal(b, &q); err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		queries = append(queries, q)
+	}
+
+	return &ruleFromConfig{
+		UID:           rule.UID.Value(),
+		Title:         rule.Title.Value(),
+		Condition:     rule.Condition.Value(),
+		Data:          queries,
+		NoDataState:   rule.NoDataState.Value(),
+		ExecErrState:  rule.ExecErrState.Value(),
+		For:           forDuration,
+		KeepFiringFor: keepFiringFor,
+		Annotations:   rule.Annotations.Value(),
+		Labels:        rule.Labels.Value(),
+		IsPaused:      rule.IsPaused.Value(),
+	}, nil
+}
+
+// parseDuration parses a duration in the Prometheus format, an empty duration is 0.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := model.ParseDuration(s)
+	return time.Duration(d), err
+}
+
+// alertRules returns the rules of the group as they are stored. The paused state of the rules
+// is always set, the provisioning files are authoritative.
+func (group *ruleGroupFromConfig) alertRules() []ngmodels.AlertRule {
+	rules := make([]ngmodels.AlertRule, 0, len(group.Rules))
+	for _, rule := range group.Rules {
+		r := ngmodels.AlertRule{
+			OrgID:           group.OrgID,
+			UID:             rule.UID,
+			Title:           rule.Title,
+			Condition:       rule.Condition,
+			Data:            rule.Data,
+			IntervalSeconds: int64(group.Interval.Seconds()),
+			NamespaceUID:    group.FolderUID,
+			RuleGroup:       group.Name,
+			NoDataState:     ngmodels.NoDataState(rule.NoDataState),
+			ExecErrState:    ngmodels.ExecutionErrorState(rule.ExecErrState),
+			For:             rule.For,
+			KeepFiringFor:   rule.KeepFiringFor,
+			Annotations:     rule.Annotations,
+			Labels:          rule.Labels,
+			IsPaused:        rule.IsPaused,
+		}
+		// the rules get the default states of the new rules when they are not provisioned
+		if r.NoDataState == "" {
+			r.NoDataState = ngmodels.NoData
+		}
+		if r.ExecErrState == "" {
+			r.ExecErrState = ngmodels.AlertingErrState
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
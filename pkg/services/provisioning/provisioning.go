@@ -4,10 +4,13 @@ import (
 	"context"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	plugifaces "github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
@@ -24,6 +27,7 @@ type ProvisioningService interface {
 	ProvisionPlugins() error
 	ProvisionNotifications() error
 	ProvisionDashboards() error
+	ProvisionAlertRules() error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 }
@@ -44,6 +48,7 @@ func NewProvisioningServiceImpl() *provisioningServiceImpl {
 		provisionNotifiers:      notifiers.Provision,
 		provisionDatasources:    datasources.Provision,
 		provisionPlugins:        plugins.Provision,
+		provisionAlertRules:     alerting.Provision,
 	}
 }
 
@@ -53,6 +58,7 @@ func newProvisioningServiceImpl(
 	provisionNotifiers func(string) error,
 	provisionDatasources func(string) error,
 	provisionPlugins func(string, plugifaces.Manager) error,
+	provisionAlertRules func(string, alerting.RuleStore) error,
 ) *provisioningServiceImpl {
 	return &provisioningServiceImpl{
 		log:                     log.New("provisioning"),
@@ -60,6 +66,7 @@ func newProvisioningServiceImpl(
 		provisionNotifiers:      provisionNotifiers,
 		provisionDatasources:    provisionDatasources,
 		provisionPlugins:        provisionPlugins,
+		provisionAlertRules:     provisionAlertRules,
 	}
 }
 
@@ -74,6 +81,7 @@ type provisioningServiceImpl struct {
 	provisionNotifiers      func(string) error
 	provisionDatasources    func(string) error
 	provisionPlugins        func(string, plugifaces.Manager) error
+	provisionAlertRules     func(string, alerting.RuleStore) error
 	mutex                   sync.Mutex
 }
 
@@ -107,6 +115,13 @@ func (ps *provisioningServiceImpl) Run(ctx context.Context) error {
 		return err
 	}
 
+	// the alert rules are provisioned once the folders they belong to are provisioned
+	err = ps.ProvisionAlertRules()
+	if err != nil {
+		ps.log.Error("Failed to provision alert rules", "error", err)
+		return err
+	}
+
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
 		ps.mutex.Lock()
@@ -170,6 +185,23 @@ func (ps *provisioningServiceImpl) ProvisionDashboards() error {
 	return nil
 }
 
+func (ps *provisioningServiceImpl) ProvisionAlertRules() error {
+	// the alert rules belong to the unified alerting, its tables only exist when it is enabled
+	if !ps.Cfg.IsNgAlertEnabled() {
+		return nil
+	}
+
+	alertRulesPath := filepath.Join(ps.Cfg.ProvisioningPath, "alerting")
+	ruleStore := &store.DBstore{
+		BaseInterval:           store.BaseIntervalSeconds * time.Second,
+		DefaultIntervalSeconds: store.DefaultIntervalSeconds,
+		SQLStore:               ps.SQLStore,
+		Logger:                 ps.log,
+	}
+	err := ps.provisionAlertRules(alertRulesPath, ruleStore)
+	return errutil.Wrap("Alert rule provisioning error", err)
+}
+
 func (ps *provisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -46,19 +47,31 @@ func (m *migration) addSilence(da dashAlert, rule *alertRule) error {
 		ExpiresAt: time.Now().Add(365 * 20 * time.Hour), // 1 year.
 	}
 
-	m.silences = append(m.silences, s)
+	if _, ok := m.silences[da.OrgId]; !ok {
+		m.silences[da.OrgId] = make([]*pb.MeshSilence, 0)
+	}
+	m.silences[da.OrgId] = append(m.silences[da.OrgId], s)
 	return nil
 }
 
-func (m *migration) writeSilencesFile() error {
+func (m *migration) writeSilencesFile(orgID int64) error {
 	var buf bytes.Buffer
-	for _, e := range m.silences {
+	orgSilences, ok := m.silences[orgID]
+	if !ok {
+		return nil
+	}
+
+	for _, e := range orgSilences {
 		if _, err := pbutil.WriteDelimited(&buf, e); err != nil {
 			return err
 		}
 	}
 
-	f, err := openReplace(silencesFileName(m.mg))
+	if err := os.MkdirAll(filepath.Dir(silencesFileNameForOrg(m.mg, orgID)), 0750); err != nil {
+		return err
+	}
+
+	f, err := openReplace(silencesFileNameForOrg(m.mg, orgID))
 	if err != nil {
 		return err
 	}
@@ -70,8 +83,9 @@ func (m *migration) writeSilencesFile() error {
 	return f.Close()
 }
 
-func silencesFileName(mg *migrator.Migrator) string {
-	return filepath.Join(mg.Cfg.DataPath, "alerting", "silences")
+// silencesFileNameForOrg returns the path of the silences snapshot of the Alertmanager of an organization.
+func silencesFileNameForOrg(mg *migrator.Migrator, orgID int64) string {
+	return filepath.Join(mg.Cfg.DataPath, "alerting", strconv.FormatInt(orgID, 10), "silences")
 }
 
 // replaceFile wraps a file that is moved to another filename on closing.
@@ -56,6 +56,7 @@ func AddDashAlertMigration(mg *migrator.Migrator) {
 		mg.AddMigration(migTitle, &migration{
 			seenChannelUIDs:  make(map[string]struct{}),
 			migratedChannels: make(map[*notificationChannel]struct{}),
+			silences:         make(map[int64][]*pb.MeshSilence),
 		})
 	case !ngEnabled && migrationRun:
 		// Remove the migration entry that creates unified alerting data. This is so when the feature
@@ -76,7 +77,7 @@ type migration struct {
 
 	seenChannelUIDs  map[string]struct{}
 	migratedChannels map[*notificationChannel]struct{}
-	silences         []*pb.MeshSilence
+	silences         map[int64][]*pb.MeshSilence
 }
 
 func (m *migration) SQL(dialect migrator.Dialect) string {
@@ -104,14 +105,14 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		return err
 	}
 
-	// allChannels: channelUID -> channelConfig
-	allChannels, defaultChannels, err := m.getNotificationChannelMap()
+	// allChannels: orgID -> channelUID -> channelConfig
+	allChannelsPerOrg, defaultChannelsPerOrg, err := m.getNotificationChannelMap()
 	if err != nil {
 		return err
 	}
 
-	amConfig := PostableUserConfig{}
-	amConfig.AlertmanagerConfig.Route = &Route{}
+	// amConfigPerOrg: orgID -> Alertmanager configuration
+	amConfigPerOrg := make(map[int64]*PostableUserConfig)
 
 	for _, da := range dashAlerts {
 		newCond, err := transConditions(*da.ParsedSettings, da.OrgId, dsIDMap)
@@ -214,7 +215,12 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 			return err
 		}
 
-		if err := m.updateReceiverAndRoute(allChannels, defaultChannels, da, rule, &amConfig); err != nil {
+		if _, ok := amConfigPerOrg[rule.OrgId]; !ok {
+			amConfigPerOrg[rule.OrgId] = newPostableUserConfig()
+		}
+		amConfig := amConfigPerOrg[rule.OrgId]
+
+		if err := m.updateReceiverAndRoute(allChannelsPerOrg[rule.OrgId], defaultChannelsPerOrg[rule.OrgId], da, rule, amConfig); err != nil {
 			return err
 		}
 
@@ -237,16 +243,37 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		}
 	}
 
-	// Create a separate receiver for all the unmigrated channels.
-	err = m.updateDefaultAndUnmigratedChannels(&amConfig, allChannels, defaultChannels)
-	if err != nil {
-		return err
+	// Organizations with notification channels but no alerts still need their channels migrated.
+	for orgID := range allChannelsPerOrg {
+		if _, ok := amConfigPerOrg[orgID]; !ok {
+			amConfigPerOrg[orgID] = newPostableUserConfig()
+		}
+	}
+
+	for orgID, amConfig := range amConfigPerOrg {
+		// Create a separate receiver for all the unmigrated channels.
+		err = m.updateDefaultAndUnmigratedChannels(amConfig, allChannelsPerOrg[orgID], defaultChannelsPerOrg[orgID])
+		if err != nil {
+			return err
+		}
+
+		if err := m.writeAlertmanagerConfig(orgID, amConfig); err != nil {
+			return err
+		}
+
+		if err := m.writeSilencesFile(orgID); err != nil {
+			m.mg.Logger.Error("alert migration error: failed to write silence file", "err", err, "org", orgID)
+		}
 	}
 
+	return nil
+}
+
+func (m *migration) writeAlertmanagerConfig(orgID int64, amConfig *PostableUserConfig) error {
 	if err := amConfig.EncryptSecureSettings(); err != nil {
 		return err
 	}
-	rawAmConfig, err := json.Marshal(&amConfig)
+	rawAmConfig, err := json.Marshal(amConfig)
 	if err != nil {
 		return err
 	}
@@ -257,16 +284,9 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		// Since we are migration for a snapshot of the code, it is always going to migrate to
 		// the v1 config.
 		ConfigurationVersion: "v1",
+		OrgID:                orgID,
 	})
-	if err != nil {
-		return err
-	}
-
-	if err := m.writeSilencesFile(); err != nil {
-		m.mg.Logger.Error("alert migration error: failed to write silence file", "err", err)
-	}
-
-	return nil
+	return err
 }
 
 type AlertConfiguration struct {
@@ -275,6 +295,7 @@ type AlertConfiguration struct {
 	AlertmanagerConfiguration string
 	ConfigurationVersion      string
 	CreatedAt                 time.Time `xorm:"created"`
+	OrgID                     int64     `xorm:"org_id"`
 }
 
 type rmMigration struct {
@@ -316,8 +337,14 @@ func (m *rmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		return err
 	}
 
-	if err := os.RemoveAll(silencesFileName(mg)); err != nil {
-		mg.Logger.Error("alert migration error: failed to remove silence file", "err", err)
+	orgIDs := make([]int64, 0)
+	if err := sess.SQL("SELECT id FROM org").Find(&orgIDs); err != nil {
+		return err
+	}
+	for _, orgID := range orgIDs {
+		if err := os.RemoveAll(silencesFileNameForOrg(mg, orgID)); err != nil {
+			mg.Logger.Error("alert migration error: failed to remove silence file", "err", err, "org", orgID)
+		}
 	}
 
 	return nil
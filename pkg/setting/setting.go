@@ -385,6 +385,55 @@ type Cfg struct {
 	// Grafana Live ws endpoint (per Grafana server instance). 0 disables
 	// Live, -1 means unlimited connections.
 	LiveMaxConnections int
+	// LiveHAEngine is the engine used to share Grafana Live publications,
+	// presence and stream state between Grafana server instances. Empty
+	// means in-memory, with no sharing between instances.
+	LiveHAEngine string
+	// LiveHAEngineConnStr is the connection string of the HA engine, in the
+	// format of the [remote_cache] redis connstr.
+	LiveHAEngineConnStr string
+	// LiveHistoryMaxCount is the maximum number of frames kept in the history
+	// of each managed stream channel. 0 disables the history.
+	LiveHistoryMaxCount int
+	// LiveHistoryMaxAge is the maximum age of the frames kept in the history
+	// of each managed stream channel. 0 means no age limit.
+	LiveHistoryMaxAge time.Duration
+	// LiveHistoryPersist makes the history of the managed stream channels
+	// persisted in the database, so that it survives restarts.
+	LiveHistoryPersist bool
+	// LiveStreamPublishInterval is the default minimum interval between two
+	// publications of a managed stream channel to its subscribers. 0 disables
+	// the throttling.
+	LiveStreamPublishInterval time.Duration
+	// LiveStreamAggregation is how the frames pushed to a throttled channel
+	// between two publications are combined, latest or mean.
+	LiveStreamAggregation string
+
+	// Unified Alerting
+	HAListenAddr       string
+	HAAdvertiseAddr    string
+	HAPeers            []string
+	HAPeerTimeout      time.Duration
+	HAGossipInterval   time.Duration
+	HAPushPullInterval time.Duration
+	// HADistributeRuleEvaluation shards the evaluation of the alert rules across the instances of the HA cluster.
+	HADistributeRuleEvaluation bool
+	// EvaluationJitterEnabled spreads the evaluations of the alert rules with the same interval over the interval.
+	EvaluationJitterEnabled bool
+	// MaxConcurrentEvaluationsPerDatasource bounds the concurrent evaluations of alert rules querying a data source, 0 is unlimited.
+	MaxConcurrentEvaluationsPerDatasource int
+	// QueryCacheTTL is for how long the results of the queries of alert rules are shared with the rules
+	// running the same queries, bounded by the interval of the rules. 0 disables the cache.
+	QueryCacheTTL time.Duration
+	// StateHistoryEnabled records the state transitions of alert instances in the database.
+	StateHistoryEnabled bool
+	// StateHistoryMaxAge is how long the state transitions are kept in the database, 0 keeps them forever.
+	StateHistoryMaxAge time.Duration
+	// RecordingRulesRemoteWriteURL is the Prometheus remote write endpoint the results of recording rules are written to.
+	RecordingRulesRemoteWriteURL      string
+	RecordingRulesRemoteWriteUser     string
+	RecordingRulesRemoteWritePassword string
+	RecordingRulesRemoteWriteTimeout  time.Duration
 }
 
 // IsLiveConfigEnabled returns true if live should be able to save configs to SQL tables
@@ -964,6 +1013,10 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		return err
 	}
 
+	if err := cfg.readUnifiedAlertingSettings(iniFile); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -1441,5 +1494,51 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 	if cfg.LiveMaxConnections < -1 {
 		return fmt.Errorf("unexpected value %d for [live] max_connections", cfg.LiveMaxConnections)
 	}
+
+	cfg.LiveHistoryMaxCount = section.Key("history_max_count").MustInt(0)
+	if cfg.LiveHistoryMaxCount < 0 {
+		return fmt.Errorf("unexpected value %d for [live] history_max_count", cfg.LiveHistoryMaxCount)
+	}
+	historyMaxAge, err := gtime.ParseDuration(valueAsString(section, "history_max_age", "10m"))
+	if err != nil {
+		return fmt.Errorf("unexpected value for [live] history_max_age: %w", err)
+	}
+	if historyMaxAge < 0 {
+		return fmt.Errorf("unexpected value %s for [live] history_max_age", historyMaxAge)
+	}
+	cfg.LiveHistoryMaxAge = historyMaxAge
+	cfg.LiveHistoryPersist = section.Key("history_persist").MustBool(false)
+
+	streamPublishInterval, err := gtime.ParseDuration(valueAsString(section, "stream_publish_interval", "0"))
+	if err != nil {
+		return fmt.Errorf("unexpected value for [live] stream_publish_interval: %w", err)
+	}
+	if streamPublishInterval < 0 {
+		return fmt.Errorf("unexpected value %s for [live] stream_publish_interval", streamPublishInterval)
+	}
+	cfg.LiveStreamPublishInterval = streamPublishInterval
+	cfg.LiveStreamAggregation = section.Key("stream_aggregation").MustString("latest")
+	switch cfg.LiveStreamAggregation {
+	case "latest", "mean":
+	default:
+		return fmt.Errorf("unsupported [live] stream_aggregation %q, use latest or mean", cfg.LiveStreamAggregation)
+	}
+
+	cfg.LiveHAEngine = section.Key("ha_engine").MustString("")
+	switch cfg.LiveHAEngine {
+	case "":
+		return nil
+	case "redis":
+	default:
+		return fmt.Errorf("unsupported [live] ha_engine %q, only redis is supported", cfg.LiveHAEngine)
+	}
+	cfg.LiveHAEngineConnStr = section.Key("ha_engine_connstr").MustString("")
+	if cfg.LiveHAEngineConnStr == "" && cfg.RemoteCacheOptions != nil && cfg.RemoteCacheOptions.Name == "redis" {
+		// Use the Redis server of the remote cache.
+		cfg.LiveHAEngineConnStr = cfg.RemoteCacheOptions.ConnStr
+	}
+	if cfg.LiveHAEngineConnStr == "" {
+		return errors.New("[live] ha_engine_connstr is required by the redis ha_engine when [remote_cache] is not redis")
+	}
 	return nil
 }
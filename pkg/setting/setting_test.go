@@ -417,3 +417,93 @@ func TestGetCDNPathWithAlphaVersion(t *testing.T) {
 	require.Equal(t, "http://cdn.grafana.com/grafana-oss/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana-oss"))
 	require.Equal(t, "http://cdn.grafana.com/grafana/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana"))
 }
+
+func TestLiveSettings(t *testing.T) {
+	newFile := func(t *testing.T, keys map[string]string) *ini.File {
+		f := ini.Empty()
+		sec, err := f.NewSection("live")
+		require.NoError(t, err)
+		for k, v := range keys {
+			_, err := sec.NewKey(k, v)
+			require.NoError(t, err)
+		}
+		return f
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readLiveSettings(ini.Empty()))
+		require.Equal(t, 100, cfg.LiveMaxConnections)
+		require.Equal(t, "", cfg.LiveHAEngine)
+		require.Equal(t, "", cfg.LiveHAEngineConnStr)
+		require.Equal(t, 0, cfg.LiveHistoryMaxCount)
+		require.Equal(t, 10*time.Minute, cfg.LiveHistoryMaxAge)
+		require.False(t, cfg.LiveHistoryPersist)
+		require.Equal(t, time.Duration(0), cfg.LiveStreamPublishInterval)
+		require.Equal(t, "latest", cfg.LiveStreamAggregation)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readLiveSettings(newFile(t, map[string]string{
+			"history_max_count": "1000",
+			"history_max_age":   "1h",
+			"history_persist":   "true",
+		})))
+		require.Equal(t, 1000, cfg.LiveHistoryMaxCount)
+		require.Equal(t, time.Hour, cfg.LiveHistoryMaxAge)
+		require.True(t, cfg.LiveHistoryPersist)
+	})
+
+	t.Run("invalid history", func(t *testing.T) {
+		cfg := NewCfg()
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"history_max_count": "-1"})))
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"history_max_age": "recent"})))
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"history_max_age": "-1m"})))
+	})
+
+	t.Run("stream throttling", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readLiveSettings(newFile(t, map[string]string{
+			"stream_publish_interval": "500ms",
+			"stream_aggregation":      "mean",
+		})))
+		require.Equal(t, 500*time.Millisecond, cfg.LiveStreamPublishInterval)
+		require.Equal(t, "mean", cfg.LiveStreamAggregation)
+	})
+
+	t.Run("invalid stream throttling", func(t *testing.T) {
+		cfg := NewCfg()
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"stream_publish_interval": "often"})))
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"stream_publish_interval": "-1s"})))
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"stream_aggregation": "max"})))
+	})
+
+	t.Run("redis engine", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readLiveSettings(newFile(t, map[string]string{
+			"ha_engine":         "redis",
+			"ha_engine_connstr": "addr=redis:6379,db=1",
+		})))
+		require.Equal(t, "redis", cfg.LiveHAEngine)
+		require.Equal(t, "addr=redis:6379,db=1", cfg.LiveHAEngineConnStr)
+	})
+
+	t.Run("redis engine uses the redis remote cache", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.RemoteCacheOptions = &RemoteCacheOptions{Name: "redis", ConnStr: "addr=cache:6379"}
+		require.NoError(t, cfg.readLiveSettings(newFile(t, map[string]string{"ha_engine": "redis"})))
+		require.Equal(t, "addr=cache:6379", cfg.LiveHAEngineConnStr)
+	})
+
+	t.Run("redis engine without a connection string", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.RemoteCacheOptions = &RemoteCacheOptions{Name: "database"}
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"ha_engine": "redis"})))
+	})
+
+	t.Run("unsupported engine", func(t *testing.T) {
+		cfg := NewCfg()
+		require.Error(t, cfg.readLiveSettings(newFile(t, map[string]string{"ha_engine": "nats"})))
+	})
+}
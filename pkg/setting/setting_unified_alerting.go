@@ -0,0 +1,82 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/cluster"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+const (
+	alertmanagerDefaultClusterAddr = "0.0.0.0:9094"
+	alertmanagerDefaultPeerTimeout = 15 * time.Second
+	stateHistoryDefaultMaxAge      = 30 * 24 * time.Hour
+	recordingRulesDefaultTimeout   = 30 * time.Second
+)
+
+func (cfg *Cfg) readUnifiedAlertingSettings(iniFile *ini.File) error {
+	ua := iniFile.Section("unified_alerting")
+	cfg.HAListenAddr = ua.Key("ha_listen_address").MustString(alertmanagerDefaultClusterAddr)
+	cfg.HAAdvertiseAddr = ua.Key("ha_advertise_address").MustString("")
+
+	peers := ua.Key("ha_peers").MustString("")
+	cfg.HAPeers = make([]string, 0)
+	if peers != "" {
+		for _, peer := range strings.Split(peers, ",") {
+			peer = strings.TrimSpace(peer)
+			if peer != "" {
+				cfg.HAPeers = append(cfg.HAPeers, peer)
+			}
+		}
+	}
+
+	var err error
+	if cfg.HAPeerTimeout, err = readUnifiedAlertingDuration(ua, "ha_peer_timeout", alertmanagerDefaultPeerTimeout); err != nil {
+		return err
+	}
+	if cfg.HAGossipInterval, err = readUnifiedAlertingDuration(ua, "ha_gossip_interval", cluster.DefaultGossipInterval); err != nil {
+		return err
+	}
+	if cfg.HAPushPullInterval, err = readUnifiedAlertingDuration(ua, "ha_push_pull_interval", cluster.DefaultPushPullInterval); err != nil {
+		return err
+	}
+	cfg.HADistributeRuleEvaluation = ua.Key("ha_distribute_rule_evaluation").MustBool(false)
+
+	cfg.EvaluationJitterEnabled = ua.Key("evaluation_jitter_enabled").MustBool(false)
+	cfg.MaxConcurrentEvaluationsPerDatasource = ua.Key("max_concurrent_evaluations_per_datasource").MustInt(0)
+	if cfg.MaxConcurrentEvaluationsPerDatasource < 0 {
+		return fmt.Errorf("unexpected value for [unified_alerting] max_concurrent_evaluations_per_datasource: %d, it must not be negative", cfg.MaxConcurrentEvaluationsPerDatasource)
+	}
+	if cfg.QueryCacheTTL, err = readUnifiedAlertingDuration(ua, "query_cache_ttl", 0); err != nil {
+		return err
+	}
+	if cfg.QueryCacheTTL < 0 {
+		return fmt.Errorf("unexpected value for [unified_alerting] query_cache_ttl: %s, it must not be negative", cfg.QueryCacheTTL)
+	}
+
+	cfg.StateHistoryEnabled = ua.Key("state_history_enabled").MustBool(false)
+	if cfg.StateHistoryMaxAge, err = readUnifiedAlertingDuration(ua, "state_history_max_age", stateHistoryDefaultMaxAge); err != nil {
+		return err
+	}
+
+	cfg.RecordingRulesRemoteWriteURL = ua.Key("recording_rules_remote_write_url").MustString("")
+	cfg.RecordingRulesRemoteWriteUser = ua.Key("recording_rules_remote_write_basic_auth_user").MustString("")
+	cfg.RecordingRulesRemoteWritePassword = ua.Key("recording_rules_remote_write_basic_auth_password").MustString("")
+	if cfg.RecordingRulesRemoteWriteTimeout, err = readUnifiedAlertingDuration(ua, "recording_rules_remote_write_timeout", recordingRulesDefaultTimeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func readUnifiedAlertingDuration(section *ini.Section, key string, defaultValue time.Duration) (time.Duration, error) {
+	d, err := gtime.ParseDuration(valueAsString(section, key, defaultValue.String()))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected value for [unified_alerting] %s: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,113 @@
+package setting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestUnifiedAlertingSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readUnifiedAlertingSettings(ini.Empty()))
+
+		require.Equal(t, "0.0.0.0:9094", cfg.HAListenAddr)
+		require.Equal(t, "", cfg.HAAdvertiseAddr)
+		require.Empty(t, cfg.HAPeers)
+		require.Equal(t, 15*time.Second, cfg.HAPeerTimeout)
+		require.Equal(t, 200*time.Millisecond, cfg.HAGossipInterval)
+		require.Equal(t, time.Minute, cfg.HAPushPullInterval)
+		require.False(t, cfg.HADistributeRuleEvaluation)
+		require.False(t, cfg.EvaluationJitterEnabled)
+		require.Equal(t, 0, cfg.MaxConcurrentEvaluationsPerDatasource)
+		require.Equal(t, time.Duration(0), cfg.QueryCacheTTL)
+		require.False(t, cfg.StateHistoryEnabled)
+		require.Equal(t, 30*24*time.Hour, cfg.StateHistoryMaxAge)
+		require.Equal(t, "", cfg.RecordingRulesRemoteWriteURL)
+		require.Equal(t, 30*time.Second, cfg.RecordingRulesRemoteWriteTimeout)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		f := ini.Empty()
+		sec, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ha_listen_address", "127.0.0.1:9095")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ha_peers", "grafana-1:9095, grafana-2:9095,")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ha_peer_timeout", "30s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ha_push_pull_interval", "2m")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ha_distribute_rule_evaluation", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("evaluation_jitter_enabled", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_concurrent_evaluations_per_datasource", "4")
+		require.NoError(t, err)
+		_, err = sec.NewKey("query_cache_ttl", "30s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("state_history_enabled", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("state_history_max_age", "1w")
+		require.NoError(t, err)
+		_, err = sec.NewKey("recording_rules_remote_write_url", "http://prometheus:9090/api/v1/write")
+		require.NoError(t, err)
+		_, err = sec.NewKey("recording_rules_remote_write_basic_auth_user", "grafana")
+		require.NoError(t, err)
+		_, err = sec.NewKey("recording_rules_remote_write_timeout", "10s")
+		require.NoError(t, err)
+
+		cfg := NewCfg()
+		require.NoError(t, cfg.readUnifiedAlertingSettings(f))
+
+		require.Equal(t, "127.0.0.1:9095", cfg.HAListenAddr)
+		require.Equal(t, []string{"grafana-1:9095", "grafana-2:9095"}, cfg.HAPeers)
+		require.Equal(t, 30*time.Second, cfg.HAPeerTimeout)
+		require.Equal(t, 2*time.Minute, cfg.HAPushPullInterval)
+		require.True(t, cfg.HADistributeRuleEvaluation)
+		require.True(t, cfg.EvaluationJitterEnabled)
+		require.Equal(t, 4, cfg.MaxConcurrentEvaluationsPerDatasource)
+		require.Equal(t, 30*time.Second, cfg.QueryCacheTTL)
+		require.True(t, cfg.StateHistoryEnabled)
+		require.Equal(t, 7*24*time.Hour, cfg.StateHistoryMaxAge)
+		require.Equal(t, "http://prometheus:9090/api/v1/write", cfg.RecordingRulesRemoteWriteURL)
+		require.Equal(t, "grafana", cfg.RecordingRulesRemoteWriteUser)
+		require.Equal(t, 10*time.Second, cfg.RecordingRulesRemoteWriteTimeout)
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		f := ini.Empty()
+		sec, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ha_gossip_interval", "often")
+		require.NoError(t, err)
+
+		cfg := NewCfg()
+		require.Error(t, cfg.readUnifiedAlertingSettings(f))
+	})
+
+	t.Run("negative concurrent evaluations", func(t *testing.T) {
+		f := ini.Empty()
+		sec, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_concurrent_evaluations_per_datasource", "-1")
+		require.NoError(t, err)
+
+		cfg := NewCfg()
+		require.Error(t, cfg.readUnifiedAlertingSettings(f))
+	})
+
+	t.Run("negative query cache ttl", func(t *testing.T) {
+		f := ini.Empty()
+		sec, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = sec.NewKey("query_cache_ttl", "-10s")
+		require.NoError(t, err)
+
+		cfg := NewCfg()
+		require.Error(t, cfg.readUnifiedAlertingSettings(f))
+	})
+}
@@ -1,6 +1,6 @@
-import React, { FC } from 'react';
+import React, { ChangeEvent, FC } from 'react';
 import { SelectableValue } from '@grafana/data';
-import { Button, Icon, InlineField, InlineFieldRow } from '@grafana/ui';
+import { Button, Icon, InlineField, InlineFieldRow, InlineSwitch } from '@grafana/ui';
 import { Condition } from './Condition';
 import { ClassicCondition, ExpressionQuery } from '../types';
 import { defaultCondition } from '../utils/expressionTypes';
@@ -53,8 +53,24 @@ export const ClassicConditions: FC<Props> = ({ onChange, query, refIds }) => {
     }
   };
 
+  const onMultiDimensionalChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({
+      ...query,
+      multiDimensional: event.currentTarget.checked,
+    });
+  };
+
   return (
     <div>
+      <InlineFieldRow>
+        <InlineField
+          label="Alert per series"
+          labelWidth={14}
+          tooltip="Evaluate the conditions for each set of labels of the series, instead of a single alert for all the series"
+        >
+          <InlineSwitch value={!!query.multiDimensional} onChange={onMultiDimensionalChange} />
+        </InlineField>
+      </InlineFieldRow>
       <InlineFieldRow>
         <InlineField label="Conditions" labelWidth={14}>
           <div>
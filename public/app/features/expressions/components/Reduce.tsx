@@ -1,7 +1,7 @@
-import React, { FC } from 'react';
+import React, { ChangeEvent, FC } from 'react';
 import { SelectableValue } from '@grafana/data';
-import { InlineField, InlineFieldRow, Select } from '@grafana/ui';
-import { ExpressionQuery, reducerTypes } from '../types';
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+import { ExpressionQuery, reduceModes, ReduceMode, reducerTypes } from '../types';
 
 interface Props {
   labelWidth: number;
@@ -12,6 +12,7 @@ interface Props {
 
 export const Reduce: FC<Props> = ({ labelWidth, onChange, refIds, query }) => {
   const reducer = reducerTypes.find((o) => o.value === query.reducer);
+  const mode = query.settings?.mode ?? ReduceMode.Strict;
 
   const onRefIdChange = (value: SelectableValue<string>) => {
     onChange({ ...query, expression: value.value });
@@ -21,6 +22,25 @@ export const Reduce: FC<Props> = ({ labelWidth, onChange, refIds, query }) => {
     onChange({ ...query, reducer: value.value });
   };
 
+  const onSelectMode = (value: SelectableValue<ReduceMode>) => {
+    const newMode = value.value ?? ReduceMode.Strict;
+    onChange({
+      ...query,
+      settings: {
+        mode: newMode,
+        replaceWithValue: newMode === ReduceMode.ReplaceNonNumbers ? query.settings?.replaceWithValue ?? 0 : undefined,
+      },
+    });
+  };
+
+  const onReplaceWithValueChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    onChange({
+      ...query,
+      settings: { mode: ReduceMode.ReplaceNonNumbers, replaceWithValue: isNaN(value) ? 0 : value },
+    });
+  };
+
   return (
     <InlineFieldRow>
       <InlineField label="Function" labelWidth={labelWidth}>
@@ -29,6 +49,14 @@ export const Reduce: FC<Props> = ({ labelWidth, onChange, refIds, query }) => {
       <InlineField label="Input" labelWidth={labelWidth}>
         <Select onChange={onRefIdChange} options={refIds} value={query.expression} width={20} />
       </InlineField>
+      <InlineField label="Mode" labelWidth={labelWidth}>
+        <Select onChange={onSelectMode} options={reduceModes} value={mode} width={25} />
+      </InlineField>
+      {mode === ReduceMode.ReplaceNonNumbers && (
+        <InlineField label="Replace with" labelWidth={labelWidth}>
+          <Input type="number" onChange={onReplaceWithValueChange} value={query.settings?.replaceWithValue} width={10} />
+        </InlineField>
+      )}
     </InlineFieldRow>
   );
 };
@@ -30,24 +30,58 @@ export const Resample: FC<Props> = ({ labelWidth, onChange, refIds, query }) =>
     onChange({ ...query, upsampler: value.value });
   };
 
+  const onAlignToChange = (value: SelectableValue<string> | null) => {
+    onChange({ ...query, alignTo: value?.value });
+  };
+
+  const onFillValueChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const fillValue = parseFloat(event.target.value);
+    onChange({ ...query, fillValue: isNaN(fillValue) ? undefined : fillValue });
+  };
+
+  const onMaxGapChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, maxGap: event.target.value });
+  };
+
   return (
     <>
       <InlineFieldRow>
         <InlineField label="Input" labelWidth={labelWidth}>
           <Select onChange={onRefIdChange} options={refIds} value={query.expression} width={20} />
         </InlineField>
+        <InlineField label="Align to" tooltip="Use the timestamps of the series of another query or expression">
+          <Select onChange={onAlignToChange} options={refIds} value={query.alignTo} width={20} isClearable />
+        </InlineField>
       </InlineFieldRow>
       <InlineFieldRow>
-        <InlineField label="Resample to" labelWidth={labelWidth} tooltip="10s, 1m, 30m, 1h">
-          <Input onChange={onWindowChange} value={query.window} width={15} />
-        </InlineField>
-        <InlineField label="Downsample">
-          <Select options={downsamplingTypes} value={downsampler} onChange={onSelectDownsampler} width={25} />
-        </InlineField>
+        {!query.alignTo && (
+          <>
+            <InlineField label="Resample to" labelWidth={labelWidth} tooltip="10s, 1m, 30m, 1h">
+              <Input onChange={onWindowChange} value={query.window} width={15} />
+            </InlineField>
+            <InlineField label="Downsample">
+              <Select options={downsamplingTypes} value={downsampler} onChange={onSelectDownsampler} width={25} />
+            </InlineField>
+          </>
+        )}
         <InlineField label="Upsample">
           <Select options={upsamplingTypes} value={upsampler} onChange={onSelectUpsampler} width={25} />
         </InlineField>
       </InlineFieldRow>
+      <InlineFieldRow>
+        {query.upsampler === 'value' && (
+          <InlineField label="Fill value" labelWidth={labelWidth}>
+            <Input type="number" onChange={onFillValueChange} value={query.fillValue} width={15} />
+          </InlineField>
+        )}
+        <InlineField
+          label="Max gap"
+          labelWidth={query.upsampler === 'value' ? undefined : labelWidth}
+          tooltip="Gaps between points longer than this duration are not filled, for example 5m"
+        >
+          <Input onChange={onMaxGapChange} value={query.maxGap} width={15} />
+        </InlineField>
+      </InlineFieldRow>
     </>
   );
 };
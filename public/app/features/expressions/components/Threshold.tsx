@@ -0,0 +1,74 @@
+import React, { ChangeEvent, FC } from 'react';
+import { SelectableValue } from '@grafana/data';
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+import { EvalFunction } from '../../alerting/state/alertDef';
+import { ExpressionQuery, thresholdFunctions } from '../types';
+import { defaultThresholdCondition } from '../utils/expressionTypes';
+
+interface Props {
+  labelWidth: number;
+  refIds: Array<SelectableValue<string>>;
+  query: ExpressionQuery;
+  onChange: (query: ExpressionQuery) => void;
+}
+
+export const Threshold: FC<Props> = ({ labelWidth, onChange, refIds, query }) => {
+  const condition = query.conditions?.[0] ?? defaultThresholdCondition;
+  const thresholdFunction = thresholdFunctions.find((o) => o.value === condition.evaluator.type);
+  const isRange =
+    condition.evaluator.type === EvalFunction.IsWithinRange || condition.evaluator.type === EvalFunction.IsOutsideRange;
+
+  const onRefIdChange = (value: SelectableValue<string>) => {
+    onChange({ ...query, expression: value.value });
+  };
+
+  const onEvaluatorChange = (type: EvalFunction, params: number[]) => {
+    onChange({ ...query, conditions: [{ ...condition, evaluator: { type, params } }] });
+  };
+
+  const onSelectFunction = (value: SelectableValue<EvalFunction>) => {
+    const type = value.value ?? EvalFunction.IsAbove;
+    const paramsCount = type === EvalFunction.IsWithinRange || type === EvalFunction.IsOutsideRange ? 2 : 1;
+    const params = [...condition.evaluator.params.slice(0, paramsCount)];
+    while (params.length < paramsCount) {
+      params.push(0);
+    }
+    onEvaluatorChange(type, params);
+  };
+
+  const onParamChange = (event: ChangeEvent<HTMLInputElement>, index: number) => {
+    const value = parseFloat(event.target.value);
+    const params = [...condition.evaluator.params];
+    params[index] = isNaN(value) ? 0 : value;
+    onEvaluatorChange(condition.evaluator.type, params);
+  };
+
+  return (
+    <InlineFieldRow>
+      <InlineField label="Input" labelWidth={labelWidth}>
+        <Select onChange={onRefIdChange} options={refIds} value={query.expression} width={20} />
+      </InlineField>
+      <InlineField label="Function" labelWidth={labelWidth}>
+        <Select options={thresholdFunctions} value={thresholdFunction} onChange={onSelectFunction} width={25} />
+      </InlineField>
+      <InlineField label={isRange ? 'From' : 'Threshold'} labelWidth={labelWidth}>
+        <Input
+          type="number"
+          onChange={(event) => onParamChange(event, 0)}
+          value={condition.evaluator.params[0]}
+          width={10}
+        />
+      </InlineField>
+      {isRange && (
+        <InlineField label="To" labelWidth={labelWidth}>
+          <Input
+            type="number"
+            onChange={(event) => onParamChange(event, 1)}
+            value={condition.evaluator.params[1]}
+            width={10}
+          />
+        </InlineField>
+      )}
+    </InlineFieldRow>
+  );
+};
@@ -6,6 +6,7 @@ export enum ExpressionQueryType {
   reduce = 'reduce',
   resample = 'resample',
   classic = 'classic_conditions',
+  threshold = 'threshold',
 }
 
 export const gelTypes: Array<SelectableValue<ExpressionQueryType>> = [
@@ -13,6 +14,7 @@ export const gelTypes: Array<SelectableValue<ExpressionQueryType>> = [
   { value: ExpressionQueryType.reduce, label: 'Reduce' },
   { value: ExpressionQueryType.resample, label: 'Resample' },
   { value: ExpressionQueryType.classic, label: 'Classic condition' },
+  { value: ExpressionQueryType.threshold, label: 'Threshold' },
 ];
 
 export const reducerTypes: Array<SelectableValue<string>> = [
@@ -21,6 +23,42 @@ export const reducerTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.mean, label: 'Mean', description: 'Get the average value' },
   { value: ReducerID.sum, label: 'Sum', description: 'Get the sum of all values' },
   { value: ReducerID.count, label: 'Count', description: 'Get the number of values' },
+  { value: 'count_non_null', label: 'Count non-null', description: 'Get the number of values that are not null or NaN' },
+  { value: ReducerID.last, label: 'Last', description: 'Get the last value' },
+  { value: 'median', label: 'Median', description: 'Get the median value' },
+  { value: 'percentile(90)', label: '90th percentile', description: 'Get the 90th percentile' },
+  { value: 'percentile(95)', label: '95th percentile', description: 'Get the 95th percentile' },
+  { value: 'percentile(99)', label: '99th percentile', description: 'Get the 99th percentile' },
+  { value: 'stddev', label: 'Standard deviation', description: 'Get the standard deviation of the values' },
+  { value: ReducerID.diff, label: 'Diff', description: 'Get the difference between the last and the first values' },
+];
+
+export enum ReduceMode {
+  Strict = 'strict',
+  DropNonNumbers = 'dropNN',
+  ReplaceNonNumbers = 'replaceNN',
+}
+
+export const reduceModes: Array<SelectableValue<ReduceMode>> = [
+  { value: ReduceMode.Strict, label: 'Strict', description: 'Null and NaN values make most results NaN' },
+  { value: ReduceMode.DropNonNumbers, label: 'Drop non-numeric values', description: 'Remove null, NaN and Inf values' },
+  {
+    value: ReduceMode.ReplaceNonNumbers,
+    label: 'Replace non-numeric values',
+    description: 'Replace null, NaN and Inf values with a number',
+  },
+];
+
+export interface ReduceSettings {
+  mode: ReduceMode;
+  replaceWithValue?: number;
+}
+
+export const thresholdFunctions: Array<SelectableValue<EvalFunction>> = [
+  { value: EvalFunction.IsAbove, label: 'Is above', description: 'The value is above the threshold' },
+  { value: EvalFunction.IsBelow, label: 'Is below', description: 'The value is below the threshold' },
+  { value: EvalFunction.IsWithinRange, label: 'Is within range', description: 'The value is between the two bounds' },
+  { value: EvalFunction.IsOutsideRange, label: 'Is outside range', description: 'The value is outside the two bounds' },
 ];
 
 export const downsamplingTypes: Array<SelectableValue<string>> = [
@@ -34,6 +72,8 @@ export const upsamplingTypes: Array<SelectableValue<string>> = [
   { value: 'pad', label: 'pad', description: 'fill with the last known value' },
   { value: 'backfilling', label: 'backfilling', description: 'fill with the next known value' },
   { value: 'fillna', label: 'fillna', description: 'Fill with NaNs' },
+  { value: 'linear', label: 'linear', description: 'interpolate linearly between the known values' },
+  { value: 'value', label: 'value', description: 'fill with a given value' },
 ];
 
 /**
@@ -47,7 +87,12 @@ export interface ExpressionQuery extends DataQuery {
   window?: string;
   downsampler?: string;
   upsampler?: string;
+  alignTo?: string;
+  fillValue?: number;
+  maxGap?: string;
   conditions?: ClassicCondition[];
+  multiDimensional?: boolean;
+  settings?: ReduceSettings;
 }
 export interface ClassicCondition {
   evaluator: {
@@ -29,6 +29,12 @@ export const getDefaults = (query: ExpressionQuery) => {
       }
       break;
 
+    case ExpressionQueryType.threshold:
+      query.conditions = [defaultThresholdCondition];
+      query.expression = undefined;
+      query.reducer = undefined;
+      break;
+
     default:
       query.reducer = undefined;
   }
@@ -51,3 +57,15 @@ export const defaultCondition: ClassicCondition = {
     type: EvalFunction.IsAbove,
   },
 };
+
+/**
+ * The threshold expression only reads the evaluator of its condition, the other
+ * fields keep the condition valid when the expression is changed to a classic condition.
+ */
+export const defaultThresholdCondition: ClassicCondition = {
+  ...defaultCondition,
+  evaluator: {
+    params: [0],
+    type: EvalFunction.IsAbove,
+  },
+};
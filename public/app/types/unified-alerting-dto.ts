@@ -40,6 +40,7 @@ export interface PromAlertingRuleDTO extends PromRuleDTOBase {
     state: Exclude<PromAlertingRuleState | GrafanaAlertState, PromAlertingRuleState.Inactive>;
     activeAt: string;
     value: string;
+    values?: Record<string, { labels?: Labels; value: number | null }>;
   }>;
   labels: Labels;
   annotations: Annotations;
@@ -116,6 +117,8 @@ export interface PostableGrafanaRuleDefinition {
   no_data_state: GrafanaAlertStateDecision;
   exec_err_state: GrafanaAlertStateDecision;
   data: AlertQuery[];
+  is_paused?: boolean;
+  record?: string;
 }
 export interface GrafanaRuleDefinition extends PostableGrafanaRuleDefinition {
   uid: string;
@@ -125,6 +128,7 @@ export interface GrafanaRuleDefinition extends PostableGrafanaRuleDefinition {
 export interface RulerGrafanaRuleDTO {
   grafana_alert: GrafanaRuleDefinition;
   for: string;
+  keep_firing_for?: string;
   annotations: Annotations;
   labels: Labels;
 }
@@ -132,6 +136,7 @@ export interface RulerGrafanaRuleDTO {
 export interface PostableRuleGrafanaRuleDTO {
   grafana_alert: PostableGrafanaRuleDefinition;
   for: string;
+  keep_firing_for?: string;
   annotations: Annotations;
   labels: Labels;
 }